@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	github.com/TeneoProtocolAI/teneo-agent-sdk v0.3.0
 	github.com/joho/godotenv v1.5.1
+	golang.org/x/time v0.9.0
 )
 
 require (
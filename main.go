@@ -1,173 +1,1950 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
+	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/aeemayo/mood_analyst/mood"
 	"github.com/aeemayo/mood_analyst/spotify"
 
 	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/agent"
+	"github.com/TeneoProtocolAI/teneo-agent-sdk/pkg/types"
 	"github.com/joho/godotenv"
+	"golang.org/x/time/rate"
 )
 
+// defaultRedirectURI is the callback used by -authorize when SPOTIFY_REDIRECT_URI is not set
+const defaultRedirectURI = "http://localhost:8888/callback"
+
+// topTrackSeeds is how many of the user's top tracks are used as recommendation seeds
+const topTrackSeeds = 2
+
+// defaultTrackCount is how many tracks are recommended when the user doesn't ask for a number
+const defaultTrackCount = 20
+
+// maxTrackCount caps how many tracks a user can ask for in one go
+const maxTrackCount = 100
+
+// seedFeatureWeight is the share of the seed tracks' audio features in the recommendation targets
+const seedFeatureWeight = 0.5
+
+// albumImageWidth is the width of cover art wanted for a track summary.
+// Spotify's album images are usually 640, 300 and 64 pixels wide.
+const albumImageWidth = 300
+
+// SpotifyClient is the part of the Spotify API the agent uses. *spotify.Client
+// implements it; a fake can be used to run the agent without network access.
+type SpotifyClient interface {
+	SearchTracks(ctx context.Context, query string, limit int) ([]spotify.Track, error)
+	SearchTracksPaged(ctx context.Context, query string, limit, offset int) ([]spotify.Track, error)
+	SearchTracksFiltered(ctx context.Context, text string, filters spotify.SearchFilters, limit, offset int) ([]spotify.Track, error)
+	SearchArtists(ctx context.Context, query string, limit int) ([]spotify.Artist, error)
+	GetRecommendations(ctx context.Context, seedTracks, seedArtists, seedGenres []string, moodParams map[string]interface{}, limit int) ([]spotify.Track, error)
+	GetAvailableGenreSeeds(ctx context.Context) ([]string, error)
+	GetAudioFeatures(ctx context.Context, trackIDs []string) ([]spotify.AudioFeatures, error)
+	GetTopTracks(ctx context.Context, timeRange string, limit int) ([]spotify.Track, error)
+	GetCurrentlyPlaying(ctx context.Context) (*spotify.Track, error)
+	GetCurrentUser(ctx context.Context) (*spotify.User, error)
+	CanActAsUser() bool
+	PlayableTracks(tracks []spotify.Track) []spotify.Track
+	FindUserPlaylist(ctx context.Context, ownerID, name string) (*spotify.Playlist, error)
+	CreatePlaylistWithOptions(ctx context.Context, userID, name, description string, opts spotify.PlaylistOptions) (*spotify.Playlist, error)
+	AddTracksToPlaylist(ctx context.Context, playlistID string, trackURIs []string) error
+	ReplacePlaylistTracks(ctx context.Context, playlistID string, trackURIs []string) error
+	GetPlaylistTracks(ctx context.Context, playlistID string) ([]spotify.Track, error)
+	SetPlaylistCover(ctx context.Context, playlistID string, jpegData []byte) error
+	UpdatePlaylistDetails(ctx context.Context, playlistID, name, description string) error
+	StartPlayback(ctx context.Context, deviceID string, uris []string) error
+	SaveTracks(ctx context.Context, trackIDs []string) error
+	GetTracks(ctx context.Context, trackIDs []string) ([]spotify.Track, error)
+	TracksAreSaved(ctx context.Context, trackIDs []string) ([]bool, error)
+}
+
+var _ SpotifyClient = (*spotify.Client)(nil)
+
+// availableCommands lists the commands ProcessTask understands
+const availableCommands = "mood_analyzer, mood_only, genre_radio, clear_playlist"
+
+// defaultPlaylistNameTemplate is the name given to mood playlists unless another template is configured
+const defaultPlaylistNameTemplate = "Mood Analyst: {mood} Vibes"
+
 type MoodalystAgent struct {
-	spotifyClient *spotify.Client
+	spotifyClient SpotifyClient
 	moodAnalyzer  *mood.MoodAnalyzer
+	logger        *slog.Logger // nil uses slog.Default()
+
+	// playlistNameTemplate names mood playlists, with {mood} and {date}
+	// placeholders. Empty uses defaultPlaylistNameTemplate.
+	playlistNameTemplate string
+
+	// historyWeight is the share of the final audio feature targets taken from
+	// the user's top tracks rather than the mood, in [0, 1]. 0 leaves the
+	// targets to the mood; 0.3 gives 70% mood and 30% history.
+	historyWeight float32
+
+	// trackSplit is how the tracks are divided between search and
+	// recommendations. The zero value uses defaultTrackSplit.
+	trackSplit trackSplit
+
+	// sessions remembers the last recommendations of each conversation, keyed
+	// by the SDK's room, so follow-ups like "more like 3" can refer to them
+	sessionsMu sync.Mutex
+	sessions   map[string]*session
+
+	// recent holds the tracks recommended lately so they aren't repeated. nil
+	// recommends tracks however recently they were suggested.
+	recent *recentTracks
+
+	// locale is the language responses are written in unless a task asks for
+	// another with lang:. Empty uses mood.DefaultLocale.
+	locale mood.Locale
+}
+
+// recentTracks remembers when tracks were recommended in each conversation,
+// so they can be left out of recommendations for a while
+type recentTracks struct {
+	mu      sync.Mutex
+	path    string        // file the history is saved to on every change; empty keeps it in memory
+	window  time.Duration // how long a recommended track is left out
+	history map[string]map[string]time.Time
+}
+
+// loadRecentTracks returns the history saved at path, or an empty one when
+// the file doesn't exist yet. An empty path keeps the history in memory only.
+func loadRecentTracks(path string, window time.Duration) (*recentTracks, error) {
+	r := &recentTracks{path: path, window: window, history: make(map[string]map[string]time.Time)}
+	if path == "" {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recent tracks file: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.history); err != nil {
+		return nil, fmt.Errorf("failed to decode recent tracks file: %w", err)
+	}
+	return r, nil
+}
+
+// filter drops the tracks recommended in the conversation within the window
+// before now, keeping all of them when every track was. A nil history keeps all tracks.
+func (r *recentTracks) filter(session string, tracks []spotify.Track, now time.Time) []spotify.Track {
+	if r == nil {
+		return tracks
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recommended := r.history[session]
+	fresh := slices.DeleteFunc(slices.Clone(tracks), func(t spotify.Track) bool {
+		at, ok := recommended[t.ID]
+		return ok && now.Sub(at) < r.window
+	})
+	if len(fresh) == 0 {
+		return tracks
+	}
+	return fresh
+}
+
+// add records the tracks as recommended in the conversation at now, forgets
+// the ones recommended before the window and saves the history if it has a path
+func (r *recentTracks) add(session string, tracks []spotify.Track, now time.Time) error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.history[session] == nil {
+		r.history[session] = make(map[string]time.Time)
+	}
+	for _, t := range tracks {
+		if t.ID != "" {
+			r.history[session][t.ID] = now
+		}
+	}
+	for id, recommended := range r.history {
+		maps.DeleteFunc(recommended, func(_ string, at time.Time) bool { return now.Sub(at) >= r.window })
+		if len(recommended) == 0 {
+			delete(r.history, id)
+		}
+	}
+
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(r.history)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recent tracks: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write recent tracks file: %w", err)
+	}
+	return nil
+}
+
+// session is what the agent remembers about a conversation between tasks
+type session struct {
+	moodDescription string          // what the last tracks were recommended for
+	tracks          []spotify.Track // the last tracks recommended, as listed
+	lastUsed        time.Time
+}
+
+// maxSessions is how many conversations are remembered; the least recently used are forgotten first
+const maxSessions = 1000
+
+// sessionKey is the context key for the ID of the conversation a task belongs to
+type sessionKey struct{}
+
+// withSession returns a context for tasks from the conversation with the given ID
+func withSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+// sessionID returns the ID of the conversation the task belongs to, or an
+// empty string, which all tasks without one share
+func sessionID(ctx context.Context) string {
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
+
+// remember stores the tracks recommended for a mood description as the last
+// result of the task's conversation
+func (a *MoodalystAgent) remember(ctx context.Context, moodDescription string, tracks []spotify.Track) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	if a.sessions == nil {
+		a.sessions = make(map[string]*session)
+	}
+	id := sessionID(ctx)
+	if _, ok := a.sessions[id]; !ok && len(a.sessions) >= maxSessions {
+		var oldest string
+		var oldestUsed time.Time
+		for key, s := range a.sessions {
+			// The shared session has an empty ID, so whether one was picked goes by its time
+			if oldestUsed.IsZero() || s.lastUsed.Before(oldestUsed) {
+				oldest, oldestUsed = key, s.lastUsed
+			}
+		}
+		delete(a.sessions, oldest)
+	}
+	a.sessions[id] = &session{moodDescription: moodDescription, tracks: tracks, lastUsed: time.Now()}
+}
+
+// lastResult returns the last result remembered for the task's conversation
+func (a *MoodalystAgent) lastResult(ctx context.Context) (session, bool) {
+	a.sessionsMu.Lock()
+	defer a.sessionsMu.Unlock()
+
+	s, ok := a.sessions[sessionID(ctx)]
+	if !ok {
+		return session{}, false
+	}
+	s.lastUsed = time.Now()
+	return *s, true
+}
+
+// ProcessTaskWithStreaming handles a task like ProcessTask, with the room it
+// came from as its conversation, and sends the response to the room
+func (a *MoodalystAgent) ProcessTaskWithStreaming(ctx context.Context, task string, room string, sender types.MessageSender) error {
+	response, err := a.ProcessTask(withSession(ctx, room), task)
+	if err != nil {
+		return err
+	}
+	return sender.SendMessage(response)
+}
+
+var _ types.StreamingTaskHandler = (*MoodalystAgent)(nil)
+
+// log returns the agent's logger
+func (a *MoodalystAgent) log() *slog.Logger {
+	if a.logger == nil {
+		return slog.Default()
+	}
+	return a.logger
+}
+
+func (a *MoodalystAgent) ProcessTask(ctx context.Context, task string) (string, error) {
+	a.log().Debug("Processing task", "task", task)
+
+	// Clean up the task input; text pasted from phones has curly quotes and dashes
+	task = strings.TrimSpace(mood.NormalizeText(task))
+	task = strings.TrimPrefix(task, "/")
+
+	// Split into command and arguments. Track links are case-sensitive, so
+	// they're taken out before the rest is lowercased.
+	seedTracks, fields := parseTrackLinks(strings.Fields(task))
+	parts := strings.Fields(strings.ToLower(strings.Join(fields, " ")))
+	if len(parts) == 0 {
+		return fmt.Sprintf(mood.MessagesFor(a.localeFor(taskOptions{})).NoCommand, availableCommands), nil
+	}
+
+	command := parts[0]
+	args := parts[1:]
+	// Replies that come before the options are parsed still use the language asked for
+	locale, _ := parseLocale(args)
+	msgs := mood.MessagesFor(a.localeFor(taskOptions{locale: locale}))
+
+	// Route to appropriate command handler
+	switch command {
+	case "mood_analyzer":
+		if n, rest, ok := parseMoreLike(args); ok {
+			opts, _ := parseOptions(rest)
+			return a.moreLike(ctx, n, opts)
+		}
+
+		// A pasted track is enough to go on, e.g. "more like this <link>"
+		if len(args) == 0 && len(seedTracks) == 0 {
+			return fmt.Sprintf(msgs.DescribeMood, command), nil
+		}
+
+		opts, args := parseOptions(args)
+		if len(args) == 0 && len(seedTracks) == 0 && opts.query == "" {
+			return fmt.Sprintf(msgs.DescribeMood, command), nil
+		}
+		opts.seedTracks = seedTracks
+
+		moodDescription := strings.Join(args, " ")
+		if moods := mood.SplitMoodSequence(moodDescription); len(moods) > 1 {
+			return a.recommendSequence(ctx, moods, opts)
+		}
+		return a.recommendMusic(ctx, moodDescription, opts)
+
+	case "mood_only":
+		opts, args := parseOptions(args)
+		if len(args) == 0 {
+			return fmt.Sprintf(msgs.DescribeMood, command), nil
+		}
+		return a.describeMood(strings.Join(args, " "), opts.format, a.localeFor(opts))
+
+	case "genre_radio":
+		opts, args := parseOptions(args)
+		if len(args) == 0 {
+			return msgs.WhichGenre, nil
+		}
+		return a.genreRadio(ctx, strings.Join(args, "-"), opts)
+
+	case "clear_playlist":
+		_, args := parseLocale(args)
+		if len(args) == 0 {
+			return msgs.WhichPlaylist, nil
+		}
+		return a.clearMoodPlaylist(ctx, strings.Join(args, " "), msgs)
+
+	default:
+		return fmt.Sprintf(msgs.UnknownCommand, command, availableCommands), nil
+	}
+}
+
+// taskOptions are what a mood_analyzer task can ask for besides describing the mood
+type taskOptions struct {
+	format  mood.OutputFormat
+	count   int  // how many tracks to recommend
+	playNow bool // start playing the tracks on the user's active device
+
+	// Where to save the tracks; with neither set they go to a playlist
+	saveLiked     bool // save to the user's Liked Songs
+	savePlaylist  bool // save to the mood playlist
+	collaborative bool // let others edit the mood playlist when it's created
+
+	excludeSaved bool // leave out tracks already in the user's Liked Songs
+
+	variations int // how many variations of the mood to recommend, 0 for a single list
+
+	sortBy trackSort // how to order the tracks, unordered when its feature is empty
+
+	seedTracks []string // IDs of tracks the user pasted links to, to find more like them
+	query      string   // search terms replacing the ones derived from the mood, if set
+	verbose    bool     // show how the tracks were found, such as the search query
+
+	duration time.Duration // total length to fill instead of a track count, 0 for none
+
+	locale mood.Locale // language of the response, empty for the agent's
+}
+
+// trackSort orders tracks by an audio feature
+type trackSort struct {
+	feature    string // "energy" or "tempo"
+	descending bool
+}
+
+// parseOptions extracts the options from the task arguments, returning them and
+// the remaining arguments, which describe the mood
+func parseOptions(args []string) (taskOptions, []string) {
+	var opts taskOptions
+	opts.format, args = parseFormat(args)
+	opts.locale, args = parseLocale(args)
+	opts.variations, args = parseVariations(args)
+	opts.sortBy, args = parseSort(args)
+	opts.playNow, args = parseFlag(args, "play:now")
+	opts.verbose, args = parseFlag(args, "verbose:on")
+	opts.excludeSaved, args = parseFlag(args, "exclude:saved")
+	opts.saveLiked, args = parseFlag(args, "save:liked")
+	opts.savePlaylist, args = parseFlag(args, "save:playlist")
+	opts.collaborative, args = parseFlag(args, "save:collab")
+	if !opts.saveLiked || opts.collaborative {
+		opts.savePlaylist = true
+	}
+	opts.duration, args = parseDuration(args)
+	opts.query, args = parseQuery(args)
+	opts.count, args = parseTrackCount(args)
+	if opts.duration > 0 {
+		opts.count = tracksForDuration(opts.duration)
+	}
+	return opts, args
+}
+
+// trackLinkPunctuation is trimmed from a word before reading it as a track link,
+// as in "like this: https://open.spotify.com/track/...!"
+const trackLinkPunctuation = ".,!?;:()<>\"'"
+
+// parseTrackLinks extracts Spotify track links and URIs from the arguments,
+// returning the IDs of the tracks and the remaining arguments
+func parseTrackLinks(args []string) ([]string, []string) {
+	var ids, rest []string
+	for _, arg := range args {
+		if id, ok := spotify.ParseTrackID(strings.Trim(arg, trackLinkPunctuation)); ok {
+			if !slices.Contains(ids, id) {
+				ids = append(ids, id)
+			}
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return ids, rest
+}
+
+// durationPattern matches a playlist length such as "30 minutes", "45-min" or
+// "1.5 hours". "a" or "an" only counts right after "for" or "make me", as in
+// "for an hour", so phrases like "give me a minute" or "an hour ago" don't.
+var durationPattern = regexp.MustCompile(`(?:^|\s)(?:(\d+(?:\.\d+)?)[\s-]*|(?:for|make me)\s+(an?)\s+)(minutes?|mins?|hours?|hrs?)(?:\s|$)`)
+
+// parseDuration extracts an optional playlist length, returning it (0 when
+// there is none) and the remaining arguments
+func parseDuration(args []string) (time.Duration, []string) {
+	joined := strings.Join(args, " ")
+	match := durationPattern.FindStringSubmatchIndex(joined)
+	if match == nil {
+		return 0, args
+	}
+
+	amount, start := 1.0, match[0]
+	if match[2] >= 0 {
+		amount, _ = strconv.ParseFloat(joined[match[2]:match[3]], 64)
+	} else {
+		// "for" and "make me" stay part of the description
+		start = match[4]
+	}
+	unit := time.Minute
+	if strings.HasPrefix(joined[match[6]:match[7]], "h") {
+		unit = time.Hour
+	}
+	duration := time.Duration(amount * float64(unit))
+	if duration <= 0 {
+		return 0, args
+	}
+	return duration, strings.Fields(joined[:start] + " " + joined[match[1]:])
+}
+
+// averageTrackLength is what a track is assumed to last when estimating how
+// many tracks fill a playlist of a given length
+const averageTrackLength = 3*time.Minute + 30*time.Second
+
+// durationTolerance is how far the length of a playlist made to a duration may be off
+const durationTolerance = 2 * time.Minute
+
+// tracksForDuration estimates how many tracks to find to fill a playlist of the
+// given length, with half again as many to choose from so it can be fitted closely
+func tracksForDuration(duration time.Duration) int {
+	needed := int((duration + averageTrackLength - 1) / averageTrackLength)
+	return min(max(1, needed*3/2), maxTrackCount)
+}
+
+// fitDuration picks tracks in order until their total length is within
+// tolerance of target, skipping tracks that would run past it and ones of
+// unknown length. It returns the picked tracks and their total length.
+func fitDuration(tracks []spotify.Track, target, tolerance time.Duration) ([]spotify.Track, time.Duration) {
+	var picked []spotify.Track
+	var total time.Duration
+	// A target within the tolerance of nothing still gets at least one track
+	minTotal := max(target-tolerance, 0)
+	for _, track := range tracks {
+		if len(picked) > 0 && total >= minTotal {
+			break
+		}
+		length := time.Duration(track.DurationMs) * time.Millisecond
+		if length <= 0 || total+length > target+tolerance {
+			continue
+		}
+		picked = append(picked, track)
+		total += length
+	}
+	return picked, total
+}
+
+// parseQuery extracts an optional "query:<terms>" argument, returning the search
+// terms and the remaining arguments. The terms run up to the next option or a
+// trailing track count, so "query:summer roadtrip 30" searches "summer roadtrip".
+func parseQuery(args []string) (string, []string) {
+	start := slices.IndexFunc(args, func(arg string) bool { return strings.HasPrefix(arg, "query:") })
+	if start < 0 {
+		return "", args
+	}
+
+	terms := []string{strings.TrimPrefix(args[start], "query:")}
+	end := start + 1
+	for ; end < len(args); end++ {
+		_, isCount := strconv.Atoi(args[end])
+		if strings.Contains(args[end], ":") || (end == len(args)-1 && isCount == nil) {
+			break
+		}
+		terms = append(terms, args[end])
+	}
+	rest := append(slices.Clone(args[:start]), args[end:]...)
+	return strings.TrimSpace(strings.Join(terms, " ")), rest
+}
+
+// parseMoreLike recognizes a request for more like a track of the last list,
+// "more like <n>" or "more like track <n>", returning the track number and the
+// remaining arguments
+func parseMoreLike(args []string) (int, []string, bool) {
+	rest, ok := cutWords(args, "more", "like")
+	if !ok || len(rest) == 0 {
+		return 0, args, false
+	}
+	if rest[0] == "track" || rest[0] == "song" || rest[0] == "number" {
+		rest = rest[1:]
+	}
+	if len(rest) == 0 {
+		return 0, args, false
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(rest[0], "#"))
+	if err != nil {
+		return 0, args, false
+	}
+	return n, rest[1:], true
+}
+
+// cutWords removes the words from the start of the arguments, reporting whether they were there
+func cutWords(args []string, words ...string) ([]string, bool) {
+	if len(args) < len(words) || !slices.Equal(args[:len(words)], words) {
+		return args, false
+	}
+	return args[len(words):], true
+}
+
+// parseFlag removes every occurrence of flag from the arguments and reports whether there was one
+func parseFlag(args []string, flag string) (bool, []string) {
+	rest := slices.DeleteFunc(slices.Clone(args), func(arg string) bool { return arg == flag })
+	return len(rest) < len(args), rest
+}
+
+// parseFormat extracts an optional "format:<plain|markdown|minimal|json>" argument,
+// returning the selected format and the remaining arguments
+func parseFormat(args []string) (mood.OutputFormat, []string) {
+	format := mood.FormatPlain
+	var rest []string
+	for _, arg := range args {
+		if name, ok := strings.CutPrefix(arg, "format:"); ok {
+			if parsed, valid := mood.ParseOutputFormat(name); valid {
+				format = parsed
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+	return format, rest
+}
+
+// parseLocale extracts an optional "lang:<locale>" argument such as "lang:es",
+// returning the locale (empty when there is none) and the remaining arguments
+func parseLocale(args []string) (mood.Locale, []string) {
+	var locale mood.Locale
+	var rest []string
+	for _, arg := range args {
+		if name, ok := strings.CutPrefix(arg, "lang:"); ok {
+			if parsed, valid := mood.ParseLocale(name); valid {
+				locale = parsed
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+	return locale, rest
+}
+
+// localeFor returns the language the task asked for responses in, or else the agent's
+func (a *MoodalystAgent) localeFor(opts taskOptions) mood.Locale {
+	if opts.locale != "" {
+		return opts.locale
+	}
+	if a.locale != "" {
+		return a.locale
+	}
+	return mood.DefaultLocale
+}
+
+// parseVariations extracts an optional "variations:<2|3>" argument, returning the
+// number of variations (0 when there is none) and the remaining arguments
+func parseVariations(args []string) (int, []string) {
+	variations := 0
+	var rest []string
+	for _, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "variations:"); ok {
+			if n, err := strconv.Atoi(value); err == nil && n >= 2 && n <= mood.MaxVariations {
+				variations = n
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+	return variations, rest
+}
+
+// parseSort extracts an optional "sort:<energy|tempo>[-desc]" argument, returning
+// the sort order (an empty feature when there is none) and the remaining arguments
+func parseSort(args []string) (trackSort, []string) {
+	var sortBy trackSort
+	var rest []string
+	for _, arg := range args {
+		if value, ok := strings.CutPrefix(arg, "sort:"); ok {
+			feature, descending := strings.CutSuffix(value, "-desc")
+			feature = strings.TrimSuffix(feature, "-asc")
+			if feature == "energy" || feature == "tempo" {
+				sortBy = trackSort{feature: feature, descending: descending}
+				continue
+			}
+		}
+		rest = append(rest, arg)
+	}
+	return sortBy, rest
+}
+
+// parseTrackCount extracts an optional trailing track count such as "happy 30",
+// returning the count capped to maxTrackCount (or defaultTrackCount when there is
+// none) and the remaining arguments. A trailing year such as "sad songs from
+// 2015" is left for the mood description.
+func parseTrackCount(args []string) (int, []string) {
+	if len(args) == 0 {
+		return defaultTrackCount, args
+	}
+
+	count, err := strconv.Atoi(args[len(args)-1])
+	if err != nil || count <= 0 || len(args[len(args)-1]) == 4 && count >= 1900 {
+		return defaultTrackCount, args
+	}
+	return min(count, maxTrackCount), args[:len(args)-1]
+}
+
+// trackSplit is the proportion of tracks taken from the initial search, whose
+// results also seed the recommendations, to tracks taken from recommendations
+type trackSplit struct {
+	search int
+	recs   int
+}
+
+// defaultTrackSplit takes 5 of every 20 tracks from search and 15 from recommendations
+var defaultTrackSplit = trackSplit{search: 5, recs: 15}
+
+// validate checks that the split asks Spotify for no more tracks per request than it allows
+func (s trackSplit) validate() error {
+	if s.search < 1 || s.search > spotify.MaxSearchLimit {
+		return fmt.Errorf("search tracks must be between 1 and %d, got %d", spotify.MaxSearchLimit, s.search)
+	}
+	if s.recs < 0 || s.recs > spotify.MaxRecommendationsLimit {
+		return fmt.Errorf("recommended tracks must be between 0 and %d, got %d", spotify.MaxRecommendationsLimit, s.recs)
+	}
+	return nil
+}
+
+// splitTrackCount divides the requested number of tracks between the initial
+// search and the recommendations in the agent's trackSplit proportion, within
+// Spotify's limits. At least one track always comes from the search.
+func (a *MoodalystAgent) splitTrackCount(count int) (searchCount, recsCount int) {
+	split := a.trackSplit
+	if split == (trackSplit{}) {
+		split = defaultTrackSplit
+	}
+	searchCount = max(1, min(count*split.search/(split.search+split.recs), spotify.MaxSearchLimit))
+	recsCount = max(0, min(count-searchCount, spotify.MaxRecommendationsLimit))
+	return searchCount, recsCount
+}
+
+// recommendationCount returns how many recommendations to ask for when a
+// search for searchCount tracks found only found of them, moving the shortfall
+// over to recommendations as far as Spotify allows
+func recommendationCount(searchCount, recsCount, found int) int {
+	return min(recsCount+max(0, searchCount-found), spotify.MaxRecommendationsLimit)
+}
+
+// errNoUserAccess is returned when saving needs a signed-in user and the client has none
+var errNoUserAccess = errors.New("user not authenticated or scope missing")
+
+// ErrNoMoodDetected is returned by Analyze when the description names neither a mood nor an activity
+var ErrNoMoodDetected = errors.New("no mood detected")
+
+// Analyze detects the mood in a mood description such as "I feel happy and
+// energetic 30" and finds tracks for it, without creating a playlist or
+// formatting a response. It accepts the same arguments as the mood_analyzer
+// command; a trailing number sets how many tracks to find.
+// The detected profile is returned even when finding tracks fails.
+func (a *MoodalystAgent) Analyze(ctx context.Context, task string) (mood.MoodProfile, []spotify.Track, error) {
+	seedTracks, fields := parseTrackLinks(strings.Fields(task))
+	opts, args := parseOptions(strings.Fields(strings.ToLower(strings.Join(fields, " "))))
+	opts.seedTracks = seedTracks
+	result, err := a.analyze(ctx, strings.Join(args, " "), opts, nil)
+	return result.profile, result.tracks, err
+}
+
+// analysis is what analyze found for a mood description
+type analysis struct {
+	profile mood.MoodProfile
+	tracks  []spotify.Track
+	query   string // the search query sent first, filters included; empty when there was no search
+}
+
+// analyze detects the mood in the description and finds tracks for it, as many
+// as opts.count, also like the tracks the user pasted, if any. A query in opts
+// replaces the search terms derived from the mood.
+// Problems that still leave tracks to recommend are added to warn, which may be nil.
+func (a *MoodalystAgent) analyze(ctx context.Context, moodDescription string, opts taskOptions, warn *warnings) (analysis, error) {
+	count, seedTracks := opts.count, opts.seedTracks
+	msgs := mood.MessagesFor(a.localeFor(opts))
+	moodProfile := a.detectMood(moodDescription)
+	a.log().Info("Detected mood", "mood", moodProfile.Mood)
+
+	// An activity such as "workout" is enough to go on even without a mood word,
+	// and so is a pasted track to find more like or the user's own query
+	undetected := !moodProfile.Detected && moodProfile.Activity == ""
+	if undetected && len(seedTracks) == 0 && opts.query == "" {
+		return analysis{profile: moodProfile}, ErrNoMoodDetected
+	}
+	// With only pasted tracks to go on there is nothing to search for
+	moodless := undetected && opts.query == ""
+
+	// Search for tracks matching the mood. The text is escaped by the client, so
+	// only the filters below can narrow the search.
+	query := moodProfile.SearchQueryTerms
+	if query == "" {
+		query = mood.StripPreamble(moodDescription)
+	}
+	var filters spotify.SearchFilters
+	// "sad like Adele" starts from the artist's own songs; recommendations add the mood
+	artist := a.findSimilarArtist(ctx, moodProfile.SimilarArtist)
+	if artist != nil {
+		query = ""
+		filters.Artist = artist.Name
+	} else if moodProfile.SimilarArtist != "" {
+		warn.add(fmt.Sprintf(msgs.WarnArtistNotFound, moodProfile.SimilarArtist))
+	}
+	// A decade or years the user asked for narrow the search by release date
+	filters.Year = moodProfile.YearRange
+	// The user's own query replaces the derived terms, but the filters still apply
+	if opts.query != "" {
+		query = opts.query
+	}
+	// A genre the user asked for narrows the search to it
+	if len(moodProfile.RequestedGenres) > 0 {
+		filters.Genre = moodProfile.RequestedGenres[0]
+		a.checkRequestedGenres(ctx, moodProfile.RequestedGenres, msgs, warn)
+	}
+
+	searchCount, recsCount := a.splitTrackCount(count)
+
+	result := analysis{profile: moodProfile}
+	var tracks []spotify.Track
+	var relaxed []relaxedSearch
+	var err error
+	if moodless {
+		// There's only the pasted tracks to go on, so recommendations find them all
+		recsCount = min(count, spotify.MaxRecommendationsLimit)
+	} else {
+		result.query = spotify.BuildSearchQuery(query, filters)
+		a.log().Debug("Searching tracks", "query", result.query)
+		tracks, err = a.spotifyClient.SearchTracksFiltered(ctx, query, filters, searchCount, 0)
+		if err != nil {
+			return result, fmt.Errorf("failed to search tracks: %w", err)
+		}
+
+		// A narrow search may find nothing at all; loosen it before giving up
+		relaxed = relaxedSearches(query, filters, moodProfile.Mood)
+		if len(tracks) == 0 {
+			tracks = a.fillBySearch(ctx, tracks, searchCount, relaxed)
+		}
+		if len(tracks) == 0 {
+			return result, nil
+		}
+		// Spotify may find fewer tracks than asked for; recommendations make up the difference
+		recsCount = recommendationCount(searchCount, recsCount, len(tracks))
+	}
+
+	// Fill up the rest of the requested tracks with recommendations
+	var searchSeedIDs []string
+	for _, t := range tracks {
+		if t.ID != "" {
+			searchSeedIDs = append(searchSeedIDs, t.ID)
+			a.log().Debug("Adding seed track", "id", t.ID, "name", t.Name)
+		}
+	}
+
+	// Tracks the user pasted lead the seeds. Seeding from what the user is
+	// listening to and their history gives more personal results.
+	seedTrackIDs := slices.Clone(seedTracks)
+	var nowPlaying *spotify.Track
+	var topTracks []spotify.Track
+	if a.spotifyClient.CanActAsUser() {
+		nowPlaying, err = a.spotifyClient.GetCurrentlyPlaying(ctx)
+		if err != nil {
+			a.log().Debug("Not seeding from the currently playing track", "error", err)
+		} else if nowPlaying != nil && nowPlaying.ID != "" && !slices.Contains(seedTrackIDs, nowPlaying.ID) {
+			a.log().Debug("Seeding from the currently playing track", "id", nowPlaying.ID, "name", nowPlaying.Name)
+			seedTrackIDs = append(seedTrackIDs, nowPlaying.ID)
+		}
+
+		topTracks, err = a.spotifyClient.GetTopTracks(ctx, spotify.TimeRangeShort, topTrackSeeds)
+		if err != nil {
+			a.log().Debug("Not seeding from top tracks", "error", err)
+		}
+	}
+	for _, t := range topTracks {
+		if t.ID != "" && !slices.Contains(seedTrackIDs, t.ID) {
+			seedTrackIDs = append(seedTrackIDs, t.ID)
+		}
+	}
+	for _, id := range searchSeedIDs {
+		if !slices.Contains(seedTrackIDs, id) {
+			seedTrackIDs = append(seedTrackIDs, id)
+		}
+	}
+
+	// The artist the user asked for or is listening to steers recommendations towards their taste
+	var seedArtistIDs []string
+	if artist != nil {
+		seedArtistIDs = append(seedArtistIDs, artist.ID)
+	}
+	personalTracks := topTracks
+	if nowPlaying != nil {
+		personalTracks = append([]spotify.Track{*nowPlaying}, topTracks...)
+	}
+	if artistID := leadArtistID(personalTracks); artistID != "" && !slices.Contains(seedArtistIDs, artistID) {
+		seedArtistIDs = append(seedArtistIDs, artistID)
+	}
+
+	// The client picks the seeds from these candidates in order, and the genres
+	// the user asked for lead SuggestedGenres
+	seedGenres := moodProfile.SuggestedGenres
+
+	// Refine the targets with what the seed tracks actually sound like
+	targetProfile := moodProfile
+	if features, err := a.spotifyClient.GetAudioFeatures(ctx, append(slices.Clone(seedTracks), searchSeedIDs...)); err != nil {
+		a.log().Warn("Could not get seed audio features", "error", err)
+	} else if len(features) > 0 {
+		targetProfile = mood.BlendAudioFeatures(moodProfile, moodFeatures(spotify.AverageAudioFeatures(features)), seedFeatureWeight)
+	}
+
+	// Pull the targets towards what the user usually listens to, as much as configured
+	if a.historyWeight > 0 && len(topTracks) > 0 {
+		targetProfile = a.blendHistory(ctx, targetProfile, topTracks)
+	}
+
+	moodParams := a.moodAnalyzer.GetMoodParameters(targetProfile)
+
+	if recsCount > 0 {
+		a.log().Debug("Fetching additional recommendations", "count", recsCount, "candidate_tracks", len(seedTrackIDs), "candidate_artists", len(seedArtistIDs), "candidate_genres", len(seedGenres))
+		recs, err := a.spotifyClient.GetRecommendations(ctx, seedTrackIDs, seedArtistIDs, seedGenres, moodParams, recsCount)
+		if err == nil {
+			a.log().Debug("Got recommendations", "count", len(recs), "existing", len(tracks))
+			tracks = append(tracks, recs...)
+		} else if moodless {
+			a.log().Warn("Failed to get recommendations for the pasted tracks", "error", err)
+		} else {
+			// Fallback: Do additional searches with different mood keywords
+			if errors.Is(err, spotify.ErrNoSeeds) {
+				// Recommendations weren't unavailable, there was just nothing to base them on
+				a.log().Debug("No seeds for recommendations, searching for more tracks instead")
+			} else {
+				a.log().Warn("Failed to get recommendations, searching for more tracks instead", "error", err)
+				warn.add(msgs.WarnSearchOnly)
+			}
+			fallbackQuery := strings.TrimSpace(fmt.Sprintf("%s %s", query, moodProfile.Mood))
+			// Skip past the first page so the fallback doesn't repeat the top results
+			moreTracks, searchErr := a.spotifyClient.SearchTracksFiltered(ctx, fallbackQuery, filters, min(recsCount, spotify.MaxSearchLimit), len(tracks))
+			if searchErr == nil && len(moreTracks) > 0 {
+				a.log().Debug("Fallback search found additional tracks", "count", len(moreTracks))
+				tracks = append(tracks, moreTracks...)
+			} else {
+				a.log().Warn("Fallback search also failed", "error", searchErr)
+				warn.add(fmt.Sprintf(msgs.WarnFewTracks, len(tracks)))
+			}
+		}
+	}
+
+	// Searches and recommendations often overlap, and not every response is
+	// filtered for the market the tracks are played in
+	tracks = a.spotifyClient.PlayableTracks(spotify.DedupeTracks(tracks))
+
+	if len(tracks) < count {
+		found := len(tracks)
+		tracks = a.fillBySearch(ctx, tracks, count, relaxed)
+		if len(tracks) > found {
+			warn.add(msgs.WarnLooserFit)
+		}
+	}
+
+	// Search results aren't filtered by popularity, so apply the range here too
+	if moodProfile.MaxPopularity > 0 {
+		filtered := spotify.FilterByPopularity(tracks, moodProfile.MinPopularity, moodProfile.MaxPopularity)
+		if len(filtered) > 0 {
+			tracks = filtered
+		} else {
+			a.log().Debug("No tracks in the requested popularity range, keeping all", "min", moodProfile.MinPopularity, "max", moodProfile.MaxPopularity)
+			warn.add(msgs.WarnPopularity)
+		}
+	}
+
+	result.tracks = tracks
+	return result, nil
+}
+
+// checkRequestedGenres warns about genres the user named that Spotify can't
+// recommend from. They still narrow the search, but not the recommendations.
+func (a *MoodalystAgent) checkRequestedGenres(ctx context.Context, genres []string, msgs mood.Messages, warn *warnings) {
+	available, err := a.spotifyClient.GetAvailableGenreSeeds(ctx)
+	if err != nil {
+		a.log().Debug("Could not check requested genres", "error", err)
+		return
+	}
+	if _, invalid := spotify.FilterGenreSeeds(genres, available); len(invalid) > 0 {
+		warn.add(fmt.Sprintf(msgs.WarnGenreSearchOnly, strings.Join(invalid, " "+msgs.Or+" ")))
+	}
+}
+
+// similarArtistCandidates is how many artists are searched for when looking
+// up the one the user named, as the right one isn't always the first hit
+const similarArtistCandidates = 5
+
+// findSimilarArtist looks up the artist the user named, returning nil when
+// there is none or no artist of roughly that name can be found
+func (a *MoodalystAgent) findSimilarArtist(ctx context.Context, name string) *spotify.Artist {
+	if name == "" {
+		return nil
+	}
+
+	artists, err := a.spotifyClient.SearchArtists(ctx, name, similarArtistCandidates)
+	if err != nil {
+		a.log().Warn("Could not look up artist", "artist", name, "error", err)
+		return nil
+	}
+	for i, artist := range artists {
+		if artist.ID != "" && sameArtistName(artist.Name, name) {
+			return &artists[i]
+		}
+	}
+	a.log().Info("No artist found", "artist", name, "candidates", len(artists))
+	return nil
+}
+
+// sameArtistName reports whether two artist names are roughly the same,
+// ignoring case, punctuation, spacing, a leading "the" and "&" for "and"
+func sameArtistName(a, b string) bool {
+	return artistNameKey(a) == artistNameKey(b)
+}
+
+// artistNameKey reduces an artist name to the letters and digits compared by sameArtistName
+func artistNameKey(name string) string {
+	name = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), "&", "and")
+	name = strings.TrimPrefix(name, "the ")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+// fallbackGenres are popular genres searched as a last resort when a mood's
+// own searches find too few tracks
+var fallbackGenres = []string{"pop", "rock", "indie"}
+
+// relaxedSearch is a search query and filters to try when earlier searches found too few tracks
+type relaxedSearch struct {
+	query   string
+	filters spotify.SearchFilters
+}
+
+// relaxedSearches returns progressively looser versions of a search, in the
+// order to try them: only the mood name as text, then without the genre and
+// year filters, then across all markets, and finally the mood in each of the
+// fallbackGenres. Steps that wouldn't change the search are left out.
+func relaxedSearches(query string, filters spotify.SearchFilters, moodName string) []relaxedSearch {
+	var searches []relaxedSearch
+	current := relaxedSearch{query, filters}
+	add := func(next relaxedSearch) {
+		if next != current {
+			searches = append(searches, next)
+			current = next
+		}
+	}
+
+	if moodName != "" && query != "" {
+		add(relaxedSearch{moodName, current.filters})
+	}
+	loosened := current.filters
+	loosened.Genre = ""
+	loosened.Year = ""
+	add(relaxedSearch{current.query, loosened})
+	loosened.AnyMarket = true
+	add(relaxedSearch{current.query, loosened})
+
+	if moodName != "" {
+		for _, genre := range fallbackGenres {
+			add(relaxedSearch{moodName, spotify.SearchFilters{Genre: genre, AnyMarket: true}})
+		}
+	}
+	return searches
+}
+
+// fillBySearch adds tracks from each of the searches in turn until there are
+// count playable tracks or the searches run out. Failed searches are skipped.
+func (a *MoodalystAgent) fillBySearch(ctx context.Context, tracks []spotify.Track, count int, searches []relaxedSearch) []spotify.Track {
+	for _, search := range searches {
+		if len(tracks) >= count {
+			break
+		}
+		a.log().Debug("Relaxing search", "query", search.query, "filters", search.filters, "have", len(tracks), "want", count)
+		more, err := a.spotifyClient.SearchTracksFiltered(ctx, search.query, search.filters, min(count, spotify.MaxSearchLimit), 0)
+		if err != nil {
+			a.log().Debug("Relaxed search failed", "error", err)
+			continue
+		}
+		tracks = a.spotifyClient.PlayableTracks(spotify.DedupeTracks(append(tracks, more...)))
+	}
+	if len(tracks) > count {
+		tracks = tracks[:count]
+	}
+	return tracks
+}
+
+// blendHistory mixes the average audio features of the user's top tracks into
+// the profile's targets with the agent's historyWeight
+func (a *MoodalystAgent) blendHistory(ctx context.Context, profile mood.MoodProfile, topTracks []spotify.Track) mood.MoodProfile {
+	var ids []string
+	for _, t := range topTracks {
+		if t.ID != "" {
+			ids = append(ids, t.ID)
+		}
+	}
+	features, err := a.spotifyClient.GetAudioFeatures(ctx, ids)
+	if err != nil || len(features) == 0 {
+		a.log().Debug("Not weighing in listening history", "error", err)
+		return profile
+	}
+	a.log().Debug("Weighing in listening history", "weight", a.historyWeight, "tracks", len(features))
+	return mood.BlendAudioFeatures(profile, moodFeatures(spotify.AverageAudioFeatures(features)), a.historyWeight)
+}
+
+// leadArtistID returns the ID of the main artist of the first track that has one
+func leadArtistID(tracks []spotify.Track) string {
+	for _, t := range tracks {
+		if len(t.Artists) > 0 && t.Artists[0].ID != "" {
+			return t.Artists[0].ID
+		}
+	}
+	return ""
+}
+
+// detectMood analyzes the mood description, or picks a random mood when the
+// user asks to be surprised. Mixed feelings get a blended profile.
+func (a *MoodalystAgent) detectMood(moodDescription string) mood.MoodProfile {
+	if isSurprise(moodDescription) {
+		return a.moodAnalyzer.RandomProfile(nil)
+	}
+
+	// Rather than picking one side of mixed feelings, blend them into a bittersweet mix
+	moodProfile := a.moodAnalyzer.AnalyzeMood(moodDescription)
+	if moodProfile.Ambiguous {
+		return a.moodAnalyzer.AnalyzeMoodBlended(moodDescription)
+	}
+	return moodProfile
+}
+
+// describeMood returns the mood detected in the description and its audio
+// feature profile without calling Spotify, so it works without credentials
+func (a *MoodalystAgent) describeMood(moodDescription string, format mood.OutputFormat, locale mood.Locale) (string, error) {
+	moodProfile := a.detectMood(moodDescription)
+	if format == mood.FormatJSON {
+		return mood.MarshalMoodProfile(moodProfile)
+	}
+	if !moodProfile.Detected && moodProfile.Activity == "" {
+		return mood.MessagesFor(locale).NoMood, nil
+	}
+	return mood.FormatLocalizedMoodProfile(moodProfile, format, locale), nil
+}
+
+// genreRadio recommends tracks from a genre alone, whatever the mood, and
+// saves them to a playlist for the genre like a mood playlist. The genre must
+// be one Spotify accepts as a recommendation seed, such as "jazz" or "hip-hop".
+func (a *MoodalystAgent) genreRadio(ctx context.Context, genre string, opts taskOptions) (string, error) {
+	locale := a.localeFor(opts)
+	msgs := mood.MessagesFor(locale)
+	available, err := a.spotifyClient.GetAvailableGenreSeeds(ctx)
+	if err != nil {
+		// Without the list the genre can't be checked, but it may well still work
+		a.log().Warn("Could not check the genre", "genre", genre, "error", err)
+	} else if !slices.Contains(available, genre) {
+		response := fmt.Sprintf(msgs.UnknownGenre, genre)
+		if similar := similarGenres(genre, available); len(similar) > 0 {
+			response += " " + fmt.Sprintf(msgs.DidYouMean, strings.Join(similar, ", "))
+		}
+		return response, nil
+	}
+
+	var warn warnings
+	filters := spotify.SearchFilters{Genre: genre}
+	searchCount, recsCount := a.splitTrackCount(opts.count)
+	tracks, err := a.spotifyClient.SearchTracksFiltered(ctx, "", filters, searchCount, 0)
+	if err != nil {
+		a.log().Warn("Error searching genre tracks", "genre", genre, "error", err)
+		return searchErrorMessage(genre, err, msgs), nil
+	}
+
+	var seedTrackIDs []string
+	for _, t := range tracks {
+		if t.ID != "" {
+			seedTrackIDs = append(seedTrackIDs, t.ID)
+		}
+	}
+	var recs []spotify.Track
+	if recsCount = recommendationCount(searchCount, recsCount, len(tracks)); recsCount > 0 {
+		recs, err = a.spotifyClient.GetRecommendations(ctx, seedTrackIDs, nil, []string{genre}, nil, recsCount)
+		if err != nil {
+			a.log().Warn("Failed to get genre recommendations, searching for more tracks instead", "error", err)
+			if !errors.Is(err, spotify.ErrNoSeeds) {
+				warn.add(msgs.WarnSearchOnly)
+			}
+			recs, err = a.spotifyClient.SearchTracksFiltered(ctx, "", filters, min(recsCount, spotify.MaxSearchLimit), len(tracks))
+			if err != nil {
+				a.log().Warn("Fallback search also failed", "error", err)
+			}
+		}
+	}
+	tracks = a.spotifyClient.PlayableTracks(spotify.DedupeTracks(append(tracks, recs...)))
+	if len(tracks) == 0 {
+		return fmt.Sprintf(msgs.NoGenreTracks, genre), nil
+	}
+
+	// The genre stands in for the mood so the playlist is named and saved the same way
+	profile := mood.MoodProfile{Mood: genre, SuggestedGenres: []string{genre}}
+	delivered := a.deliver(ctx, profile, tracks, opts, &warn)
+
+	if opts.format == mood.FormatJSON {
+		payload := mood.NewRecommendationsPayload(profile, trackSummaries(tracks))
+		payload.PlaylistURL = delivered.playlistURL
+		payload.Warnings = warn
+		return payload.Marshal()
+	}
+
+	var response strings.Builder
+	if opts.format != mood.FormatMinimal {
+		fmt.Fprintf(&response, msgs.GenreRadioHeader+"\n\n", titleCase(genre))
+	}
+	if err := mood.WriteLocalizedTrackList(&response, trackSummaries(tracks), opts.format, locale); err != nil {
+		return "", fmt.Errorf("failed to write track list: %w", err)
+	}
+	response.WriteString(delivered.notes(msgs))
+	response.WriteString(warn.section(msgs))
+	return response.String(), nil
+}
+
+// similarGenres returns up to three of the available genres that contain the
+// genre or are contained in it, e.g. "indie-pop" for "indie"
+func similarGenres(genre string, available []string) []string {
+	var similar []string
+	for _, candidate := range available {
+		if strings.Contains(candidate, genre) || strings.Contains(genre, candidate) {
+			similar = append(similar, candidate)
+			if len(similar) == 3 {
+				break
+			}
+		}
+	}
+	return similar
+}
+
+// isSurprise reports whether the mood description is "surprise" or "surprise me"
+func isSurprise(moodDescription string) bool {
+	return moodDescription == "surprise" || moodDescription == "surprise me"
+}
+
+// moreLike recommends tracks like the nth track of the conversation's last
+// list, for the mood that list was made for
+func (a *MoodalystAgent) moreLike(ctx context.Context, n int, opts taskOptions) (string, error) {
+	msgs := mood.MessagesFor(a.localeFor(opts))
+	last, ok := a.lastResult(ctx)
+	if !ok || len(last.tracks) == 0 {
+		return msgs.NoEarlierList, nil
+	}
+	if n < 1 || n > len(last.tracks) {
+		return fmt.Sprintf(msgs.PickTrackNumber, len(last.tracks)), nil
+	}
+
+	track := last.tracks[n-1]
+	a.log().Debug("Recommending more like a track", "n", n, "id", track.ID, "name", track.Name)
+	opts.seedTracks = []string{track.ID}
+	response, err := a.recommendMusic(ctx, last.moodDescription, opts)
+	if err != nil || opts.format == mood.FormatJSON || opts.format == mood.FormatMinimal {
+		return response, err
+	}
+	return fmt.Sprintf(msgs.MoreLike+"\n\n", track.Name, strings.Join(track.ArtistNames(), ", ")) + response, nil
 }
 
-func (a *MoodalystAgent) ProcessTask(ctx context.Context, task string) (string, error) {
-	log.Printf("Processing task: %s", task)
+// recommendMusic analyzes the mood, recommends tracks from Spotify and saves
+// them to a playlist when the client has user access
+func (a *MoodalystAgent) recommendMusic(ctx context.Context, moodDescription string, opts taskOptions) (string, error) {
+	format := opts.format
+	locale := a.localeFor(opts)
+	msgs := mood.MessagesFor(locale)
+	var warn warnings
+	result, err := a.analyze(ctx, moodDescription, opts, &warn)
+	moodProfile, tracks := result.profile, result.tracks
+	if errors.Is(err, ErrNoMoodDetected) {
+		return msgs.NoMood, nil
+	}
+	if err != nil {
+		a.log().Warn("Error searching tracks", "error", err)
+		return searchErrorMessage(moodProfile.Mood, err, msgs), nil
+	}
 
-	// Clean up the task input
-	task = strings.TrimSpace(task)
-	task = strings.TrimPrefix(task, "/")
-	taskLower := strings.ToLower(task)
+	if len(tracks) == 0 && !moodProfile.Detected && len(opts.seedTracks) > 0 {
+		return msgs.NoSimilarTracks, nil
+	}
+	if len(tracks) == 0 {
+		return fmt.Sprintf(msgs.NoTracks, moodProfile.Mood), nil
+	}
 
-	// Split into command and arguments
-	parts := strings.Fields(taskLower)
-	if len(parts) == 0 {
-		return "No command provided. Available commands: mood_analyzer", nil
+	if opts.variations > 0 {
+		return a.recommendVariations(ctx, moodProfile, tracks, opts)
 	}
 
-	command := parts[0]
-	args := parts[1:]
+	// Refreshing the playlist should bring new songs rather than the same ones again
+	if opts.savePlaylist {
+		tracks = a.freshTracks(ctx, moodProfile, tracks)
+	}
+	if opts.excludeSaved {
+		tracks = a.unsavedTracks(ctx, tracks, msgs, &warn)
+	}
+	tracks = a.recent.filter(sessionID(ctx), tracks, time.Now())
+	var totalLength time.Duration
+	if opts.duration > 0 {
+		tracks, totalLength = a.fillDuration(ctx, tracks, opts.duration, msgs, &warn)
+	}
+	if opts.sortBy.feature != "" {
+		tracks = a.sortTracks(ctx, tracks, opts.sortBy)
+	}
 
-	// Route to appropriate command handler
-	switch command {
-	case "mood_analyzer":
-		if len(args) == 0 {
-			return "Please describe your mood. Example: 'mood_analyzer I feel happy and energetic'", nil
+	delivered := a.deliver(ctx, moodProfile, tracks, opts, &warn)
+	a.remember(ctx, moodDescription, tracks)
+	if err := a.recent.add(sessionID(ctx), tracks, time.Now()); err != nil {
+		a.log().Warn("Could not save recent tracks", "error", err)
+	}
+
+	// Build response with recommendations
+	a.log().Debug("Building response", "tracks", len(tracks))
+	if format == mood.FormatJSON {
+		payload := mood.NewRecommendationsPayload(moodProfile, trackSummaries(tracks))
+		payload.PlaylistURL = delivered.playlistURL
+		payload.Warnings = warn
+		if opts.verbose {
+			payload.SearchQuery = result.query
 		}
+		return payload.Marshal()
+	}
 
-		moodDescription := strings.Join(args, " ")
-		return a.recommendMusic(ctx, moodDescription)
+	var response strings.Builder
+	if format != mood.FormatMinimal {
+		if isSurprise(moodDescription) {
+			fmt.Fprintf(&response, msgs.Surprise+"\n\n", moodProfile.Mood)
+		} else if moodProfile.Ambiguous {
+			response.WriteString(msgs.Ambiguous + "\n\n")
+		} else if summary := mood.FormatLocalizedIntensitySummary(moodProfile, locale); summary != "" {
+			response.WriteString(summary + "\n\n")
+		}
+	}
+	if err := mood.WriteLocalizedRecommendations(&response, trackSummaries(tracks), moodProfile, format, locale); err != nil {
+		return "", fmt.Errorf("failed to write recommendations: %w", err)
+	}
+	if totalLength > 0 && format != mood.FormatMinimal {
+		fmt.Fprintf(&response, "\n"+msgs.TotalLength+"\n", formatLength(totalLength, msgs))
+	}
+	response.WriteString(delivered.notes(msgs))
+	response.WriteString(warn.section(msgs))
+	if opts.verbose {
+		response.WriteString(verboseSection(result, msgs))
+	}
 
-	default:
-		return fmt.Sprintf("Unknown command '%s'. Available commands: mood_analyzer", command), nil
+	return response.String(), nil
+}
+
+// fillDuration picks tracks adding up to the duration, within durationTolerance,
+// looking up the lengths of tracks that lack them. When the lengths are unknown
+// the tracks are kept as they are and their total length is 0.
+func (a *MoodalystAgent) fillDuration(ctx context.Context, tracks []spotify.Track, duration time.Duration, msgs mood.Messages, warn *warnings) ([]spotify.Track, time.Duration) {
+	var missing []string
+	for _, t := range tracks {
+		if t.DurationMs == 0 && t.ID != "" {
+			missing = append(missing, t.ID)
+		}
+	}
+	if len(missing) > 0 {
+		full, err := a.spotifyClient.GetTracks(ctx, missing)
+		if err != nil {
+			a.log().Warn("Could not get track lengths", "error", err)
+		}
+		lengths := make(map[string]int, len(full))
+		for _, t := range full {
+			lengths[t.ID] = t.DurationMs
+		}
+		for i, t := range tracks {
+			if t.DurationMs == 0 {
+				tracks[i].DurationMs = lengths[t.ID]
+			}
+		}
 	}
+
+	picked, total := fitDuration(tracks, duration, durationTolerance)
+	if len(picked) == 0 {
+		warn.add(fmt.Sprintf(msgs.WarnUnknownLengths, formatLength(duration, msgs)))
+		return tracks, 0
+	}
+	if total < duration-durationTolerance {
+		warn.add(fmt.Sprintf(msgs.WarnShortLength, formatLength(total, msgs)))
+	}
+	return picked, total
 }
 
-// recommendMusic analyzes the mood and recommends music from Spotify
-func (a *MoodalystAgent) recommendMusic(_ context.Context, moodDescription string) (string, error) {
-	// Analyze the mood
-	moodProfile := a.moodAnalyzer.AnalyzeMood(moodDescription)
-	log.Printf("Detected mood: %s", moodProfile.Mood)
+// formatLength describes a playlist length in minutes, or hours and minutes
+func formatLength(d time.Duration, msgs mood.Messages) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	plural := func(n int, one, many string) string {
+		if n == 1 {
+			return one
+		}
+		return fmt.Sprintf(many, n)
+	}
+	if minutes < 60 {
+		return plural(minutes, msgs.OneMinute, msgs.Minutes)
+	}
+	if minutes%60 == 0 {
+		return plural(minutes/60, msgs.OneHour, msgs.Hours)
+	}
+	return plural(minutes/60, msgs.OneHour, msgs.Hours) + " " + plural(minutes%60, msgs.OneMinute, msgs.Minutes)
+}
 
-	// Search for tracks matching the mood
-	query := moodProfile.SearchQueryTerms
-	if query == "" {
-		query = moodDescription
+// delivery is what became of recommended tracks besides listing them
+type delivery struct {
+	playlistURL string
+	reused      bool   // an existing playlist was refreshed rather than a new one created
+	liked       string // how saving to Liked Songs went
+	playback    string // how starting playback went
+}
+
+// deliver saves the tracks to the mood playlist or Liked Songs and starts
+// playing them, as the options ask. A playlist that couldn't be saved is added to warn.
+func (a *MoodalystAgent) deliver(ctx context.Context, moodProfile mood.MoodProfile, tracks []spotify.Track, opts taskOptions, warn *warnings) delivery {
+	var trackURIs, trackIDs []string
+	for _, track := range tracks {
+		if track.URI != "" {
+			trackURIs = append(trackURIs, track.URI)
+		}
+		if track.ID != "" {
+			trackIDs = append(trackIDs, track.ID)
+		}
 	}
 
-	tracks, err := a.spotifyClient.SearchTracks(query, 5)
-	if err != nil {
-		log.Printf("Error searching tracks: %v", err)
-		return fmt.Sprintf("I detected your mood as '%s', but I couldn't fetch recommendations right now. Try again later!", moodProfile.Mood), nil
+	msgs := mood.MessagesFor(a.localeFor(opts))
+	var d delivery
+	// Try to create a playlist if we have user access
+	if opts.savePlaylist {
+		var err error
+		var collaborative bool
+		d.playlistURL, d.reused, collaborative, err = a.saveMoodPlaylist(ctx, moodProfile, trackURIs, opts.collaborative, msgs)
+		if err != nil {
+			a.log().Info("Skipping playlist", "error", err)
+		}
+		if err == nil && opts.collaborative && !collaborative {
+			warn.add(msgs.WarnNotCollaborative)
+		}
+		// Without user access there is no playlist to expect, so that's not worth a note
+		if err != nil && !errors.Is(err, errNoUserAccess) {
+			warn.add(msgs.WarnPlaylistNotSaved)
+		}
+	}
+	if opts.saveLiked {
+		d.liked = a.saveLikedSongs(ctx, trackIDs, msgs)
+	}
+	if opts.playNow {
+		d.playback = a.startPlayback(ctx, trackURIs, msgs)
+	}
+	return d
+}
+
+// notes tells the user about the playlist, Liked Songs and playback
+func (d delivery) notes(msgs mood.Messages) string {
+	var notes string
+	if d.playlistURL != "" && d.reused {
+		notes += "\n" + fmt.Sprintf(msgs.PlaylistRefreshed, d.playlistURL) + "\n"
+	} else if d.playlistURL != "" {
+		notes += "\n" + fmt.Sprintf(msgs.PlaylistCreated, d.playlistURL) + "\n"
+	}
+	if d.liked != "" {
+		notes += "\n" + d.liked + "\n"
+	}
+	if d.playback != "" {
+		notes += "\n" + d.playback + "\n"
+	}
+	return notes
+}
+
+// warnings collects problems that didn't stop the recommendations but that the
+// user should know about, such as recommendations falling back to search
+type warnings []string
+
+// add records a warning unless it was already recorded. Adding to a nil
+// *warnings does nothing, so callers that don't report warnings can pass nil.
+func (w *warnings) add(message string) {
+	if w == nil {
+		return
+	}
+	if !slices.Contains(*w, message) {
+		*w = append(*w, message)
+	}
+}
+
+// section renders the warnings as a "Note:" section for the end of a response,
+// or an empty string when there are none
+func (w warnings) section(msgs mood.Messages) string {
+	switch len(w) {
+	case 0:
+		return ""
+	case 1:
+		return "\n" + msgs.Note + " " + w[0] + "\n"
+	}
+	return "\n" + msgs.Note + "\n- " + strings.Join(w, "\n- ") + "\n"
+}
+
+// verboseSection shows how the tracks of an analysis were found, for users
+// tuning their request with query:
+func verboseSection(result analysis, msgs mood.Messages) string {
+	if result.query == "" {
+		return "\n" + msgs.SearchQueryNone + "\n"
+	}
+	return fmt.Sprintf("\n"+msgs.SearchQuery+"\n", result.query)
+}
+
+// recommendSequence recommends tracks for moods to go through one after the
+// other, e.g. "happy and then relaxed", splitting the tracks evenly between them.
+// Each section is ordered by energy in the direction of the whole sequence so
+// the moods flow into each other, and all sections are saved as one playlist.
+func (a *MoodalystAgent) recommendSequence(ctx context.Context, moodDescriptions []string, opts taskOptions) (string, error) {
+	perMood := max(1, opts.count/len(moodDescriptions))
+	locale := a.localeFor(opts)
+	msgs := mood.MessagesFor(locale)
+	seen := make(map[string]bool)
+	var warn warnings
+
+	var sections []mood.MoodSection
+	var sectionTracks [][]spotify.Track
+	for _, moodDescription := range moodDescriptions {
+		result, err := a.analyze(ctx, moodDescription, taskOptions{count: perMood, locale: locale}, &warn)
+		moodProfile, tracks := result.profile, result.tracks
+		if errors.Is(err, ErrNoMoodDetected) {
+			return fmt.Sprintf(msgs.SequenceNoMood, moodDescription), nil
+		}
+		if err != nil {
+			a.log().Warn("Error searching tracks", "mood", moodProfile.Mood, "error", err)
+			return searchErrorMessage(moodProfile.Mood, err, msgs), nil
+		}
+
+		// A song fits only one part of the sequence
+		var unique []spotify.Track
+		for _, t := range tracks {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				unique = append(unique, t)
+			}
+		}
+		sections = append(sections, mood.MoodSection{Profile: moodProfile})
+		sectionTracks = append(sectionTracks, unique)
 	}
 
+	// Winding down from an upbeat mood goes from high to low energy, and the other way round
+	sortBy := trackSort{feature: "energy", descending: sections[len(sections)-1].Profile.Energy < sections[0].Profile.Energy}
+	if opts.sortBy.feature != "" {
+		sortBy = opts.sortBy
+	}
+	var tracks []spotify.Track
+	for i := range sections {
+		sorted := a.sortTracks(ctx, sectionTracks[i], sortBy)
+		sections[i].Tracks = trackSummaries(sorted)
+		tracks = append(tracks, sorted...)
+	}
 	if len(tracks) == 0 {
-		return fmt.Sprintf("I understand you're feeling %s, but I couldn't find any matching songs right now.", moodProfile.Mood), nil
+		return fmt.Sprintf(msgs.SequenceNoTracks, mood.LocalizedSequenceName(sections, locale)), nil
 	}
 
-	// Get 15 additional recommendations to make a total of 20 tracks
-	var seedTrackIDs []string
+	// The playlist is named after the whole sequence and gets the first mood's cover
+	sequenceProfile := sections[0].Profile
+	sequenceProfile.Mood = mood.LocalizedSequenceName(sections, locale)
+	delivered := a.deliver(ctx, sequenceProfile, tracks, opts, &warn)
+
+	if opts.format == mood.FormatJSON {
+		return mood.MarshalSequence(sections, delivered.playlistURL)
+	}
+	return mood.FormatLocalizedSequence(sections, opts.format, locale) + delivered.notes(msgs) + warn.section(msgs), nil
+}
+
+// unsavedTracks drops the tracks already in the user's Liked Songs, keeping all
+// of them when they can't be checked or every track is saved
+func (a *MoodalystAgent) unsavedTracks(ctx context.Context, tracks []spotify.Track, msgs mood.Messages, warn *warnings) []spotify.Track {
+	if !a.spotifyClient.CanActAsUser() {
+		warn.add(msgs.WarnSignInToExclude)
+		return tracks
+	}
+
+	var ids []string
+	for _, t := range tracks {
+		if t.ID != "" {
+			ids = append(ids, t.ID)
+		}
+	}
+	saved, err := a.spotifyClient.TracksAreSaved(ctx, ids)
+	if err != nil {
+		a.log().Warn("Could not check saved tracks", "error", err)
+		warn.add(msgs.WarnSavedCheckFailed)
+		return tracks
+	}
+	isSaved := make(map[string]bool, len(ids))
+	for i, id := range ids {
+		isSaved[id] = saved[i]
+	}
+
+	unsaved := slices.DeleteFunc(slices.Clone(tracks), func(t spotify.Track) bool { return isSaved[t.ID] })
+	if len(unsaved) == 0 {
+		warn.add(msgs.WarnAllSaved)
+		return tracks
+	}
+	a.log().Debug("Dropped saved tracks", "dropped", len(tracks)-len(unsaved))
+	return unsaved
+}
+
+// sortTracks orders tracks by an audio feature, fetching the features of the
+// tracks. Tracks keep their order when the features can't be fetched.
+func (a *MoodalystAgent) sortTracks(ctx context.Context, tracks []spotify.Track, sortBy trackSort) []spotify.Track {
+	ids := make([]string, 0, len(tracks))
 	for _, t := range tracks {
+		ids = append(ids, t.ID)
+	}
+
+	features, err := a.spotifyClient.GetAudioFeatures(ctx, ids)
+	if err != nil || len(features) == 0 {
+		a.log().Debug("Not sorting tracks", "by", sortBy.feature, "error", err)
+		return tracks
+	}
+	return sortByFeature(tracks, features, sortBy)
+}
+
+// sortByFeature orders tracks by an audio feature, lowest first unless the sort
+// is descending. Tracks without features go last, and equal tracks keep their order.
+func sortByFeature(tracks []spotify.Track, features []spotify.AudioFeatures, sortBy trackSort) []spotify.Track {
+	values := make(map[string]float32, len(features))
+	for _, f := range features {
+		switch sortBy.feature {
+		case "energy":
+			values[f.ID] = f.Energy
+		case "tempo":
+			values[f.ID] = f.Tempo
+		}
+	}
+
+	sorted := slices.Clone(tracks)
+	slices.SortStableFunc(sorted, func(x, y spotify.Track) int {
+		vx, okX := values[x.ID]
+		vy, okY := values[y.ID]
+		switch {
+		case !okX && !okY:
+			return 0
+		case !okX:
+			return 1
+		case !okY:
+			return -1
+		case sortBy.descending:
+			return cmp.Compare(vy, vx)
+		default:
+			return cmp.Compare(vx, vy)
+		}
+	})
+	return sorted
+}
+
+// recommendVariations recommends tracks for several variations of the mood,
+// seeded from the tracks already found for it, and returns them as labelled groups.
+// The tracks are split evenly between the variations.
+func (a *MoodalystAgent) recommendVariations(ctx context.Context, moodProfile mood.MoodProfile, moodTracks []spotify.Track, opts taskOptions) (string, error) {
+	var seedTrackIDs []string
+	for _, t := range moodTracks {
 		if t.ID != "" {
 			seedTrackIDs = append(seedTrackIDs, t.ID)
-			log.Printf("Adding seed track ID: %s (Name: %s)", t.ID, t.Name)
 		}
 	}
 
-	// Spotify allows max 5 seeds. We use the tracks we found as seeds.
-	// If we have fewer than 5 tracks, we can fill up with genres.
-	var seedGenres []string
-	if len(seedTrackIDs) < 5 {
-		remaining := 5 - len(seedTrackIDs)
-		if len(moodProfile.SuggestedGenres) > 0 {
-			if len(moodProfile.SuggestedGenres) > remaining {
-				seedGenres = moodProfile.SuggestedGenres[:remaining]
-			} else {
-				seedGenres = moodProfile.SuggestedGenres
-			}
+	variations := mood.Variations(moodProfile, opts.variations)
+	perVariation := max(1, opts.count/len(variations))
+
+	var groups []mood.VariationTracks
+	for _, variation := range variations {
+		moodParams := a.moodAnalyzer.GetMoodParameters(variation.Profile)
+		tracks, err := a.spotifyClient.GetRecommendations(ctx, seedTrackIDs, nil, variation.Profile.SuggestedGenres, moodParams, perVariation)
+		if err != nil {
+			a.log().Warn("Failed to get recommendations for variation", "variation", variation.Name, "error", err)
+			continue
+		}
+		if len(tracks) > 0 {
+			groups = append(groups, mood.VariationTracks{Variation: variation, Tracks: trackSummaries(tracks)})
 		}
 	}
 
-	moodParams := map[string]interface{}{
-		"target_energy":       moodProfile.Energy,
-		"target_danceability": moodProfile.Danceability,
-		"target_valence":      moodProfile.Valence,
-		"target_acousticness": moodProfile.Acousticness,
+	locale := a.localeFor(opts)
+	if len(groups) == 0 {
+		return fmt.Sprintf(mood.MessagesFor(locale).NoVariations, moodProfile.Mood), nil
+	}
+	if opts.format == mood.FormatJSON {
+		return mood.MarshalVariations(groups)
+	}
+	return mood.FormatLocalizedVariations(groups, moodProfile.Mood, opts.format, locale), nil
+}
+
+// startPlayback plays the tracks on the user's active device and returns a
+// message telling the user how it went
+func (a *MoodalystAgent) startPlayback(ctx context.Context, trackURIs []string, msgs mood.Messages) string {
+	if !a.spotifyClient.CanActAsUser() {
+		return msgs.PlaybackSignIn
+	}
+	err := a.spotifyClient.StartPlayback(ctx, "", trackURIs)
+	switch {
+	case err == nil:
+		return msgs.PlaybackStarted
+	case errors.Is(err, spotify.ErrNoActiveDevice):
+		return msgs.PlaybackNoDevice
+	default:
+		a.log().Warn("Failed to start playback", "error", err)
+		return msgs.PlaybackFailed
 	}
+}
 
-	log.Printf("Fetching 15 additional recommendations using %d seed tracks and %d genres", len(seedTrackIDs), len(seedGenres))
-	recs, err := a.spotifyClient.GetRecommendations(seedTrackIDs, seedGenres, moodParams, 15)
-	if err == nil {
-		log.Printf("Successfully got %d recommendations, appending to %d existing tracks", len(recs), len(tracks))
-		tracks = append(tracks, recs...)
-		log.Printf("Total tracks now: %d", len(tracks))
-	} else {
-		log.Printf("Failed to get recommendations: %v", err)
-		// Fallback: Do additional searches with different mood keywords
-		log.Printf("Trying fallback: searching for more tracks with mood keywords")
-		fallbackQuery := fmt.Sprintf("%s %s", query, moodProfile.Mood)
-		moreTracks, searchErr := a.spotifyClient.SearchTracks(fallbackQuery, 15)
-		if searchErr == nil && len(moreTracks) > 0 {
-			log.Printf("Fallback successful: found %d additional tracks", len(moreTracks))
-			tracks = append(tracks, moreTracks...)
-		} else {
-			log.Printf("Fallback also failed: %v", searchErr)
+// saveLikedSongs saves the tracks to the user's Liked Songs and returns a
+// message telling the user how it went
+func (a *MoodalystAgent) saveLikedSongs(ctx context.Context, trackIDs []string, msgs mood.Messages) string {
+	if !a.spotifyClient.CanActAsUser() {
+		return msgs.LikedSignIn
+	}
+	err := a.spotifyClient.SaveTracks(ctx, trackIDs)
+	var batchErr *spotify.BatchError
+	switch {
+	case err == nil:
+		return msgs.LikedSaved
+	case errors.As(err, &batchErr) && batchErr.Succeeded > 0:
+		a.log().Warn("Only saved some tracks to Liked Songs", "error", err)
+		return fmt.Sprintf(msgs.LikedPartlySaved, batchErr.Succeeded)
+	default:
+		a.log().Warn("Failed to save tracks to Liked Songs", "error", err)
+		return msgs.LikedFailed
+	}
+}
+
+// searchErrorMessage explains a failed track search to the user based on the kind of failure
+func searchErrorMessage(moodName string, err error, msgs mood.Messages) string {
+	switch {
+	case errors.Is(err, spotify.ErrRateLimited):
+		return fmt.Sprintf(msgs.SearchRateLimited, moodName)
+	case errors.Is(err, spotify.ErrNotAuthenticated), errors.Is(err, spotify.ErrUnauthorized), errors.Is(err, spotify.ErrForbidden):
+		return fmt.Sprintf(msgs.SearchAuthFailed, moodName)
+	default:
+		return fmt.Sprintf(msgs.SearchFailed, moodName)
+	}
+}
+
+// saveMoodPlaylist fills the user's playlist for the mood with the given tracks and
+// returns its URL. An existing playlist with the same name is reused instead of
+// creating a duplicate, in which case reused is true. A new playlist is made
+// collaborative when asked; isCollaborative tells whether the playlist saved to is.
+// It fails when the client has no user access (user not authenticated or scope missing).
+func (a *MoodalystAgent) saveMoodPlaylist(ctx context.Context, moodProfile mood.MoodProfile, trackURIs []string, collaborative bool, msgs mood.Messages) (playlistURL string, reused, isCollaborative bool, err error) {
+	if !a.spotifyClient.CanActAsUser() {
+		return "", false, false, errNoUserAccess
+	}
+	user, err := a.spotifyClient.GetCurrentUser(ctx)
+	if err != nil {
+		return "", false, false, fmt.Errorf("%w: %w", errNoUserAccess, err)
+	}
+
+	playlistName := a.playlistName(moodProfile.Mood)
+	description := fmt.Sprintf(msgs.PlaylistDescription, moodProfile.Mood)
+
+	existing, err := a.spotifyClient.FindUserPlaylist(ctx, user.ID, playlistName)
+	if err != nil {
+		a.log().Warn("Could not look up existing playlists", "error", err)
+	}
+
+	if existing != nil {
+		a.log().Info("Reusing playlist", "id", existing.ID, "tracks", len(trackURIs))
+		if err := a.spotifyClient.ReplacePlaylistTracks(ctx, existing.ID, trackURIs); err != nil && !a.partiallyAdded(err) {
+			return "", false, false, fmt.Errorf("failed to replace playlist tracks: %w", err)
 		}
+
+		// Say when the songs were last swapped so the user knows they're fresh
+		refreshed := fmt.Sprintf(msgs.PlaylistRefreshedOn, description, time.Now().Format(msgs.DateLayout))
+		if err := a.spotifyClient.UpdatePlaylistDetails(ctx, existing.ID, "", refreshed); err != nil {
+			a.log().Warn("Could not update playlist description", "error", err)
+		}
+		return existing.ExternalURLs.Spotify, true, existing.Collaborative, nil
 	}
 
-	// Build response with recommendations
-	response := fmt.Sprintf("Based on your mood (%s), here are some song recommendations:\n\n", moodProfile.Mood)
-	var trackURIs []string
+	options := spotify.PlaylistOptions{Collaborative: collaborative}
+	playlist, err := a.spotifyClient.CreatePlaylistWithOptions(ctx, user.ID, playlistName, description, options)
+	if err != nil {
+		return "", false, false, fmt.Errorf("failed to create playlist: %w", err)
+	}
+
+	a.log().Info("Created playlist", "id", playlist.ID, "tracks", len(trackURIs))
+	if err := a.spotifyClient.AddTracksToPlaylist(ctx, playlist.ID, trackURIs); err != nil && !a.partiallyAdded(err) {
+		return "", false, false, fmt.Errorf("failed to add tracks to playlist: %w", err)
+	}
+
+	// A cover is a nice touch but not worth failing the playlist over
+	if err := a.setMoodCover(ctx, playlist.ID, moodProfile); err != nil {
+		a.log().Warn("Could not set playlist cover", "error", err)
+	}
+
+	return playlist.ExternalURLs.Spotify, false, collaborative, nil
+}
+
+// clearMoodPlaylist removes all tracks from the user's playlist for the mood in
+// the description and returns a message in the words of msgs saying how it went
+func (a *MoodalystAgent) clearMoodPlaylist(ctx context.Context, moodDescription string, msgs mood.Messages) (string, error) {
+	moodProfile := a.detectMood(moodDescription)
+	if !moodProfile.Detected {
+		return msgs.ClearWhichMood, nil
+	}
+
+	if !a.spotifyClient.CanActAsUser() {
+		return msgs.ClearNoAccess, nil
+	}
+	user, err := a.spotifyClient.GetCurrentUser(ctx)
+	if err != nil {
+		a.log().Info("Can't clear playlist", "error", err)
+		return msgs.ClearNoAccess, nil
+	}
+
+	playlistName := a.playlistName(moodProfile.Mood)
+	playlist, err := a.spotifyClient.FindUserPlaylist(ctx, user.ID, playlistName)
+	if err != nil {
+		a.log().Warn("Could not look up playlists", "error", err)
+		return msgs.ClearLookupFailed, nil
+	}
+	if playlist == nil {
+		return fmt.Sprintf(msgs.ClearNoPlaylist, moodProfile.Mood), nil
+	}
+
+	cleared := playlist.Tracks.Total
+	if cleared == 0 {
+		return fmt.Sprintf(msgs.ClearAlreadyEmpty, playlistName), nil
+	}
+
+	// Replacing the tracks with none empties the playlist in one request
+	if err := a.spotifyClient.ReplacePlaylistTracks(ctx, playlist.ID, nil); err != nil {
+		a.log().Warn("Failed to clear playlist", "id", playlist.ID, "error", err)
+		return fmt.Sprintf(msgs.ClearFailed, playlistName), nil
+	}
+
+	a.log().Info("Cleared playlist", "id", playlist.ID, "tracks", cleared)
+	if cleared == 1 {
+		return fmt.Sprintf(msgs.ClearedOne, playlistName), nil
+	}
+	return fmt.Sprintf(msgs.ClearedMany, cleared, playlistName), nil
+}
+
+// playlistName returns the name of the playlist the agent keeps for a mood today
+func (a *MoodalystAgent) playlistName(moodName string) string {
+	template := a.playlistNameTemplate
+	if template == "" {
+		template = defaultPlaylistNameTemplate
+	}
+	return renderPlaylistName(template, moodName, time.Now())
+}
+
+// renderPlaylistName fills in the {mood} and {date} placeholders of a playlist name template
+func renderPlaylistName(template, moodName string, date time.Time) string {
+	return strings.NewReplacer(
+		"{mood}", titleCase(moodName),
+		"{date}", date.Format("2006-01-02"),
+	).Replace(template)
+}
+
+// freshTracks drops the tracks already in the user's playlist for the mood,
+// keeping all of them when there is no such playlist or every track is in it
+func (a *MoodalystAgent) freshTracks(ctx context.Context, moodProfile mood.MoodProfile, tracks []spotify.Track) []spotify.Track {
+	if !a.spotifyClient.CanActAsUser() {
+		return tracks
+	}
+	user, err := a.spotifyClient.GetCurrentUser(ctx)
+	if err != nil {
+		return tracks
+	}
+	playlist, err := a.spotifyClient.FindUserPlaylist(ctx, user.ID, a.playlistName(moodProfile.Mood))
+	if err != nil || playlist == nil {
+		return tracks
+	}
+
+	existing, err := a.spotifyClient.GetPlaylistTracks(ctx, playlist.ID)
+	if err != nil {
+		a.log().Warn("Could not get playlist tracks", "id", playlist.ID, "error", err)
+		return tracks
+	}
+	inPlaylist := make(map[string]bool, len(existing))
+	for _, t := range existing {
+		inPlaylist[t.ID] = true
+	}
+
+	fresh := slices.DeleteFunc(slices.Clone(tracks), func(t spotify.Track) bool { return inPlaylist[t.ID] })
+	if len(fresh) == 0 {
+		a.log().Debug("All tracks are already in the playlist, keeping them", "id", playlist.ID)
+		return tracks
+	}
+	a.log().Debug("Dropped tracks already in the playlist", "id", playlist.ID, "dropped", len(tracks)-len(fresh))
+	return fresh
+}
+
+// setMoodCover gives a playlist a cover image matching the mood
+func (a *MoodalystAgent) setMoodCover(ctx context.Context, playlistID string, moodProfile mood.MoodProfile) error {
+	cover, err := mood.CoverImage(moodProfile)
+	if err != nil {
+		return err
+	}
+	return a.spotifyClient.SetPlaylistCover(ctx, playlistID, cover)
+}
+
+// titleCase upper-cases the first letter of each word, e.g. "feel good" becomes "Feel Good"
+func titleCase(s string) string {
+	words := strings.Fields(s)
+	for i, word := range words {
+		r, size := utf8.DecodeRuneInString(word)
+		words[i] = string(unicode.ToTitle(r)) + word[size:]
+	}
+	return strings.Join(words, " ")
+}
 
-	log.Printf("Building response with %d total tracks", len(tracks))
-	for i, track := range tracks {
-		artistName := "Unknown"
-		if len(track.Artists) > 0 {
-			artistName = track.Artists[0].Name
+// trackSummaries converts Spotify tracks into the summaries the mood package formats
+func trackSummaries(tracks []spotify.Track) []mood.TrackSummary {
+	summaries := make([]mood.TrackSummary, 0, len(tracks))
+	for _, track := range tracks {
+		summary := mood.TrackSummary{
+			Name:       track.Name,
+			Artists:    track.ArtistNames(),
+			Album:      track.Album.Name,
+			DurationMs: track.DurationMs,
+			URL:        track.ExternalURLs.Spotify,
+			URI:        track.URI,
+			PreviewURL: track.PreviewURL,
 		}
-		recommendation := mood.FormatTrackRecommendation(track.Name, artistName, track.ExternalURLs.Spotify)
-		response += fmt.Sprintf("%d. %s\n", i+1, recommendation)
-		if track.URI != "" {
-			trackURIs = append(trackURIs, track.URI)
+		if image, ok := track.Album.BestImage(albumImageWidth); ok {
+			summary.AlbumImage = image.URL
 		}
+		summaries = append(summaries, summary)
 	}
+	return summaries
+}
 
-	// Try to create a playlist if we have user access
-	user, err := a.spotifyClient.GetCurrentUser()
-	if err == nil && user != nil {
-		playlistName := fmt.Sprintf("Mood Analyst: %s Vibes", strings.Title(moodProfile.Mood))
-		description := fmt.Sprintf("A playlist curated for your %s mood.", moodProfile.Mood)
+// moodFeatures converts Spotify audio features into the ones the mood package blends
+func moodFeatures(features spotify.AudioFeatures) mood.AudioFeatures {
+	return mood.AudioFeatures{
+		Energy:       features.Energy,
+		Danceability: features.Danceability,
+		Valence:      features.Valence,
+		Acousticness: features.Acousticness,
+		Tempo:        features.Tempo,
+	}
+}
 
-		playlist, err := a.spotifyClient.CreatePlaylist(user.ID, playlistName, description)
-		if err == nil {
-			log.Printf("Created playlist, adding %d tracks", len(trackURIs))
-			err = a.spotifyClient.AddTracksToPlaylist(playlist.ID, trackURIs)
+// partiallyAdded reports whether a playlist update error still left some of the
+// tracks in the playlist, in which case the playlist is still worth sharing
+func (a *MoodalystAgent) partiallyAdded(err error) bool {
+	var batchErr *spotify.BatchError
+	if errors.As(err, &batchErr) && batchErr.Succeeded > 0 {
+		a.log().Warn("Only added some tracks to playlist", "error", err)
+		return true
+	}
+	return false
+}
+
+// authenticate signs the client in, reusing tokens saved in tokenFile when there are any
+func authenticate(spotifyClient *spotify.Client, tokenFile string) error {
+	ctx := context.Background()
+	if tokenFile != "" {
+		if _, err := os.Stat(tokenFile); err == nil {
+			err := spotifyClient.LoadTokens(ctx, tokenFile)
 			if err == nil {
-				response += fmt.Sprintf("\n✨ I've also created a playlist for you: %s\n", playlist.ExternalURLs.Spotify)
-			} else {
-				log.Printf("Failed to add tracks to playlist: %v", err)
+				slog.Info("Loaded Spotify tokens", "file", tokenFile)
+				return nil
 			}
-		} else {
-			log.Printf("Failed to create playlist: %v", err)
+			slog.Warn("Failed to load saved Spotify tokens, authenticating again", "error", err)
 		}
-	} else {
-
-		log.Printf("Skipping playlist creation (user not authenticated or scope missing): %v", err)
 	}
+	return spotifyClient.Authenticate(ctx)
+}
 
-	return response, nil
+// newLogger returns a logger writing text to w at the named level, such as
+// "debug", or info when the name is empty or unknown. Debug output includes
+// seed tracks and request URLs, so it is only shown on request.
+func newLogger(w io.Writer, levelName string) *slog.Logger {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(levelName)); err != nil {
+		level = slog.LevelInfo
+	}
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: level}))
 }
 
 func main() {
+	authorize := flag.Bool("authorize", false, "sign in a Spotify user through the browser before starting")
+	flag.Parse()
+
 	godotenv.Load()
+
+	logger := newLogger(os.Stderr, os.Getenv("LOG_LEVEL"))
+	slog.SetDefault(logger)
+
 	config := agent.DefaultConfig()
 
 	config.Name = "mood analyst"
@@ -177,26 +1954,126 @@ func main() {
 	config.NFTTokenID = os.Getenv("NFT_TOKEN_ID")
 	config.OwnerAddress = os.Getenv("OWNER_ADDRESS")
 
-	// Initialize Spotify client
-	spotifyClient, err := spotify.LoadFromEnv()
+	// Initialize Spotify client from a config file if there is one, otherwise the environment
+	var spotifyClient *spotify.Client
+	var err error
+	if configFile := os.Getenv("SPOTIFY_CONFIG_FILE"); configFile != "" {
+		spotifyClient, err = spotify.LoadFromFile(configFile)
+	} else {
+		spotifyClient, err = spotify.LoadFromEnv()
+	}
 	if err != nil {
 		log.Fatalf("Failed to initialize Spotify client: %v", err)
 	}
+	spotifyClient.Logger = logger
+
+	// Optionally throttle requests so bursts stay under Spotify's rate limits
+	if perSecond, err := strconv.ParseFloat(os.Getenv("SPOTIFY_REQUESTS_PER_SECOND"), 64); err == nil && perSecond > 0 {
+		spotifyClient.RateLimiter = rate.NewLimiter(rate.Limit(perSecond), max(1, int(perSecond)))
+	}
+
+	var historyWeight float64
+	if value := os.Getenv("MOOD_HISTORY_WEIGHT"); value != "" {
+		historyWeight, err = strconv.ParseFloat(value, 32)
+		if err != nil || historyWeight < 0 || historyWeight > 1 {
+			log.Fatalf("MOOD_HISTORY_WEIGHT must be a number between 0 and 1, got %q", value)
+		}
+	}
+
+	split := defaultTrackSplit
+	for name, value := range map[string]*int{"SEARCH_TRACKS": &split.search, "RECOMMENDED_TRACKS": &split.recs} {
+		if env := os.Getenv(name); env != "" {
+			if *value, err = strconv.Atoi(env); err != nil {
+				log.Fatalf("%s must be a whole number, got %q", name, env)
+			}
+		}
+	}
+	if err := split.validate(); err != nil {
+		log.Fatalf("Invalid SEARCH_TRACKS or RECOMMENDED_TRACKS: %v", err)
+	}
+
+	var locale mood.Locale
+	if value := os.Getenv("MOOD_LOCALE"); value != "" {
+		var ok bool
+		if locale, ok = mood.ParseLocale(value); !ok {
+			log.Fatalf("MOOD_LOCALE must be one of en, es or fr, got %q", value)
+		}
+	}
+
+	tokenFile := os.Getenv("SPOTIFY_TOKEN_FILE")
+
+	// Optionally leave out tracks recommended in the last few days, saved next to the tokens
+	var recent *recentTracks
+	if value := os.Getenv("RECENT_TRACKS_DAYS"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Fatalf("RECENT_TRACKS_DAYS must be a whole number of days, got %q", value)
+		}
+		recentFile := os.Getenv("RECENT_TRACKS_FILE")
+		if recentFile == "" && tokenFile != "" {
+			recentFile = filepath.Join(filepath.Dir(tokenFile), "recent_tracks.json")
+		}
+		if days > 0 {
+			recent, err = loadRecentTracks(recentFile, time.Duration(days)*24*time.Hour)
+			if err != nil {
+				log.Fatalf("Failed to load recent tracks: %v", err)
+			}
+		}
+	}
+
+	// Optionally sign in a Spotify user through the browser instead of using SPOTIFY_REFRESH_TOKEN
+	if *authorize {
+		redirectURI := os.Getenv("SPOTIFY_REDIRECT_URI")
+		if redirectURI == "" {
+			redirectURI = defaultRedirectURI
+		}
 
-	// Authenticate with Spotify
-	if err := spotifyClient.Authenticate(); err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+		err := spotifyClient.AuthorizeInteractive(ctx, spotify.DefaultUserScopes, redirectURI)
+		cancel()
+		if err != nil {
+			log.Fatalf("Failed to authorize with Spotify: %v", err)
+		}
+		if tokenFile != "" {
+			logger.Info("Authorized! Your tokens will be saved so you can skip this step next time", "file", tokenFile)
+		} else {
+			// The refresh token is a long-lived credential, so it is shown once
+			// on stdout rather than kept in the logs
+			fmt.Printf("Authorized! Add this line to your .env to skip this step next time:\nSPOTIFY_REFRESH_TOKEN=%s\n", spotifyClient.RefreshToken())
+		}
+	} else if err := authenticate(spotifyClient, tokenFile); err != nil {
 		log.Fatalf("Failed to authenticate with Spotify: %v", err)
 	}
 
-	log.Println("Successfully authenticated with Spotify")
+	// Remember the tokens so a restart doesn't need to sign in again
+	if tokenFile != "" {
+		if err := spotifyClient.SaveTokens(tokenFile); err != nil {
+			logger.Warn("Failed to save Spotify tokens", "error", err)
+		}
+	}
+
+	logger.Info("Successfully authenticated with Spotify")
 
-	moodAnalyzer := &mood.MoodAnalyzer{}
+	// Load custom mood categories if configured, otherwise use the built-in ones
+	moodAnalyzer, err := mood.NewMoodAnalyzerFromFile(os.Getenv("MOOD_CATEGORIES_FILE"))
+	if err != nil {
+		log.Fatalf("Failed to load mood categories: %v", err)
+	}
+	moodAnalyzer.RegisterLanguage("es", mood.SpanishKeywords)
+	moodAnalyzer.RegisterLanguage("fr", mood.FrenchKeywords)
 
 	enhancedAgent, err := agent.NewEnhancedAgent(&agent.EnhancedAgentConfig{
 		Config: config,
 		AgentHandler: &MoodalystAgent{
 			spotifyClient: spotifyClient,
 			moodAnalyzer:  moodAnalyzer,
+			logger:        logger,
+
+			playlistNameTemplate: os.Getenv("PLAYLIST_NAME_TEMPLATE"),
+			historyWeight:        float32(historyWeight),
+			trackSplit:           split,
+			recent:               recent,
+			locale:               locale,
 		},
 	})
 
@@ -204,6 +2081,6 @@ func main() {
 		log.Fatal(err)
 	}
 
-	log.Println("Starting mood analyst...")
+	logger.Info("Starting mood analyst...")
 	enhancedAgent.Run()
 }
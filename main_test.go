@@ -0,0 +1,2607 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aeemayo/mood_analyst/mood"
+	"github.com/aeemayo/mood_analyst/spotify"
+)
+
+// fakeSpotifyClient is a SpotifyClient serving canned data without network
+// access. It records what the agent asked of it. Unset fields give empty
+// results, and a nil user means the client can't act for a user.
+type fakeSpotifyClient struct {
+	mu sync.Mutex
+
+	searchResults      map[string][]spotify.Track // results by query
+	searchTracks       []spotify.Track            // results for queries not in searchResults
+	searchErr          error
+	artists            []spotify.Artist
+	recommendations    []spotify.Track
+	recommendationsErr error
+	genreSeeds         []string
+	audioFeatures      []spotify.AudioFeatures
+	topTracks          []spotify.Track
+	currentlyPlaying   *spotify.Track
+	tracks             []spotify.Track // served by GetTracks
+	user               *spotify.User
+	playbackErr        error
+	createErr          error
+	saveErr            error
+	savedTrackIDs      map[string]bool
+	savedCheckErr      error  // TracksAreSaved returns it
+	market             string // when set, PlayableTracks keeps the tracks available there
+	playlists          []spotify.Playlist
+	playlistTracks     map[string][]spotify.Track
+
+	searches        []string // the query of each search
+	searchLimits    []int
+	recommendSeeds  [][]string // the seed tracks, artists and genres of each recommendations request
+	recommendParams []map[string]interface{}
+	recommendLimits []int
+	createdOptions  []spotify.PlaylistOptions
+	addedTracks     map[string][]string
+	replacedTracks  map[string][]string
+	coversSet       []string
+	updatedDetails  map[string]string // new descriptions, by playlist ID
+	playedURIs      []string
+	savedIDs        []string
+}
+
+var _ SpotifyClient = (*fakeSpotifyClient)(nil)
+
+func (f *fakeSpotifyClient) search(query string, limit int) ([]spotify.Track, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.searches = append(f.searches, query)
+	f.searchLimits = append(f.searchLimits, limit)
+	if f.searchErr != nil {
+		return nil, f.searchErr
+	}
+	tracks, ok := f.searchResults[query]
+	if !ok {
+		tracks = f.searchTracks
+	}
+	return slices.Clone(tracks[:min(limit, len(tracks))]), nil
+}
+
+func (f *fakeSpotifyClient) SearchTracks(ctx context.Context, query string, limit int) ([]spotify.Track, error) {
+	return f.search(query, limit)
+}
+
+func (f *fakeSpotifyClient) SearchTracksPaged(ctx context.Context, query string, limit, offset int) ([]spotify.Track, error) {
+	return f.search(query, limit)
+}
+
+func (f *fakeSpotifyClient) SearchTracksFiltered(ctx context.Context, text string, filters spotify.SearchFilters, limit, offset int) ([]spotify.Track, error) {
+	return f.search(spotify.BuildSearchQuery(text, filters), limit)
+}
+
+func (f *fakeSpotifyClient) SearchArtists(ctx context.Context, query string, limit int) ([]spotify.Artist, error) {
+	return f.artists, nil
+}
+
+func (f *fakeSpotifyClient) GetRecommendations(ctx context.Context, seedTracks, seedArtists, seedGenres []string, moodParams map[string]interface{}, limit int) ([]spotify.Track, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	seeds := slices.Concat(seedTracks, seedArtists, seedGenres)
+	f.recommendSeeds = append(f.recommendSeeds, seeds)
+	f.recommendParams = append(f.recommendParams, moodParams)
+	f.recommendLimits = append(f.recommendLimits, limit)
+	if len(seeds) == 0 {
+		return nil, spotify.ErrNoSeeds
+	}
+	if f.recommendationsErr != nil {
+		return nil, f.recommendationsErr
+	}
+	return slices.Clone(f.recommendations[:min(limit, len(f.recommendations))]), nil
+}
+
+func (f *fakeSpotifyClient) GetAvailableGenreSeeds(ctx context.Context) ([]string, error) {
+	return f.genreSeeds, nil
+}
+
+func (f *fakeSpotifyClient) GetAudioFeatures(ctx context.Context, trackIDs []string) ([]spotify.AudioFeatures, error) {
+	var features []spotify.AudioFeatures
+	for _, feature := range f.audioFeatures {
+		if slices.Contains(trackIDs, feature.ID) {
+			features = append(features, feature)
+		}
+	}
+	return features, nil
+}
+
+func (f *fakeSpotifyClient) GetTopTracks(ctx context.Context, timeRange string, limit int) ([]spotify.Track, error) {
+	if f.user == nil {
+		return nil, spotify.ErrForbidden
+	}
+	return f.topTracks[:min(limit, len(f.topTracks))], nil
+}
+
+func (f *fakeSpotifyClient) GetCurrentlyPlaying(ctx context.Context) (*spotify.Track, error) {
+	return f.currentlyPlaying, nil
+}
+
+func (f *fakeSpotifyClient) GetCurrentUser(ctx context.Context) (*spotify.User, error) {
+	if f.user == nil {
+		return nil, spotify.ErrForbidden
+	}
+	return f.user, nil
+}
+
+func (f *fakeSpotifyClient) CanActAsUser() bool {
+	return f.user != nil
+}
+
+func (f *fakeSpotifyClient) PlayableTracks(tracks []spotify.Track) []spotify.Track {
+	if f.market == "" {
+		return tracks
+	}
+	return spotify.FilterByMarket(tracks, f.market)
+}
+
+func (f *fakeSpotifyClient) FindUserPlaylist(ctx context.Context, ownerID, name string) (*spotify.Playlist, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i := range f.playlists {
+		if f.playlists[i].Name == name && f.playlists[i].Owner.ID == ownerID {
+			playlist := f.playlists[i]
+			return &playlist, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeSpotifyClient) CreatePlaylistWithOptions(ctx context.Context, userID, name, description string, opts spotify.PlaylistOptions) (*spotify.Playlist, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.createErr != nil {
+		return nil, f.createErr
+	}
+
+	playlist := testPlaylist(fmt.Sprintf("created-%d", len(f.createdOptions)+1), name, userID)
+	playlist.Collaborative = opts.Collaborative
+
+	f.createdOptions = append(f.createdOptions, opts)
+	f.playlists = append(f.playlists, playlist)
+	return &playlist, nil
+}
+
+func (f *fakeSpotifyClient) AddTracksToPlaylist(ctx context.Context, playlistID string, trackURIs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.addedTracks == nil {
+		f.addedTracks = make(map[string][]string)
+	}
+	f.addedTracks[playlistID] = append(f.addedTracks[playlistID], trackURIs...)
+	return nil
+}
+
+func (f *fakeSpotifyClient) ReplacePlaylistTracks(ctx context.Context, playlistID string, trackURIs []string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.replacedTracks == nil {
+		f.replacedTracks = make(map[string][]string)
+	}
+	f.replacedTracks[playlistID] = slices.Clone(trackURIs)
+	return nil
+}
+
+func (f *fakeSpotifyClient) GetPlaylistTracks(ctx context.Context, playlistID string) ([]spotify.Track, error) {
+	return f.playlistTracks[playlistID], nil
+}
+
+func (f *fakeSpotifyClient) SetPlaylistCover(ctx context.Context, playlistID string, jpegData []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.coversSet = append(f.coversSet, playlistID)
+	return nil
+}
+
+func (f *fakeSpotifyClient) UpdatePlaylistDetails(ctx context.Context, playlistID, name, description string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.updatedDetails == nil {
+		f.updatedDetails = make(map[string]string)
+	}
+	f.updatedDetails[playlistID] = description
+	return nil
+}
+
+func (f *fakeSpotifyClient) StartPlayback(ctx context.Context, deviceID string, uris []string) error {
+	if f.playbackErr != nil {
+		return f.playbackErr
+	}
+	f.playedURIs = uris
+	return nil
+}
+
+func (f *fakeSpotifyClient) SaveTracks(ctx context.Context, trackIDs []string) error {
+	if f.saveErr != nil {
+		return f.saveErr
+	}
+	f.savedIDs = append(f.savedIDs, trackIDs...)
+	return nil
+}
+
+func (f *fakeSpotifyClient) GetTracks(ctx context.Context, trackIDs []string) ([]spotify.Track, error) {
+	var tracks []spotify.Track
+	for _, track := range f.tracks {
+		if slices.Contains(trackIDs, track.ID) {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks, nil
+}
+
+func (f *fakeSpotifyClient) TracksAreSaved(ctx context.Context, trackIDs []string) ([]bool, error) {
+	if f.savedCheckErr != nil {
+		return nil, f.savedCheckErr
+	}
+	saved := make([]bool, len(trackIDs))
+	for i, id := range trackIDs {
+		saved[i] = f.savedTrackIDs[id]
+	}
+	return saved, nil
+}
+
+// englishMessages are the strings of the default responses
+var englishMessages = mood.MessagesFor(mood.LocaleEnglish)
+
+// newTestAgent returns an agent using client that logs nothing
+func newTestAgent(client *fakeSpotifyClient) *MoodalystAgent {
+	return &MoodalystAgent{
+		spotifyClient: client,
+		moodAnalyzer:  mood.NewMoodAnalyzer(),
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+}
+
+func TestSearchErrorMessage(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("search: %w", &spotify.APIError{StatusCode: 429}), "too many requests"},
+		{spotify.ErrNotAuthenticated, "trouble signing in"},
+		{&spotify.APIError{StatusCode: 401}, "trouble signing in"},
+		{&spotify.APIError{StatusCode: 403}, "trouble signing in"},
+		{&spotify.APIError{StatusCode: 500}, "couldn't fetch recommendations"},
+		{errors.New("connection reset"), "couldn't fetch recommendations"},
+	}
+
+	for _, tt := range tests {
+		got := searchErrorMessage("happy", tt.err, englishMessages)
+		if !strings.Contains(got, tt.want) || !strings.Contains(got, "'happy'") {
+			t.Errorf("searchErrorMessage(%v) = %q, want it to mention the mood and %q", tt.err, got, tt.want)
+		}
+	}
+}
+
+// testPlaylist returns a playlist owned by ownerID
+func testPlaylist(id, name, ownerID string) spotify.Playlist {
+	var playlist spotify.Playlist
+	playlist.ID = id
+	playlist.Name = name
+	playlist.Owner.ID = ownerID
+	playlist.ExternalURLs.Spotify = "https://open.spotify.com/playlist/" + id
+	return playlist
+}
+
+func TestSaveMoodPlaylistReusesExisting(t *testing.T) {
+	fake := &fakeSpotifyClient{
+		user: &spotify.User{ID: "me"},
+		playlists: []spotify.Playlist{
+			testPlaylist("theirs", "Mood Analyst: Happy Vibes", "friend"),
+			testPlaylist("mine", "Mood Analyst: Happy Vibes", "me"),
+		},
+	}
+	agent := newTestAgent(fake)
+
+	uris := []string{"spotify:track:1", "spotify:track:2"}
+	url, reused, _, err := agent.saveMoodPlaylist(context.Background(), mood.MoodProfile{Mood: "happy"}, uris, false, englishMessages)
+	if err != nil {
+		t.Fatalf("saveMoodPlaylist() error = %v", err)
+	}
+
+	if !reused || url != "https://open.spotify.com/playlist/mine" {
+		t.Errorf("saveMoodPlaylist() = %q, reused %v, want the user's existing playlist", url, reused)
+	}
+	if got := fake.replacedTracks["mine"]; !slices.Equal(got, uris) {
+		t.Errorf("replaced tracks = %v, want %v", got, uris)
+	}
+	if len(fake.createdOptions) != 0 {
+		t.Errorf("created playlists with %+v, want none", fake.createdOptions)
+	}
+	if !strings.Contains(fake.updatedDetails["mine"], "Refreshed on") {
+		t.Errorf("description = %q, want it to say when it was refreshed", fake.updatedDetails["mine"])
+	}
+}
+
+func TestSaveMoodPlaylistCreatesMissing(t *testing.T) {
+	fake := &fakeSpotifyClient{
+		user:      &spotify.User{ID: "me"},
+		playlists: []spotify.Playlist{testPlaylist("sad", "Mood Analyst: Sad Vibes", "me")},
+	}
+	agent := newTestAgent(fake)
+
+	uris := []string{"spotify:track:1"}
+	url, reused, _, err := agent.saveMoodPlaylist(context.Background(), mood.MoodProfile{Mood: "happy"}, uris, false, englishMessages)
+	if err != nil {
+		t.Fatalf("saveMoodPlaylist() error = %v", err)
+	}
+
+	if reused || url != "https://open.spotify.com/playlist/created-1" {
+		t.Errorf("saveMoodPlaylist() = %q, reused %v, want a new playlist", url, reused)
+	}
+	if got := fake.addedTracks["created-1"]; !slices.Equal(got, uris) {
+		t.Errorf("added tracks = %v, want %v", got, uris)
+	}
+	if len(fake.replacedTracks) != 0 {
+		t.Errorf("replaced tracks of %v, want none", fake.replacedTracks)
+	}
+
+	// A second save for the same mood finds the playlist just made
+	if _, reused, _, err := agent.saveMoodPlaylist(context.Background(), mood.MoodProfile{Mood: "happy"}, uris, false, englishMessages); err != nil || !reused {
+		t.Errorf("second saveMoodPlaylist() reused = %v, error = %v, want the new playlist reused", reused, err)
+	}
+}
+
+func TestSaveMoodPlaylistWithoutUser(t *testing.T) {
+	agent := newTestAgent(&fakeSpotifyClient{})
+
+	_, _, _, err := agent.saveMoodPlaylist(context.Background(), mood.MoodProfile{Mood: "happy"}, nil, false, englishMessages)
+	if !errors.Is(err, errNoUserAccess) {
+		t.Errorf("saveMoodPlaylist() error = %v, want errNoUserAccess", err)
+	}
+}
+
+func TestParseTrackCount(t *testing.T) {
+	tests := []struct {
+		args      string
+		wantCount int
+		wantRest  string
+	}{
+		{"happy 30", 30, "happy"},
+		{"happy", defaultTrackCount, "happy"},
+		{"", defaultTrackCount, ""},
+		{"happy 500", maxTrackCount, "happy"},
+		{"happy 0", defaultTrackCount, "happy 0"},
+		{"happy -5", defaultTrackCount, "happy -5"},
+		{"sad songs from 2015", defaultTrackCount, "sad songs from 2015"},
+		{"happy 1000", maxTrackCount, "happy"},
+	}
+
+	for _, tt := range tests {
+		count, rest := parseTrackCount(strings.Fields(tt.args))
+		if count != tt.wantCount || strings.Join(rest, " ") != tt.wantRest {
+			t.Errorf("parseTrackCount(%q) = %d, %q, want %d, %q", tt.args, count, strings.Join(rest, " "), tt.wantCount, tt.wantRest)
+		}
+	}
+}
+
+func TestSplitTrackCount(t *testing.T) {
+	tests := []struct {
+		split      trackSplit
+		count      int
+		wantSearch int
+		wantRecs   int
+	}{
+		{trackSplit{}, 20, 5, 15},
+		{trackSplit{}, 40, 10, 30},
+		{trackSplit{}, 2, 1, 1},
+		{trackSplit{}, 1, 1, 0},
+		{trackSplit{}, maxTrackCount, 25, 75},
+		{trackSplit{search: 1, recs: 1}, 200, spotify.MaxSearchLimit, spotify.MaxRecommendationsLimit},
+	}
+
+	for _, tt := range tests {
+		agent := &MoodalystAgent{trackSplit: tt.split}
+		search, recs := agent.splitTrackCount(tt.count)
+		if search != tt.wantSearch || recs != tt.wantRecs {
+			t.Errorf("splitTrackCount(%d) with split %+v = %d, %d, want %d, %d", tt.count, tt.split, search, recs, tt.wantSearch, tt.wantRecs)
+		}
+	}
+}
+
+// fakeTrack returns a track with the given ID and name by one artist
+func fakeTrack(id, name, artist string) spotify.Track {
+	track := spotify.Track{ID: id, Name: name, URI: "spotify:track:" + id}
+	track.Artists = append(track.Artists, struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	}{ID: "artist-" + artist, Name: artist})
+	track.ExternalURLs.Spotify = "https://open.spotify.com/track/" + id
+	return track
+}
+
+// fakeTracks returns n tracks with IDs prefix-1, prefix-2, ...
+func fakeTracks(prefix string, n int) []spotify.Track {
+	tracks := make([]spotify.Track, n)
+	for i := range tracks {
+		id := fmt.Sprintf("%s-%d", prefix, i+1)
+		tracks[i] = fakeTrack(id, "Song "+id, "Artist "+id)
+	}
+	return tracks
+}
+
+func TestRecommendMusicHonorsTrackCount(t *testing.T) {
+	tests := []struct {
+		task string
+		want int
+	}{
+		{"mood_analyzer I feel happy", defaultTrackCount},
+		{"mood_analyzer I feel happy 30", 30},
+		{"mood_analyzer I feel happy 3", 3},
+	}
+
+	for _, tt := range tests {
+		agent := newTestAgent(&fakeSpotifyClient{
+			searchTracks:    fakeTracks("search", 50),
+			recommendations: fakeTracks("rec", 100),
+		})
+		response, err := agent.ProcessTask(context.Background(), tt.task)
+		if err != nil {
+			t.Fatalf("ProcessTask(%q) error = %v", tt.task, err)
+		}
+		if got := strings.Count(response, "🎵"); got != tt.want {
+			t.Errorf("ProcessTask(%q) recommended %d tracks, want %d", tt.task, got, tt.want)
+		}
+	}
+}
+
+func TestTitleCase(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"happy", "Happy"},
+		{"feel good", "Feel Good"},
+		{"bittersweet  party", "Bittersweet Party"},
+		{"ébullient", "Ébullient"},
+		{"Already Titled", "Already Titled"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := titleCase(tt.in); got != tt.want {
+			t.Errorf("titleCase(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTrackSummaries(t *testing.T) {
+	withArt := fakeTrack("1", "Hello", "Adele")
+	withArt.Album.Name = "25"
+	withArt.Album.Images = []spotify.Image{
+		{URL: "https://i.scdn.co/image/640", Width: 640},
+		{URL: "https://i.scdn.co/image/300", Width: 300},
+		{URL: "https://i.scdn.co/image/64", Width: 64},
+	}
+	withoutArt := fakeTrack("2", "Skyfall", "Adele")
+
+	summaries := trackSummaries([]spotify.Track{withArt, withoutArt})
+	if len(summaries) != 2 {
+		t.Fatalf("got %d summaries, want 2", len(summaries))
+	}
+	got := summaries[0]
+	if got.Name != "Hello" || !slices.Equal(got.Artists, []string{"Adele"}) || got.Album != "25" ||
+		got.URL != "https://open.spotify.com/track/1" || got.URI != "spotify:track:1" {
+		t.Errorf("summary = %+v, want the track's name, artists, album and links", got)
+	}
+	if got.AlbumImage != "https://i.scdn.co/image/300" {
+		t.Errorf("AlbumImage = %q, want the card-sized image", got.AlbumImage)
+	}
+	if got := summaries[1].AlbumImage; got != "" {
+		t.Errorf("AlbumImage = %q for a track without art, want it empty", got)
+	}
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	tests := []struct {
+		level     string
+		wantDebug bool
+		wantInfo  bool
+	}{
+		{"", false, true},
+		{"info", false, true},
+		{"debug", true, true},
+		{"DEBUG", true, true},
+		{"warn", false, false},
+		{"chatty", false, true},
+	}
+
+	for _, tt := range tests {
+		var buf strings.Builder
+		logger := newLogger(&buf, tt.level)
+		logger.Debug("debug message")
+		logger.Info("info message")
+
+		if got := strings.Contains(buf.String(), "debug message"); got != tt.wantDebug {
+			t.Errorf("newLogger(%q) logged debug = %v, want %v", tt.level, got, tt.wantDebug)
+		}
+		if got := strings.Contains(buf.String(), "info message"); got != tt.wantInfo {
+			t.Errorf("newLogger(%q) logged info = %v, want %v", tt.level, got, tt.wantInfo)
+		}
+	}
+}
+
+func TestDebugLogsSuppressedAtInfo(t *testing.T) {
+	for _, level := range []string{"info", "debug"} {
+		var buf strings.Builder
+		agent := newTestAgent(&fakeSpotifyClient{
+			searchTracks:    fakeTracks("search", 5),
+			recommendations: fakeTracks("rec", 15),
+		})
+		agent.logger = newLogger(&buf, level)
+
+		if _, err := agent.ProcessTask(context.Background(), "mood_analyzer I feel happy"); err != nil {
+			t.Fatalf("ProcessTask() error = %v", err)
+		}
+
+		logs := buf.String()
+		if !strings.Contains(logs, "Detected mood") {
+			t.Errorf("at %s level, logs = %q, want the detected mood at info", level, logs)
+		}
+		// Debug output carries the user's message and the seed tracks
+		hasDebug := strings.Contains(logs, "level=DEBUG") || strings.Contains(logs, "I feel happy") || strings.Contains(logs, "search-1")
+		if hasDebug != (level == "debug") {
+			t.Errorf("at %s level, logs include debug details = %v:\n%s", level, hasDebug, logs)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	fake := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	profile, tracks, err := newTestAgent(fake).Analyze(context.Background(), "I feel sad and lonely")
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if profile.Mood != "sad" || !profile.Detected {
+		t.Errorf("Analyze() mood = %q, detected %v, want sad", profile.Mood, profile.Detected)
+	}
+	if profile.Valence >= 0.5 {
+		t.Errorf("Analyze() valence = %.2f, want a sad profile's low valence", profile.Valence)
+	}
+	if len(tracks) != defaultTrackCount || tracks[0].ID != "search-1" || tracks[len(tracks)-1].ID != "rec-15" {
+		t.Errorf("Analyze() tracks = %v, want the 5 searched then 15 recommended tracks", trackIDs(tracks))
+	}
+	if len(fake.recommendSeeds) != 1 || !slices.Contains(fake.recommendSeeds[0], "search-1") {
+		t.Errorf("recommendation seeds = %v, want them seeded from the search", fake.recommendSeeds)
+	}
+}
+
+func TestAnalyzeNoMood(t *testing.T) {
+	fake := &fakeSpotifyClient{}
+
+	profile, tracks, err := newTestAgent(fake).Analyze(context.Background(), "the weather report")
+	if !errors.Is(err, ErrNoMoodDetected) {
+		t.Errorf("Analyze() error = %v, want ErrNoMoodDetected", err)
+	}
+	if profile.Detected || tracks != nil {
+		t.Errorf("Analyze() = %+v, %v, want an undetected profile and no tracks", profile, tracks)
+	}
+	if len(fake.searches) != 0 {
+		t.Errorf("Analyze() searched %q, want no requests", fake.searches)
+	}
+}
+
+func TestAnalyzeSearchFailure(t *testing.T) {
+	fake := &fakeSpotifyClient{searchErr: fmt.Errorf("failed to search tracks: %w", spotify.ErrRateLimited)}
+
+	profile, _, err := newTestAgent(fake).Analyze(context.Background(), "I feel happy")
+	if !errors.Is(err, spotify.ErrRateLimited) {
+		t.Errorf("Analyze() error = %v, want ErrRateLimited", err)
+	}
+	if profile.Mood != "happy" {
+		t.Errorf("Analyze() mood = %q, want the detected profile even on failure", profile.Mood)
+	}
+}
+
+// trackIDs returns the IDs of tracks
+func trackIDs(tracks []spotify.Track) []string {
+	ids := make([]string, len(tracks))
+	for i, track := range tracks {
+		ids[i] = track.ID
+	}
+	return ids
+}
+
+func TestRecommendMusicWithFakeClient(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+	agent := newTestAgent(client)
+
+	response, err := agent.ProcessTask(context.Background(), "mood_analyzer I feel happy")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"Based on your mood (happy), here are some song recommendations:",
+		"1. 🎵 Song search-1 by Artist search-1",
+		"20. 🎵 Song rec-15 by Artist rec-15",
+		"✨ I've also created a playlist for you: https://open.spotify.com/playlist/created-1",
+	} {
+		if !strings.Contains(response, want) {
+			t.Errorf("response missing %q:\n%s", want, response)
+		}
+	}
+
+	if len(client.createdOptions) != 1 || client.createdOptions[0] != (spotify.PlaylistOptions{}) {
+		t.Errorf("created playlists with %+v, want one private playlist", client.createdOptions)
+	}
+	if !slices.Contains(client.coversSet, "created-1") {
+		t.Error("no cover set on the new playlist")
+	}
+	added := client.addedTracks["created-1"]
+	if len(added) != defaultTrackCount || added[0] != "spotify:track:search-1" {
+		t.Errorf("added %v to the playlist, want the %d recommended tracks", added, defaultTrackCount)
+	}
+}
+
+func TestRecommendMusicWithoutUser(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if strings.Contains(response, "playlist") || strings.Contains(response, "Note:") {
+		t.Errorf("response mentions a playlist or problem without user access:\n%s", response)
+	}
+	if len(client.createdOptions) != 0 {
+		t.Errorf("created %d playlists without user access, want none", len(client.createdOptions))
+	}
+}
+
+func TestAnalyzeFiltersByPopularity(t *testing.T) {
+	tracks := fakeTracks("search", 6)
+	for i := range tracks {
+		tracks[i].Popularity = i * 20 // 0, 20, ..., 100
+	}
+	client := &fakeSpotifyClient{searchTracks: tracks}
+
+	_, found, err := newTestAgent(client).Analyze(context.Background(), "chill underground")
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	for _, track := range found {
+		if track.Popularity > 40 {
+			t.Errorf("Analyze() kept %s with popularity %d, want at most 40", track.ID, track.Popularity)
+		}
+	}
+	if len(found) != 3 {
+		t.Errorf("Analyze() found %v, want the 3 least popular tracks", trackIDs(found))
+	}
+}
+
+func TestProcessTaskSurprise(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer surprise me")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if !strings.Contains(response, "🎲 Surprise!") {
+		t.Errorf("ProcessTask() = %q, want the surprise intro", response)
+	}
+	if len(client.recommendSeeds) == 0 {
+		t.Error("ProcessTask() made no recommendation request, want one for the picked mood")
+	}
+}
+
+func TestIsSurprise(t *testing.T) {
+	tests := []struct {
+		desc string
+		want bool
+	}{
+		{"surprise", true},
+		{"surprise me", true},
+		{"what a surprise, i'm sad", false},
+		{"happy", false},
+	}
+	for _, tt := range tests {
+		if got := isSurprise(tt.desc); got != tt.want {
+			t.Errorf("isSurprise(%q) = %v, want %v", tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestDetectMoodBlendsMixedFeelings(t *testing.T) {
+	agent := newTestAgent(&fakeSpotifyClient{})
+
+	if got := agent.detectMood("I'm happy but also kind of sad"); got.Mood != mood.BittersweetMood || !got.Ambiguous {
+		t.Errorf("detectMood() = %q, ambiguous %v, want %q", got.Mood, got.Ambiguous, mood.BittersweetMood)
+	}
+	if got := agent.detectMood("happy and cheerful"); got.Mood != "happy" || got.Ambiguous {
+		t.Errorf("detectMood() = %q, ambiguous %v, want happy", got.Mood, got.Ambiguous)
+	}
+
+	response, err := agent.describeMood("I'm happy but also kind of sad", mood.FormatPlain, mood.LocaleEnglish)
+	if err != nil {
+		t.Fatalf("describeMood() error = %v", err)
+	}
+	if !strings.Contains(strings.ToLower(response), mood.BittersweetMood) {
+		t.Errorf("describeMood() = %q, want the bittersweet mood", response)
+	}
+}
+
+func TestProcessTaskAmbiguous(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I'm happy but also kind of sad")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if !strings.Contains(response, "You sound a little torn") {
+		t.Errorf("ProcessTask() = %q, want the mixed-feelings note", response)
+	}
+}
+
+func TestRecommendMusicSeedsFromCurrentlyPlaying(t *testing.T) {
+	playing := fakeTrack("now", "Now Playing", "current")
+	client := &fakeSpotifyClient{
+		user:             &spotify.User{ID: "me"},
+		currentlyPlaying: &playing,
+		searchTracks:     fakeTracks("search", 5),
+		recommendations:  fakeTracks("rec", 15),
+	}
+
+	if _, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.recommendSeeds) == 0 {
+		t.Fatal("no recommendations requested")
+	}
+	seeds := client.recommendSeeds[0]
+	if seeds[0] != "now" {
+		t.Errorf("recommendation seeds = %v, want the playing track first", seeds)
+	}
+}
+
+func TestRecommendMusicNothingPlaying(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	if _, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.recommendSeeds) == 0 || client.recommendSeeds[0][0] != "search-1" {
+		t.Errorf("recommendation seeds = %v, want them led by the searched tracks", client.recommendSeeds)
+	}
+}
+
+func TestStartPlayback(t *testing.T) {
+	uris := []string{"spotify:track:1"}
+	tests := []struct {
+		name   string
+		client *fakeSpotifyClient
+		want   string
+	}{
+		{"playing", &fakeSpotifyClient{user: &spotify.User{ID: "me"}}, "Playing these"},
+		{"no device", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, playbackErr: fmt.Errorf("start: %w", spotify.ErrNoActiveDevice)}, "open Spotify on one of your devices"},
+		{"failed", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, playbackErr: spotify.ErrForbidden}, "Premium"},
+		{"no user", &fakeSpotifyClient{}, "sign in"},
+	}
+
+	for _, tt := range tests {
+		if got := newTestAgent(tt.client).startPlayback(context.Background(), uris, englishMessages); !strings.Contains(got, tt.want) {
+			t.Errorf("%s: startPlayback() = %q, want it to mention %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendMusicSeedsFromPlayingArtist(t *testing.T) {
+	playing := fakeTrack("now", "Now Playing", "current")
+	client := &fakeSpotifyClient{
+		user:             &spotify.User{ID: "me"},
+		currentlyPlaying: &playing,
+		searchTracks:     fakeTracks("search", 5),
+		recommendations:  fakeTracks("rec", 15),
+	}
+
+	if _, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.recommendSeeds) == 0 {
+		t.Fatal("no recommendations requested")
+	}
+	seeds := client.recommendSeeds[0]
+	if !slices.Contains(seeds, "artist-current") {
+		t.Errorf("recommendation seeds = %v, want the playing track's artist", seeds)
+	}
+}
+
+func TestSetMoodCover(t *testing.T) {
+	client := &fakeSpotifyClient{}
+	agent := newTestAgent(client)
+
+	if err := agent.setMoodCover(context.Background(), "p1", mood.NewMoodAnalyzer().AnalyzeMood("happy")); err != nil {
+		t.Fatalf("setMoodCover() error = %v", err)
+	}
+	if !slices.Equal(client.coversSet, []string{"p1"}) {
+		t.Errorf("covers set on %v, want [p1]", client.coversSet)
+	}
+}
+
+func TestRecommendMusicShowsPreviews(t *testing.T) {
+	tracks := fakeTracks("search", 5)
+	tracks[0].PreviewURL = "https://p.scdn.co/mp3-preview/search-1"
+	client := &fakeSpotifyClient{
+		searchTracks:    tracks,
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if got := strings.Count(response, "▶ preview"); got != 1 {
+		t.Errorf("response has %d preview links, want 1:\n%s", got, response)
+	}
+	if !strings.Contains(response, "▶ preview: https://p.scdn.co/mp3-preview/search-1") {
+		t.Errorf("response missing the preview link:\n%s", response)
+	}
+}
+
+func TestRecommendMusicUnknownArtist(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer sad like nobody at all")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if !strings.Contains(response, "I couldn't find nobody at all on Spotify") {
+		t.Errorf("response missing the unknown artist note:\n%s", response)
+	}
+}
+
+func TestFindSimilarArtist(t *testing.T) {
+	agent := newTestAgent(&fakeSpotifyClient{artists: []spotify.Artist{{ID: "a1", Name: "Adele"}}})
+	if got := agent.findSimilarArtist(context.Background(), "adele"); got == nil || got.ID != "a1" {
+		t.Errorf("findSimilarArtist(adele) = %+v, want a1", got)
+	}
+	if got := agent.findSimilarArtist(context.Background(), ""); got != nil {
+		t.Errorf("findSimilarArtist(\"\") = %+v, want nil", got)
+	}
+
+	agent = newTestAgent(&fakeSpotifyClient{})
+	if got := agent.findSimilarArtist(context.Background(), "nobody"); got != nil {
+		t.Errorf("findSimilarArtist(nobody) = %+v, want nil when nothing is found", got)
+	}
+
+	agent = newTestAgent(&fakeSpotifyClient{artists: []spotify.Artist{
+		{ID: "tribute", Name: "Adele Tribute Band"},
+		{ID: "beatles", Name: "The Beatles"},
+	}})
+	if got := agent.findSimilarArtist(context.Background(), "beatles"); got == nil || got.ID != "beatles" {
+		t.Errorf("findSimilarArtist(beatles) = %+v, want the matching second hit", got)
+	}
+	if got := agent.findSimilarArtist(context.Background(), "adele"); got != nil {
+		t.Errorf("findSimilarArtist(adele) = %+v, want nil when no hit has that name", got)
+	}
+}
+
+func TestSameArtistName(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"Adele", "adele", true},
+		{"The Beatles", "beatles", true},
+		{"Guns N' Roses", "guns n roses", true},
+		{"Simon & Garfunkel", "simon and garfunkel", true},
+		{"Bon Iver", "boniver", true},
+		{"Adele Tribute Band", "adele", false},
+		{"Nobody", "nobody at all", false},
+	}
+	for _, tt := range tests {
+		if got := sameArtistName(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameArtistName(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendMusicSeedsFromSimilarArtist(t *testing.T) {
+	client := &fakeSpotifyClient{
+		artists:         []spotify.Artist{{ID: "adele-id", Name: "Adele"}},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	if _, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer sad songs like adele"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.searches) == 0 || client.searches[0] != `artist:"Adele"` {
+		t.Errorf("searches = %q, want the artist's songs first", client.searches)
+	}
+	if len(client.recommendSeeds) == 0 || !slices.Contains(client.recommendSeeds[0], "adele-id") {
+		t.Errorf("recommendation seeds = %v, want the artist's ID among them", client.recommendSeeds)
+	}
+}
+
+func TestRecommendMusicPassesAllSeedCandidates(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	profile, _, err := newTestAgent(client).Analyze(context.Background(), "I feel sad")
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(client.recommendSeeds) == 0 {
+		t.Fatal("no recommendations requested")
+	}
+
+	// The client picks the seeds, so the agent hands over every candidate
+	want := slices.Concat(trackIDs(fakeTracks("search", 5)), profile.SuggestedGenres)
+	if got := client.recommendSeeds[0]; !slices.Equal(got, want) {
+		t.Errorf("seed candidates = %v, want all searched tracks and genres %v", got, want)
+	}
+}
+
+func TestClearPlaylist(t *testing.T) {
+	full := testPlaylist("mine", "Mood Analyst: Happy Vibes", "me")
+	full.Tracks.Total = 12
+	single := testPlaylist("mine", "Mood Analyst: Happy Vibes", "me")
+	single.Tracks.Total = 1
+	empty := testPlaylist("mine", "Mood Analyst: Happy Vibes", "me")
+
+	tests := []struct {
+		name        string
+		task        string
+		client      *fakeSpotifyClient
+		want        string
+		wantCleared bool
+	}{
+		{"clears tracks", "clear_playlist happy", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, playlists: []spotify.Playlist{full}},
+			"🧹 Cleared 12 tracks from 'Mood Analyst: Happy Vibes'.", true},
+		{"one track", "clear_playlist happy", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, playlists: []spotify.Playlist{single}},
+			"🧹 Cleared 1 track from 'Mood Analyst: Happy Vibes'.", true},
+		{"already empty", "clear_playlist happy", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, playlists: []spotify.Playlist{empty}},
+			"Your playlist 'Mood Analyst: Happy Vibes' is already empty.", false},
+		{"no playlist", "clear_playlist happy", &fakeSpotifyClient{user: &spotify.User{ID: "me"}},
+			"You don't have a happy playlist yet. Try 'mood_analyzer I feel happy' to make one.", false},
+		{"someone else's playlist", "clear_playlist happy", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, playlists: []spotify.Playlist{testPlaylist("theirs", "Mood Analyst: Happy Vibes", "friend")}},
+			"You don't have a happy playlist yet. Try 'mood_analyzer I feel happy' to make one.", false},
+		{"no user", "clear_playlist happy", &fakeSpotifyClient{},
+			"I need access to your Spotify account to clear playlists. Please sign in and try again.", false},
+		{"no mood", "clear_playlist the weather", &fakeSpotifyClient{user: &spotify.User{ID: "me"}},
+			"I couldn't tell which mood's playlist you mean. Example: 'clear_playlist happy'", false},
+		{"no arguments", "clear_playlist", &fakeSpotifyClient{},
+			"Which mood's playlist should I clear? Example: 'clear_playlist happy'", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestAgent(tt.client).ProcessTask(context.Background(), tt.task)
+			if err != nil {
+				t.Fatalf("ProcessTask(%q) error = %v", tt.task, err)
+			}
+			if got != tt.want {
+				t.Errorf("ProcessTask(%q) = %q, want %q", tt.task, got, tt.want)
+			}
+
+			replaced, cleared := tt.client.replacedTracks["mine"]
+			if cleared != tt.wantCleared || len(replaced) != 0 {
+				t.Errorf("replaced tracks = %v (cleared %v), want cleared %v with no tracks left", replaced, cleared, tt.wantCleared)
+			}
+		})
+	}
+}
+
+func TestRenderPlaylistName(t *testing.T) {
+	date := time.Date(2026, time.March, 7, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		template string
+		mood     string
+		want     string
+	}{
+		{defaultPlaylistNameTemplate, "happy", "Mood Analyst: Happy Vibes"},
+		{defaultPlaylistNameTemplate, "bittersweet", "Mood Analyst: Bittersweet Vibes"},
+		{"{date} {mood}", "sad", "2026-03-07 Sad"},
+		{"My {mood} mix ({date}), {mood} again", "chill", "My Chill mix (2026-03-07), Chill again"},
+		{"No placeholders", "happy", "No placeholders"},
+	}
+
+	for _, tt := range tests {
+		if got := renderPlaylistName(tt.template, tt.mood, date); got != tt.want {
+			t.Errorf("renderPlaylistName(%q, %q) = %q, want %q", tt.template, tt.mood, got, tt.want)
+		}
+	}
+}
+
+func TestPlaylistNameTemplate(t *testing.T) {
+	client := &fakeSpotifyClient{user: &spotify.User{ID: "me"}}
+	agent := newTestAgent(client)
+	agent.playlistNameTemplate = "{mood} on {date}"
+
+	if _, _, _, err := agent.saveMoodPlaylist(context.Background(), mood.MoodProfile{Mood: "happy"}, []string{"spotify:track:1"}, false, englishMessages); err != nil {
+		t.Fatalf("saveMoodPlaylist() error = %v", err)
+	}
+
+	want := "Happy on " + time.Now().Format("2006-01-02")
+	if len(client.playlists) != 1 || client.playlists[0].Name != want {
+		t.Errorf("created playlists %+v, want one named %q", client.playlists, want)
+	}
+	if got := newTestAgent(client).playlistName("happy"); got != "Mood Analyst: Happy Vibes" {
+		t.Errorf("playlistName() without a template = %q, want the default", got)
+	}
+}
+
+func TestSaveLikedSongs(t *testing.T) {
+	ids := []string{"t1", "t2", "t3"}
+	tests := []struct {
+		name   string
+		client *fakeSpotifyClient
+		want   string
+	}{
+		{"saved", &fakeSpotifyClient{user: &spotify.User{ID: "me"}}, "❤️ I've saved these songs to your Liked Songs."},
+		{"partly saved", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, saveErr: &spotify.BatchError{Total: 3, Succeeded: 2}},
+			"❤️ I've saved 2 of these songs to your Liked Songs."},
+		{"failed", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, saveErr: spotify.ErrForbidden},
+			"I couldn't save these to your Liked Songs right now (that needs a signed-in Spotify account)."},
+		{"no user", &fakeSpotifyClient{}, "To save these to your Liked Songs, sign in with your Spotify account."},
+	}
+
+	for _, tt := range tests {
+		if got := newTestAgent(tt.client).saveLikedSongs(context.Background(), ids, englishMessages); got != tt.want {
+			t.Errorf("%s: saveLikedSongs() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRecommendMusicSaveLiked(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy save:liked")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.savedIDs) != defaultTrackCount || client.savedIDs[0] != "search-1" {
+		t.Errorf("saved %v to Liked Songs, want the %d recommended track IDs", client.savedIDs, defaultTrackCount)
+	}
+	if len(client.createdOptions) != 0 {
+		t.Errorf("created %d playlists, want none when only saving to Liked Songs", len(client.createdOptions))
+	}
+	if !strings.Contains(response, "Liked Songs") {
+		t.Errorf("response doesn't mention Liked Songs:\n%s", response)
+	}
+}
+
+func TestRecommendMusicIntensitySummary(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel sad")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	summary := mood.FormatIntensitySummary(mood.NewMoodAnalyzer().AnalyzeMood("I feel sad"))
+	if summary == "" || !strings.Contains(response, summary) {
+		t.Errorf("response missing the intensity summary %q:\n%s", summary, response)
+	}
+}
+
+func TestRecommendVariations(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel relaxed variations:2")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	for _, want := range []string{"Variation A (acoustic)", "Variation B (upbeat)"} {
+		if !strings.Contains(response, want) {
+			t.Errorf("response missing %q:\n%s", want, response)
+		}
+	}
+
+	// The mood's own recommendations come first, then one request per variation
+	if len(client.recommendParams) != 3 {
+		t.Fatalf("made %d recommendation requests, want the mood's and one per variation", len(client.recommendParams))
+	}
+	acoustic, upbeat := client.recommendParams[1], client.recommendParams[2]
+	if acoustic["target_acousticness"] == upbeat["target_acousticness"] || acoustic["target_energy"] == upbeat["target_energy"] {
+		t.Errorf("variation parameters = %v and %v, want distinct targets", acoustic, upbeat)
+	}
+}
+
+func TestRecommendVariationsNoneFound(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:       fakeTracks("search", 5),
+		recommendationsErr: spotify.ErrNotFound,
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel relaxed variations:3")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if want := "I couldn't put together any variations right now."; !strings.Contains(response, want) {
+		t.Errorf("ProcessTask() = %q, want it to say no variations were found", response)
+	}
+}
+
+func TestParseVariations(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     int
+		wantRest []string
+	}{
+		{[]string{"happy", "variations:2"}, 2, []string{"happy"}},
+		{[]string{"variations:3", "sad"}, 3, []string{"sad"}},
+		{[]string{"happy", "variations:1"}, 0, []string{"happy", "variations:1"}},
+		{[]string{"happy", "variations:9"}, 0, []string{"happy", "variations:9"}},
+		{[]string{"happy"}, 0, []string{"happy"}},
+	}
+
+	for _, tt := range tests {
+		got, rest := parseVariations(tt.args)
+		if got != tt.want || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("parseVariations(%q) = %d, %q, want %d, %q", tt.args, got, rest, tt.want, tt.wantRest)
+		}
+	}
+}
+
+func TestProcessTaskTypographicPunctuation(t *testing.T) {
+	agent := newTestAgent(&fakeSpotifyClient{searchTracks: fakeTracks("search", 5)})
+
+	for _, task := range []string{"mood_analyzer I’m so happy", "mood_analyzer so happy—really"} {
+		response, err := agent.ProcessTask(context.Background(), task)
+		if err != nil {
+			t.Fatalf("ProcessTask(%q) error = %v", task, err)
+		}
+		if !strings.Contains(response, "(happy)") {
+			t.Errorf("ProcessTask(%q) = %q, want the happy mood", task, response)
+		}
+	}
+}
+
+func TestFreshTracks(t *testing.T) {
+	tracks := fakeTracks("rec", 4)
+	playlist := testPlaylist("mine", "Mood Analyst: Happy Vibes", "me")
+	happy := mood.MoodProfile{Mood: "happy"}
+
+	client := &fakeSpotifyClient{
+		user:           &spotify.User{ID: "me"},
+		playlists:      []spotify.Playlist{playlist},
+		playlistTracks: map[string][]spotify.Track{"mine": {tracks[1], tracks[3]}},
+	}
+	if got := trackIDs(newTestAgent(client).freshTracks(context.Background(), happy, tracks)); !slices.Equal(got, []string{"rec-1", "rec-3"}) {
+		t.Errorf("freshTracks() = %v, want the tracks not in the playlist", got)
+	}
+
+	// Everything is already there, so there's nothing better to offer
+	client.playlistTracks["mine"] = tracks
+	if got := newTestAgent(client).freshTracks(context.Background(), happy, tracks); len(got) != len(tracks) {
+		t.Errorf("freshTracks() = %v, want all tracks kept", trackIDs(got))
+	}
+
+	// No playlist or no user leaves the tracks alone
+	for _, client := range []*fakeSpotifyClient{{user: &spotify.User{ID: "me"}}, {}} {
+		if got := newTestAgent(client).freshTracks(context.Background(), happy, tracks); len(got) != len(tracks) {
+			t.Errorf("freshTracks() = %v, want all tracks kept", trackIDs(got))
+		}
+	}
+}
+
+func TestRecommendSequence(t *testing.T) {
+	happy := fakeTracks("happy", 3)
+	relaxed := fakeTracks("relaxed", 3)
+	energies := map[string]float32{
+		"happy-1": 0.6, "happy-2": 0.9, "happy-3": 0.7,
+		"relaxed-1": 0.2, "relaxed-2": 0.4, "relaxed-3": 0.1,
+	}
+	var features []spotify.AudioFeatures
+	for id, energy := range energies {
+		features = append(features, spotify.AudioFeatures{ID: id, Energy: energy})
+	}
+
+	client := &fakeSpotifyClient{
+		searchResults: map[string][]spotify.Track{
+			"happy upbeat energetic": happy,
+			"relaxing chill ambient": relaxed,
+		},
+		audioFeatures: features,
+	}
+
+	// No recommendations come back, so every track comes from its own mood's search
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer happy and then relaxed 40")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+
+	partOne := strings.Index(response, "Part 1: happy")
+	partTwo := strings.Index(response, "Part 2: relaxed")
+	if !strings.HasPrefix(response, "Here's a journey from happy to relaxed:") || partOne < 0 || partTwo < partOne {
+		t.Fatalf("response missing the sections in order:\n%s", response)
+	}
+
+	// Winding down, each section goes from high to low energy
+	order := []string{"happy-2", "happy-3", "happy-1", "relaxed-2", "relaxed-1", "relaxed-3"}
+	last := -1
+	for _, id := range order {
+		i := strings.Index(response, "Song "+id+" ")
+		if i <= last {
+			t.Errorf("%s is out of order, want %v:\n%s", id, order, response)
+		}
+		last = i
+	}
+	if strings.Index(response, "Song happy-1 ") > partTwo || strings.Index(response, "Song relaxed-2 ") < partTwo {
+		t.Errorf("tracks are in the wrong sections:\n%s", response)
+	}
+}
+
+func TestRecommendSequenceUnknownMood(t *testing.T) {
+	response, err := newTestAgent(&fakeSpotifyClient{}).ProcessTask(context.Background(), "mood_analyzer happy and then the weather")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if want := "I couldn't pick up a mood from 'the weather'."; !strings.HasPrefix(response, want) {
+		t.Errorf("ProcessTask() = %q, want it to name the part without a mood", response)
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     trackSort
+		wantRest []string
+	}{
+		{[]string{"happy", "sort:energy"}, trackSort{feature: "energy"}, []string{"happy"}},
+		{[]string{"sort:tempo-desc", "happy"}, trackSort{feature: "tempo", descending: true}, []string{"happy"}},
+		{[]string{"happy", "sort:energy-asc"}, trackSort{feature: "energy"}, []string{"happy"}},
+		{[]string{"happy", "sort:loudness"}, trackSort{}, []string{"happy", "sort:loudness"}},
+		{[]string{"happy"}, trackSort{}, []string{"happy"}},
+	}
+
+	for _, tt := range tests {
+		got, rest := parseSort(tt.args)
+		if got != tt.want || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("parseSort(%q) = %+v, %q, want %+v, %q", tt.args, got, rest, tt.want, tt.wantRest)
+		}
+	}
+}
+
+func TestSortByFeature(t *testing.T) {
+	tracks := fakeTracks("t", 4)
+	features := []spotify.AudioFeatures{
+		{ID: "t-1", Energy: 0.5, Tempo: 90},
+		{ID: "t-2", Energy: 0.9, Tempo: 170},
+		{ID: "t-3", Energy: 0.5, Tempo: 120},
+	}
+
+	tests := []struct {
+		sortBy trackSort
+		want   []string
+	}{
+		{trackSort{feature: "energy"}, []string{"t-1", "t-3", "t-2", "t-4"}},
+		{trackSort{feature: "energy", descending: true}, []string{"t-2", "t-1", "t-3", "t-4"}},
+		{trackSort{feature: "tempo"}, []string{"t-1", "t-3", "t-2", "t-4"}},
+		{trackSort{feature: "tempo", descending: true}, []string{"t-2", "t-3", "t-1", "t-4"}},
+	}
+
+	for _, tt := range tests {
+		if got := trackIDs(sortByFeature(tracks, features, tt.sortBy)); !slices.Equal(got, tt.want) {
+			t.Errorf("sortByFeature(%+v) = %v, want %v", tt.sortBy, got, tt.want)
+		}
+	}
+	if got := trackIDs(tracks); !slices.Equal(got, []string{"t-1", "t-2", "t-3", "t-4"}) {
+		t.Errorf("sortByFeature() reordered its input to %v", got)
+	}
+}
+
+func TestRecommendMusicSorted(t *testing.T) {
+	tracks := fakeTracks("t", 3)
+	client := &fakeSpotifyClient{
+		searchTracks: tracks,
+		audioFeatures: []spotify.AudioFeatures{
+			{ID: "t-1", Tempo: 120}, {ID: "t-2", Tempo: 80}, {ID: "t-3", Tempo: 160},
+		},
+	}
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy sort:tempo-desc")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	first, second, third := strings.Index(response, "Song t-3 "), strings.Index(response, "Song t-1 "), strings.Index(response, "Song t-2 ")
+	if first < 0 || !(first < second && second < third) {
+		t.Errorf("response isn't ordered by descending tempo:\n%s", response)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	msgs := mood.MessagesFor(mood.DefaultLocale)
+
+	var warn warnings
+	if got := warn.section(msgs); got != "" {
+		t.Errorf("section() with no warnings = %q, want empty", got)
+	}
+
+	warn.add(fmt.Sprintf(englishMessages.WarnFewTracks, 3))
+	if got, want := warn.section(msgs), "\nNote: I could only find 3 songs this time.\n"; got != want {
+		t.Errorf("section() = %q, want %q", got, want)
+	}
+
+	warn.add(fmt.Sprintf(englishMessages.WarnFewTracks, 3))
+	warn.add("I couldn't save these to a playlist this time.")
+	want := "\nNote:\n- I could only find 3 songs this time.\n- I couldn't save these to a playlist this time.\n"
+	if got := warn.section(msgs); got != want {
+		t.Errorf("section() = %q, want %q with the duplicate dropped", got, want)
+	}
+
+	var none *warnings
+	none.add("ignored")
+}
+
+func TestRecommendMusicNotesPartialFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		task   string
+		client *fakeSpotifyClient
+		want   string
+	}{
+		{
+			name:   "recommendations failed",
+			task:   "mood_analyzer I feel happy",
+			client: &fakeSpotifyClient{searchTracks: fakeTracks("search", 5), recommendationsErr: spotify.ErrNotFound},
+			want:   "Spotify's recommendations weren't available, so these songs all come from search.",
+		},
+		{
+			name:   "playlist not created",
+			task:   "mood_analyzer I feel happy",
+			client: &fakeSpotifyClient{user: &spotify.User{ID: "me"}, searchTracks: fakeTracks("search", 5), recommendations: fakeTracks("rec", 15), createErr: spotify.ErrForbidden},
+			want:   "I couldn't save these to a playlist this time.",
+		},
+		{
+			name:   "invalid genre",
+			task:   "mood_analyzer happy grunge",
+			client: &fakeSpotifyClient{searchTracks: fakeTracks("search", 5), recommendations: fakeTracks("rec", 15), genreSeeds: []string{"pop", "rock"}},
+			want:   "Spotify doesn't recommend by grunge, so I could only use it to search.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := newTestAgent(tt.client).ProcessTask(context.Background(), tt.task)
+			if err != nil {
+				t.Fatalf("ProcessTask() error = %v", err)
+			}
+			if !strings.Contains(response, "Note:") || !strings.Contains(response, tt.want) {
+				t.Errorf("response missing the note %q:\n%s", tt.want, response)
+			}
+		})
+	}
+}
+
+func TestRecommendMusicNoNoteWhenAllWent(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if strings.Contains(response, "Note:") {
+		t.Errorf("response has a note without any failure:\n%s", response)
+	}
+}
+
+// noCallsClient is a SpotifyClient that fails the test on any call, since its
+// embedded interface is nil and every method panics
+type noCallsClient struct {
+	SpotifyClient
+}
+
+func TestMoodOnlyMakesNoSpotifyCalls(t *testing.T) {
+	agent := &MoodalystAgent{
+		spotifyClient: noCallsClient{},
+		moodAnalyzer:  mood.NewMoodAnalyzer(),
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	tests := []struct {
+		task string
+		want string
+	}{
+		{"mood_only I feel happy and excited", "Mood: happy (confidence 80%)\nMatched: happy, excited\n"},
+		{"mood_only I feel happy format:minimal", "happy 60%\n"},
+		{"mood_only the weather report", "I couldn't pick up a mood from that."},
+	}
+
+	for _, tt := range tests {
+		response, err := agent.ProcessTask(context.Background(), tt.task)
+		if err != nil {
+			t.Fatalf("ProcessTask(%q) error = %v", tt.task, err)
+		}
+		if !strings.HasPrefix(response, tt.want) {
+			t.Errorf("ProcessTask(%q) = %q, want it to start with %q", tt.task, response, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeBuildsFilteredQuery(t *testing.T) {
+	client := &fakeSpotifyClient{
+		artists:         []spotify.Artist{{ID: "adele-id", Name: "Adele"}},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	if _, _, err := newTestAgent(client).Analyze(context.Background(), "sad jazz from the 90s like adele"); err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if want := `artist:"Adele" year:1990-1999 genre:"jazz"`; len(client.searches) == 0 || client.searches[0] != want {
+		t.Errorf("searches = %q, want the first to be %q", client.searches, want)
+	}
+}
+
+func TestRelaxedSearches(t *testing.T) {
+	filters := spotify.SearchFilters{Year: "1990-1999", Genre: "jazz"}
+	got := relaxedSearches("sad melancholy", filters, "sad")
+	want := []relaxedSearch{
+		{"sad", filters},
+		{"sad", spotify.SearchFilters{}},
+		{"sad", spotify.SearchFilters{AnyMarket: true}},
+		{"sad", spotify.SearchFilters{Genre: "pop", AnyMarket: true}},
+		{"sad", spotify.SearchFilters{Genre: "rock", AnyMarket: true}},
+		{"sad", spotify.SearchFilters{Genre: "indie", AnyMarket: true}},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("relaxedSearches() = %+v, want %+v", got, want)
+	}
+
+	// Steps that wouldn't change anything are skipped
+	got = relaxedSearches("", spotify.SearchFilters{Artist: "Adele"}, "")
+	want = []relaxedSearch{{"", spotify.SearchFilters{Artist: "Adele", AnyMarket: true}}}
+	if !slices.Equal(got, want) {
+		t.Errorf("relaxedSearches() without a mood = %+v, want %+v", got, want)
+	}
+}
+
+func TestFillBySearch(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchResults: map[string][]spotify.Track{
+			"sad":                fakeTracks("sad", 2),
+			"sad year:1990-1999": fakeTracks("sad", 2),
+			`sad genre:"pop"`:    slices.Concat(fakeTracks("sad", 1), fakeTracks("pop", 4)),
+			`sad genre:"rock"`:   fakeTracks("rock", 5),
+		},
+	}
+	searches := []relaxedSearch{
+		{"sad", spotify.SearchFilters{Year: "1990-1999"}},
+		{"sad", spotify.SearchFilters{}},
+		{"sad", spotify.SearchFilters{Genre: "pop"}},
+		{"sad", spotify.SearchFilters{Genre: "rock"}},
+	}
+
+	// The first query is sparse; the looser ones fill the quota without duplicates
+	tracks := newTestAgent(client).fillBySearch(context.Background(), fakeTracks("found", 1), 5, searches)
+	want := []string{"found-1", "sad-1", "sad-2", "pop-1", "pop-2"}
+	if got := trackIDs(tracks); !slices.Equal(got, want) {
+		t.Errorf("fillBySearch() = %v, want %v", got, want)
+	}
+	if len(client.searches) != 3 {
+		t.Errorf("searched %q, want to stop once there were enough tracks", client.searches)
+	}
+}
+
+func TestAnalyzeRelaxesSparseSearch(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchResults: map[string][]spotify.Track{
+			"sad emotional soulful": fakeTracks("narrow", 1),
+			"sad":                   fakeTracks("loose", 10),
+		},
+	}
+	_, tracks, err := newTestAgent(client).Analyze(context.Background(), "I feel sad 6")
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(tracks) != 6 || tracks[0].ID != "narrow-1" {
+		t.Errorf("Analyze() = %v, want the narrow result topped up to 6 by the looser search", trackIDs(tracks))
+	}
+}
+
+func TestBlendHistory(t *testing.T) {
+	client := &fakeSpotifyClient{audioFeatures: []spotify.AudioFeatures{
+		{ID: "top-1", Energy: 0.2, Valence: 0.2},
+		{ID: "top-2", Energy: 0.4, Valence: 0.6},
+	}}
+	topTracks := []spotify.Track{fakeTrack("top-1", "One", "A"), fakeTrack("top-2", "Two", "B")}
+	profile := mood.MoodProfile{Mood: "happy", Energy: 0.8, Valence: 0.9}
+
+	tests := []struct {
+		weight          float32
+		energy, valence float32
+	}{
+		{0.3, 0.65, 0.75},
+		{0.5, 0.55, 0.65},
+	}
+	for _, tt := range tests {
+		agent := newTestAgent(client)
+		agent.historyWeight = tt.weight
+		got := agent.blendHistory(context.Background(), profile, topTracks)
+		if math.Abs(float64(got.Energy-tt.energy)) > 1e-4 || math.Abs(float64(got.Valence-tt.valence)) > 1e-4 {
+			t.Errorf("blendHistory(weight %v) = energy %v valence %v, want %v and %v", tt.weight, got.Energy, got.Valence, tt.energy, tt.valence)
+		}
+	}
+}
+
+func TestBlendHistoryWithoutFeatures(t *testing.T) {
+	agent := newTestAgent(&fakeSpotifyClient{})
+	agent.historyWeight = 0.5
+	profile := mood.MoodProfile{Mood: "happy", Energy: 0.8, Valence: 0.9}
+	if got := agent.blendHistory(context.Background(), profile, []spotify.Track{fakeTrack("top-1", "One", "A")}); got.Energy != profile.Energy || got.Valence != profile.Valence {
+		t.Errorf("blendHistory() = %+v, want the profile unchanged", got)
+	}
+}
+
+// clientCredentialsClient is a fake client without user access that counts
+// calls to the endpoints that need a signed-in user
+type clientCredentialsClient struct {
+	*fakeSpotifyClient
+	userCalls []string
+}
+
+func (c *clientCredentialsClient) GetCurrentUser(ctx context.Context) (*spotify.User, error) {
+	c.userCalls = append(c.userCalls, "GetCurrentUser")
+	return c.fakeSpotifyClient.GetCurrentUser(ctx)
+}
+
+func (c *clientCredentialsClient) GetCurrentlyPlaying(ctx context.Context) (*spotify.Track, error) {
+	c.userCalls = append(c.userCalls, "GetCurrentlyPlaying")
+	return c.fakeSpotifyClient.GetCurrentlyPlaying(ctx)
+}
+
+func (c *clientCredentialsClient) GetTopTracks(ctx context.Context, timeRange string, limit int) ([]spotify.Track, error) {
+	c.userCalls = append(c.userCalls, "GetTopTracks")
+	return c.fakeSpotifyClient.GetTopTracks(ctx, timeRange, limit)
+}
+
+func (c *clientCredentialsClient) StartPlayback(ctx context.Context, deviceID string, trackURIs []string) error {
+	c.userCalls = append(c.userCalls, "StartPlayback")
+	return c.fakeSpotifyClient.StartPlayback(ctx, deviceID, trackURIs)
+}
+
+func (c *clientCredentialsClient) SaveTracks(ctx context.Context, trackIDs []string) error {
+	c.userCalls = append(c.userCalls, "SaveTracks")
+	return c.fakeSpotifyClient.SaveTracks(ctx, trackIDs)
+}
+
+func TestRecommendMusicSkipsUserStepsWithoutUser(t *testing.T) {
+	client := &clientCredentialsClient{fakeSpotifyClient: &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}}
+	agent := newTestAgent(client.fakeSpotifyClient)
+	agent.spotifyClient = client
+
+	response, err := agent.ProcessTask(context.Background(), "mood_analyzer I feel happy save:playlist save:liked play:now")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.userCalls) != 0 {
+		t.Errorf("called %v without a signed-in user, want no user-only calls", client.userCalls)
+	}
+	for _, want := range []string{"To save these to your Liked Songs, sign in", "To play these on Spotify, sign in"} {
+		if !strings.Contains(response, want) {
+			t.Errorf("response doesn't contain %q:\n%s", want, response)
+		}
+	}
+	if strings.Contains(response, "couldn't save these to a playlist") {
+		t.Errorf("response notes a failed playlist without user access:\n%s", response)
+	}
+}
+
+func TestRecommendMusicUsesUserStepsWithUser(t *testing.T) {
+	client := &clientCredentialsClient{fakeSpotifyClient: &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}}
+	agent := newTestAgent(client.fakeSpotifyClient)
+	agent.spotifyClient = client
+
+	if _, err := agent.ProcessTask(context.Background(), "mood_analyzer I feel happy save:playlist"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	for _, want := range []string{"GetCurrentlyPlaying", "GetTopTracks", "GetCurrentUser"} {
+		if !slices.Contains(client.userCalls, want) {
+			t.Errorf("user calls = %v, want %s with a signed-in user", client.userCalls, want)
+		}
+	}
+}
+
+func TestParseTrackLinks(t *testing.T) {
+	const id1, id2 = "4uLU6hMCjMI75M1A2tKUQC", "7GhIk7Il098yCjg4BQjzvb"
+	tests := []struct {
+		args     []string
+		wantIDs  []string
+		wantRest []string
+	}{
+		{[]string{"more", "like", "https://open.spotify.com/track/" + id1 + "?si=x"}, []string{id1}, []string{"more", "like"}},
+		{[]string{"(spotify:track:" + id1 + "),", "and", "spotify:track:" + id2 + "!"}, []string{id1, id2}, []string{"and"}},
+		{[]string{"spotify:track:" + id1, "spotify:track:" + id1}, []string{id1}, nil},
+		{[]string{"https://open.spotify.com/album/" + id1, "sad"}, nil, []string{"https://open.spotify.com/album/" + id1, "sad"}},
+		{[]string{"spotify:track:broken", "happy"}, nil, []string{"spotify:track:broken", "happy"}},
+	}
+	for _, tt := range tests {
+		ids, rest := parseTrackLinks(tt.args)
+		if !slices.Equal(ids, tt.wantIDs) || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("parseTrackLinks(%q) = %q, %q, want %q, %q", tt.args, ids, rest, tt.wantIDs, tt.wantRest)
+		}
+	}
+}
+
+func TestRecommendMusicSeedsFromPastedLink(t *testing.T) {
+	const id = "4uLU6hMCjMI75M1A2tKUQC"
+	client := &fakeSpotifyClient{
+		tracks:          []spotify.Track{fakeTrack(id, "Never Gonna Give You Up", "Rick Astley")},
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer more like this https://open.spotify.com/track/"+id+"?si=abc")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.searches) != 0 {
+		t.Errorf("searched %q, want only recommendations for a pasted track", client.searches)
+	}
+	if len(client.recommendSeeds) == 0 || !slices.Contains(client.recommendSeeds[0], id) {
+		t.Errorf("recommendation seeds = %v, want the pasted track %s", client.recommendSeeds, id)
+	}
+	if !strings.Contains(response, "Song rec-1") {
+		t.Errorf("response doesn't list the recommendations:\n%s", response)
+	}
+}
+
+func TestRecommendMusicDropsUnplayableTracks(t *testing.T) {
+	search := fakeTracks("search", 5)
+	search[1].AvailableMarkets = []string{"US", "CA"}
+	search[2].AvailableMarkets = []string{"DE", "GB"}
+	client := &fakeSpotifyClient{
+		market:          "DE",
+		searchTracks:    search,
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if strings.Contains(response, "Song search-2") {
+		t.Errorf("response recommends a track not available in DE:\n%s", response)
+	}
+	for _, want := range []string{"Song search-1", "Song search-3"} {
+		if !strings.Contains(response, want) {
+			t.Errorf("response doesn't recommend %s, which is playable:\n%s", want, response)
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		args      []string
+		wantQuery string
+		wantRest  []string
+	}{
+		{[]string{"happy", "query:summer", "roadtrip"}, "summer roadtrip", []string{"happy"}},
+		{[]string{"happy", "query:summer", "roadtrip", "30"}, "summer roadtrip", []string{"happy", "30"}},
+		{[]string{"query:indie", "format:minimal", "sad"}, "indie", []string{"format:minimal", "sad"}},
+		{[]string{"query:", "summer"}, "summer", []string{}},
+		{[]string{"happy", "songs"}, "", []string{"happy", "songs"}},
+	}
+	for _, tt := range tests {
+		query, rest := parseQuery(tt.args)
+		if query != tt.wantQuery || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("parseQuery(%q) = %q, %q, want %q, %q", tt.args, query, rest, tt.wantQuery, tt.wantRest)
+		}
+	}
+}
+
+func TestQueryOverridesDerivedTerms(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	profile, _, err := newTestAgent(client).Analyze(context.Background(), "happy query:summer roadtrip")
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if profile.Mood != "happy" {
+		t.Errorf("Analyze() mood = %q, want happy", profile.Mood)
+	}
+	if len(client.searches) == 0 || client.searches[0] != "summer roadtrip" {
+		t.Errorf("searches = %q, want summer roadtrip first", client.searches)
+	}
+}
+
+func TestVerboseShowsSearchQuery(t *testing.T) {
+	tests := []struct {
+		task string
+		want string
+	}{
+		{"mood_analyzer happy verbose:on", "Search query: " + spotify.BuildSearchQuery(mood.NewMoodAnalyzer().AnalyzeMood("happy").SearchQueryTerms, spotify.SearchFilters{})},
+		{"mood_analyzer happy query:summer roadtrip verbose:on", "Search query: summer roadtrip"},
+	}
+	for _, tt := range tests {
+		client := &fakeSpotifyClient{
+			searchTracks:    fakeTracks("search", 5),
+			recommendations: fakeTracks("rec", 15),
+		}
+		response, err := newTestAgent(client).ProcessTask(context.Background(), tt.task)
+		if err != nil {
+			t.Fatalf("ProcessTask(%q) error = %v", tt.task, err)
+		}
+		if !strings.Contains(response, tt.want+"\n") {
+			t.Errorf("ProcessTask(%q) doesn't show %q:\n%s", tt.task, tt.want, response)
+		}
+	}
+
+	client := &fakeSpotifyClient{searchTracks: fakeTracks("search", 5), recommendations: fakeTracks("rec", 15)}
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer happy query:summer roadtrip")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if strings.Contains(response, "Search query") {
+		t.Errorf("response shows the search query without verbose:on:\n%s", response)
+	}
+}
+
+func TestVerboseJSONIncludesSearchQuery(t *testing.T) {
+	for _, verbose := range []bool{true, false} {
+		task := "mood_analyzer happy query:summer roadtrip format:json"
+		if verbose {
+			task += " verbose:on"
+		}
+		client := &fakeSpotifyClient{searchTracks: fakeTracks("search", 5), recommendations: fakeTracks("rec", 15)}
+		response, err := newTestAgent(client).ProcessTask(context.Background(), task)
+		if err != nil {
+			t.Fatalf("ProcessTask(%q) error = %v", task, err)
+		}
+		var payload mood.RecommendationsPayload
+		if err := json.Unmarshal([]byte(response), &payload); err != nil {
+			t.Fatalf("ProcessTask(%q) isn't JSON: %v", task, err)
+		}
+		want := ""
+		if verbose {
+			want = "summer roadtrip"
+		}
+		if payload.SearchQuery != want {
+			t.Errorf("ProcessTask(%q) search_query = %q, want %q", task, payload.SearchQuery, want)
+		}
+	}
+}
+
+func TestGenreRadio(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		genreSeeds:      []string{"blues", "jazz", "hip-hop"},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "genre_radio hip hop")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	wantQuery := spotify.BuildSearchQuery("", spotify.SearchFilters{Genre: "hip-hop"})
+	if len(client.searches) == 0 || client.searches[0] != wantQuery {
+		t.Errorf("searches = %q, want %q first", client.searches, wantQuery)
+	}
+	if len(client.recommendSeeds) != 1 || !slices.Contains(client.recommendSeeds[0], "hip-hop") {
+		t.Errorf("recommendation seeds = %v, want the genre", client.recommendSeeds)
+	}
+	if client.recommendParams[0] != nil {
+		t.Errorf("recommendation params = %v, want no mood targets", client.recommendParams[0])
+	}
+	if len(client.playlists) != 1 || client.playlists[0].Name != "Mood Analyst: Hip-hop Vibes" {
+		t.Errorf("playlists = %v, want one named for the genre", client.playlists)
+	}
+	for _, want := range []string{"Hip-hop radio", "Song search-1", "Song rec-1", "https://open.spotify.com/playlist/created-1"} {
+		if !strings.Contains(response, want) {
+			t.Errorf("response doesn't contain %q:\n%s", want, response)
+		}
+	}
+}
+
+func TestGenreRadioUnknownGenre(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:       &spotify.User{ID: "me"},
+		genreSeeds: []string{"jazz", "acid-jazz", "blues"},
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "genre_radio jaz")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	want := "Spotify doesn't know the genre 'jaz'. Did you mean jazz, acid-jazz?"
+	if response != want {
+		t.Errorf("ProcessTask() = %q, want %q", response, want)
+	}
+	if len(client.searches) != 0 || len(client.recommendSeeds) != 0 || len(client.createdOptions) != 0 {
+		t.Errorf("looked for or saved tracks for an unknown genre: searches %q, recommendations %v", client.searches, client.recommendSeeds)
+	}
+
+	response, err = newTestAgent(client).ProcessTask(context.Background(), "genre_radio polka")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if want := "Spotify doesn't know the genre 'polka'."; response != want {
+		t.Errorf("ProcessTask() = %q, want %q without suggestions", response, want)
+	}
+}
+
+func TestGenreRadioUsage(t *testing.T) {
+	response, err := newTestAgent(&fakeSpotifyClient{}).ProcessTask(context.Background(), "genre_radio")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if !strings.Contains(response, "genre_radio jazz") {
+		t.Errorf("ProcessTask() = %q, want an example", response)
+	}
+}
+
+func TestSimilarGenres(t *testing.T) {
+	available := []string{"jazz", "acid-jazz", "blues", "rock", "hard-rock", "rock-n-roll", "punk-rock"}
+	tests := []struct {
+		genre string
+		want  []string
+	}{
+		{"jaz", []string{"jazz", "acid-jazz"}},
+		{"rock", []string{"rock", "hard-rock", "rock-n-roll"}},
+		{"polka", nil},
+	}
+	for _, tt := range tests {
+		if got := similarGenres(tt.genre, available); !slices.Equal(got, tt.want) {
+			t.Errorf("similarGenres(%q) = %v, want %v", tt.genre, got, tt.want)
+		}
+	}
+}
+
+// tracksOfLength returns tracks lasting the given lengths, in order
+func tracksOfLength(lengths ...time.Duration) []spotify.Track {
+	tracks := fakeTracks("len", len(lengths))
+	for i, length := range lengths {
+		tracks[i].DurationMs = int(length / time.Millisecond)
+	}
+	return tracks
+}
+
+func TestFitDuration(t *testing.T) {
+	const minute = time.Minute
+	tests := []struct {
+		name      string
+		lengths   []time.Duration
+		target    time.Duration
+		wantIDs   []string
+		wantTotal time.Duration
+	}{
+		{"stops once near the target", []time.Duration{4 * minute, 4 * minute, 4 * minute, 4 * minute, 4 * minute, 4 * minute, 4 * minute, 4 * minute}, 30 * minute,
+			[]string{"len-1", "len-2", "len-3", "len-4", "len-5", "len-6", "len-7"}, 28 * minute},
+		{"skips tracks running past it", []time.Duration{10 * minute, 13 * minute, 9 * minute, 3 * minute}, 20 * minute,
+			[]string{"len-1", "len-3"}, 19 * minute},
+		{"skips unknown lengths", []time.Duration{0, 5 * minute, 5 * minute}, 10 * minute,
+			[]string{"len-2", "len-3"}, 10 * minute},
+		{"short target", []time.Duration{3 * minute, 2 * minute}, time.Minute,
+			[]string{"len-1"}, 3 * minute},
+		{"target of the tolerance", []time.Duration{90 * time.Second, 90 * time.Second}, durationTolerance,
+			[]string{"len-1"}, 90 * time.Second},
+		{"not enough music", []time.Duration{5 * minute, 5 * minute}, 60 * minute,
+			[]string{"len-1", "len-2"}, 10 * minute},
+		{"nothing fits", []time.Duration{10 * minute}, 3 * minute, nil, 0},
+	}
+	for _, tt := range tests {
+		picked, total := fitDuration(tracksOfLength(tt.lengths...), tt.target, durationTolerance)
+		if got := trackIDs(picked); !slices.Equal(got, tt.wantIDs) || total != tt.wantTotal {
+			t.Errorf("%s: fitDuration() = %v, %v, want %v, %v", tt.name, got, total, tt.wantIDs, tt.wantTotal)
+		}
+		if len(picked) > 0 && (total > tt.target+durationTolerance) {
+			t.Errorf("%s: fitDuration() total %v runs past %v", tt.name, total, tt.target+durationTolerance)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		args     []string
+		want     time.Duration
+		wantRest []string
+	}{
+		{strings.Fields("happy 30 minutes"), 30 * time.Minute, []string{"happy"}},
+		{strings.Fields("a 45-min workout mix"), 45 * time.Minute, strings.Fields("a workout mix")},
+		{strings.Fields("chill for an hour"), time.Hour, strings.Fields("chill for")},
+		{strings.Fields("1.5 hours of focus"), 90 * time.Minute, strings.Fields("of focus")},
+		{strings.Fields("sad 20"), 0, strings.Fields("sad 20")},
+		{strings.Fields("0 minutes"), 0, strings.Fields("0 minutes")},
+		{strings.Fields("make me an hour of calm"), time.Hour, strings.Fields("make me of calm")},
+		{strings.Fields("give me a minute to relax"), 0, strings.Fields("give me a minute to relax")},
+		{strings.Fields("an hour ago I was happy"), 0, strings.Fields("an hour ago I was happy")},
+		{strings.Fields("happy for a few minutes"), 0, strings.Fields("happy for a few minutes")},
+	}
+	for _, tt := range tests {
+		got, rest := parseDuration(tt.args)
+		if got != tt.want || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("parseDuration(%q) = %v, %q, want %v, %q", tt.args, got, rest, tt.want, tt.wantRest)
+		}
+	}
+}
+
+func TestTracksForDuration(t *testing.T) {
+	tests := []struct {
+		duration time.Duration
+		want     int
+	}{
+		{time.Minute, 1},
+		{30 * time.Minute, 13},
+		{time.Hour, 27},
+		{24 * time.Hour, maxTrackCount},
+	}
+	for _, tt := range tests {
+		if got := tracksForDuration(tt.duration); got != tt.want {
+			t.Errorf("tracksForDuration(%v) = %d, want %d", tt.duration, got, tt.want)
+		}
+	}
+}
+
+func TestFillDurationLooksUpLengths(t *testing.T) {
+	tracks := tracksOfLength(0, 0, 0, 0)
+	full := tracksOfLength(5*time.Minute, 5*time.Minute, 5*time.Minute, 5*time.Minute)
+	client := &fakeSpotifyClient{tracks: full}
+
+	var warn warnings
+	picked, total := newTestAgent(client).fillDuration(context.Background(), tracks, 15*time.Minute, englishMessages, &warn)
+	if len(picked) != 3 || total != 15*time.Minute {
+		t.Errorf("fillDuration() = %d tracks lasting %v, want 3 lasting 15m", len(picked), total)
+	}
+	if len(warn) != 0 {
+		t.Errorf("warnings = %q, want none", warn)
+	}
+}
+
+func TestFillDurationUnknownLengths(t *testing.T) {
+	tracks := tracksOfLength(0, 0)
+
+	var warn warnings
+	picked, total := newTestAgent(&fakeSpotifyClient{}).fillDuration(context.Background(), tracks, 30*time.Minute, englishMessages, &warn)
+	if len(picked) != 2 || total != 0 {
+		t.Errorf("fillDuration() = %d tracks lasting %v, want both kept with no length", len(picked), total)
+	}
+	if len(warn) != 1 || !strings.Contains(warn[0], "couldn't tell how long") {
+		t.Errorf("warnings = %q, want one about the unknown lengths", warn)
+	}
+}
+
+func TestRecommendMusicForDuration(t *testing.T) {
+	search := fakeTracks("search", 5)
+	recs := fakeTracks("rec", 15)
+	for i := range search {
+		search[i].DurationMs = 4 * 60 * 1000
+	}
+	for i := range recs {
+		recs[i].DurationMs = 4 * 60 * 1000
+	}
+	client := &fakeSpotifyClient{searchTracks: search, recommendations: recs}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer happy 30 minutes")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if strings.Contains(response, "8. ") || !strings.Contains(response, "7. ") {
+		t.Errorf("response doesn't list the 7 tracks that make up 28 minutes:\n%s", response)
+	}
+	if !strings.Contains(response, "28 minutes") {
+		t.Errorf("response doesn't give the total length:\n%s", response)
+	}
+}
+
+func TestUnsavedTracks(t *testing.T) {
+	tracks := fakeTracks("t", 4)
+	tests := []struct {
+		name     string
+		client   *fakeSpotifyClient
+		wantIDs  []string
+		wantWarn string
+	}{
+		{"drops saved", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, savedTrackIDs: map[string]bool{"t-2": true, "t-4": true}},
+			[]string{"t-1", "t-3"}, ""},
+		{"all saved", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, savedTrackIDs: map[string]bool{"t-1": true, "t-2": true, "t-3": true, "t-4": true}},
+			[]string{"t-1", "t-2", "t-3", "t-4"}, "already saved all"},
+		{"check failed", &fakeSpotifyClient{user: &spotify.User{ID: "me"}, savedCheckErr: spotify.ErrForbidden},
+			[]string{"t-1", "t-2", "t-3", "t-4"}, "couldn't check"},
+		{"no user", &fakeSpotifyClient{savedTrackIDs: map[string]bool{"t-1": true}},
+			[]string{"t-1", "t-2", "t-3", "t-4"}, "Sign in"},
+	}
+	for _, tt := range tests {
+		var warn warnings
+		got := newTestAgent(tt.client).unsavedTracks(context.Background(), tracks, englishMessages, &warn)
+		if ids := trackIDs(got); !slices.Equal(ids, tt.wantIDs) {
+			t.Errorf("%s: unsavedTracks() = %v, want %v", tt.name, ids, tt.wantIDs)
+		}
+		if tt.wantWarn == "" && len(warn) != 0 {
+			t.Errorf("%s: warnings = %q, want none", tt.name, warn)
+		}
+		if tt.wantWarn != "" && (len(warn) != 1 || !strings.Contains(warn[0], tt.wantWarn)) {
+			t.Errorf("%s: warnings = %q, want one mentioning %q", tt.name, warn, tt.wantWarn)
+		}
+	}
+	if ids := trackIDs(tracks); !slices.Equal(ids, []string{"t-1", "t-2", "t-3", "t-4"}) {
+		t.Errorf("unsavedTracks() changed its input to %v", ids)
+	}
+}
+
+func TestRecommendMusicExcludesSaved(t *testing.T) {
+	for _, exclude := range []bool{true, false} {
+		client := &fakeSpotifyClient{
+			user:            &spotify.User{ID: "me"},
+			searchTracks:    fakeTracks("search", 5),
+			recommendations: fakeTracks("rec", 15),
+			savedTrackIDs:   map[string]bool{"search-1": true, "rec-3": true},
+		}
+		task := "mood_analyzer I feel happy"
+		if exclude {
+			task += " exclude:saved"
+		}
+
+		response, err := newTestAgent(client).ProcessTask(context.Background(), task)
+		if err != nil {
+			t.Fatalf("ProcessTask(%q) error = %v", task, err)
+		}
+		for _, saved := range []string{"Song search-1 ", "Song rec-3 "} {
+			if listed := strings.Contains(response, saved); listed == exclude {
+				t.Errorf("ProcessTask(%q) listing %q = %v, want %v", task, saved, listed, !exclude)
+			}
+		}
+	}
+}
+
+func TestMoreLikeReseedsFromLastList(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+	agent := newTestAgent(client)
+	ctx := withSession(context.Background(), "room-1")
+
+	if _, err := agent.ProcessTask(ctx, "mood_analyzer I feel happy"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	response, err := agent.ProcessTask(ctx, "mood_analyzer more like 3")
+	if err != nil {
+		t.Fatalf("ProcessTask(more like 3) error = %v", err)
+	}
+
+	lastSeeds := client.recommendSeeds[len(client.recommendSeeds)-1]
+	if lastSeeds[0] != "search-3" {
+		t.Errorf("recommendation seeds = %v, want the third track first", lastSeeds)
+	}
+	if !strings.HasPrefix(response, "🔁 More like Song search-3 by Artist search-3.") {
+		t.Errorf("response doesn't say which track it's like:\n%s", response)
+	}
+	last, _ := agent.lastResult(ctx)
+	if last.moodDescription != "i feel happy" {
+		t.Errorf("remembered mood description = %q, want it kept for the next request", last.moodDescription)
+	}
+}
+
+func TestMoreLikeKeepsSessionsApart(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+	agent := newTestAgent(client)
+
+	if _, err := agent.ProcessTask(withSession(context.Background(), "room-1"), "mood_analyzer I feel happy"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	response, err := agent.ProcessTask(withSession(context.Background(), "room-2"), "mood_analyzer more like 1")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if !strings.Contains(response, "don't have an earlier list") {
+		t.Errorf("another conversation got %q, want no earlier list", response)
+	}
+
+	response, err = agent.ProcessTask(withSession(context.Background(), "room-1"), "mood_analyzer more like 21")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if want := fmt.Sprintf("Pick a song number between 1 and %d from the last list.", defaultTrackCount); response != want {
+		t.Errorf("ProcessTask(more like 21) = %q, want %q", response, want)
+	}
+}
+
+func TestParseMoreLike(t *testing.T) {
+	tests := []struct {
+		args     string
+		wantN    int
+		wantRest []string
+		wantOK   bool
+	}{
+		{"more like 3", 3, nil, true},
+		{"more like track #2 format:minimal", 2, []string{"format:minimal"}, true},
+		{"more like song 4", 4, nil, true},
+		{"more like adele", 0, strings.Fields("more like adele"), false},
+		{"more like", 0, strings.Fields("more like"), false},
+		{"happy songs", 0, strings.Fields("happy songs"), false},
+	}
+	for _, tt := range tests {
+		n, rest, ok := parseMoreLike(strings.Fields(tt.args))
+		if n != tt.wantN || ok != tt.wantOK || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("parseMoreLike(%q) = %d, %q, %v, want %d, %q, %v", tt.args, n, rest, ok, tt.wantN, tt.wantRest, tt.wantOK)
+		}
+	}
+}
+
+func TestRememberForgetsLeastRecentlyUsed(t *testing.T) {
+	agent := newTestAgent(&fakeSpotifyClient{})
+	tracks := fakeTracks("t", 1)
+
+	// The shared session without an ID is the oldest
+	agent.remember(context.Background(), "happy", tracks)
+	for i := 1; i < maxSessions; i++ {
+		agent.remember(withSession(context.Background(), fmt.Sprintf("room-%d", i)), "happy", tracks)
+	}
+	agent.sessions[""].lastUsed = time.Now().Add(-time.Hour)
+	agent.sessions["room-1"].lastUsed = time.Now().Add(-time.Minute)
+
+	agent.remember(withSession(context.Background(), "room-new"), "sad", tracks)
+	if len(agent.sessions) != maxSessions {
+		t.Errorf("remembering %d sessions, want at most %d", len(agent.sessions), maxSessions)
+	}
+	if _, ok := agent.lastResult(context.Background()); ok {
+		t.Error("the least recently used session is still remembered")
+	}
+	for _, id := range []string{"room-1", "room-new"} {
+		if _, ok := agent.lastResult(withSession(context.Background(), id)); !ok {
+			t.Errorf("session %s was forgotten", id)
+		}
+	}
+}
+
+func TestTrackSplitValidate(t *testing.T) {
+	tests := []struct {
+		split   trackSplit
+		wantErr bool
+	}{
+		{defaultTrackSplit, false},
+		{trackSplit{search: 1, recs: 0}, false},
+		{trackSplit{search: spotify.MaxSearchLimit, recs: spotify.MaxRecommendationsLimit}, false},
+		{trackSplit{search: 0, recs: 20}, true},
+		{trackSplit{search: spotify.MaxSearchLimit + 1, recs: 5}, true},
+		{trackSplit{search: 5, recs: -1}, true},
+		{trackSplit{search: 5, recs: spotify.MaxRecommendationsLimit + 1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.split.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.validate() error = %v, want error %v", tt.split, err, tt.wantErr)
+		}
+	}
+}
+
+func TestTrackSplitDrivesRequests(t *testing.T) {
+	tests := []struct {
+		split      trackSplit
+		wantSearch int
+		wantRecs   int
+	}{
+		{trackSplit{}, 5, 15},
+		{trackSplit{search: 10, recs: 10}, 10, 10},
+		{trackSplit{search: 3, recs: 1}, 15, 5},
+	}
+	for _, tt := range tests {
+		client := &fakeSpotifyClient{
+			searchTracks:    fakeTracks("search", 20),
+			recommendations: fakeTracks("rec", 20),
+		}
+		agent := newTestAgent(client)
+		agent.trackSplit = tt.split
+
+		_, tracks, err := agent.Analyze(context.Background(), "I feel happy")
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		if len(client.searchLimits) != 1 || client.searchLimits[0] != tt.wantSearch {
+			t.Errorf("split %+v: search limits = %v, want one search for %d", tt.split, client.searchLimits, tt.wantSearch)
+		}
+		if len(client.recommendLimits) != 1 || client.recommendLimits[0] != tt.wantRecs {
+			t.Errorf("split %+v: recommendation limits = %v, want one request for %d", tt.split, client.recommendLimits, tt.wantRecs)
+		}
+		if len(tracks) != defaultTrackCount {
+			t.Errorf("split %+v: Analyze() = %d tracks, want %d", tt.split, len(tracks), defaultTrackCount)
+		}
+	}
+}
+
+func TestRecentTracksWindow(t *testing.T) {
+	recent, err := loadRecentTracks("", 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("loadRecentTracks() error = %v", err)
+	}
+	tracks := fakeTracks("t", 3)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := recent.add("room-1", tracks[:2], start); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		session string
+		now     time.Time
+		want    []string
+	}{
+		{"within the window", "room-1", start.Add(6 * 24 * time.Hour), []string{"t-3"}},
+		{"after the window", "room-1", start.Add(7 * 24 * time.Hour), []string{"t-1", "t-2", "t-3"}},
+		{"another conversation", "room-2", start.Add(time.Hour), []string{"t-1", "t-2", "t-3"}},
+	}
+	for _, tt := range tests {
+		if got := trackIDs(recent.filter(tt.session, tracks, tt.now)); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: filter() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// When every track was recommended recently they're kept rather than leaving nothing
+	if got := trackIDs(recent.filter("room-1", tracks[:2], start.Add(time.Hour))); !slices.Equal(got, []string{"t-1", "t-2"}) {
+		t.Errorf("filter() of only recent tracks = %v, want them all kept", got)
+	}
+
+	var none *recentTracks
+	if got := trackIDs(none.filter("room-1", tracks, start)); !slices.Equal(got, []string{"t-1", "t-2", "t-3"}) {
+		t.Errorf("nil filter() = %v, want all tracks", got)
+	}
+	if err := none.add("room-1", tracks, start); err != nil {
+		t.Errorf("nil add() error = %v", err)
+	}
+}
+
+func TestRecentTracksForgetsExpired(t *testing.T) {
+	recent, _ := loadRecentTracks("", time.Hour)
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := recent.add("room-1", fakeTracks("old", 2), start); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	if err := recent.add("room-2", fakeTracks("new", 1), start.Add(2*time.Hour)); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+	if _, ok := recent.history["room-1"]; ok || len(recent.history) != 1 {
+		t.Errorf("history = %v, want only room-2 after room-1's tracks expired", recent.history)
+	}
+}
+
+func TestRecentTracksPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "recent.json")
+	recent, err := loadRecentTracks(path, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("loadRecentTracks() of a missing file error = %v", err)
+	}
+	now := time.Now()
+	if err := recent.add("room-1", fakeTracks("t", 2), now); err != nil {
+		t.Fatalf("add() error = %v", err)
+	}
+
+	reloaded, err := loadRecentTracks(path, 7*24*time.Hour)
+	if err != nil {
+		t.Fatalf("loadRecentTracks() error = %v", err)
+	}
+	if got := trackIDs(reloaded.filter("room-1", fakeTracks("t", 3), now.Add(time.Minute))); !slices.Equal(got, []string{"t-3"}) {
+		t.Errorf("filter() after reloading = %v, want the saved tracks left out", got)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("history file: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("history file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	if err := os.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadRecentTracks(path, time.Hour); err == nil {
+		t.Error("loadRecentTracks() of a corrupt file error = nil, want one")
+	}
+}
+
+func TestRecommendMusicLeavesOutRecentTracks(t *testing.T) {
+	client := &fakeSpotifyClient{
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+	agent := newTestAgent(client)
+	agent.recent, _ = loadRecentTracks("", 7*24*time.Hour)
+	ctx := withSession(context.Background(), "room-1")
+
+	if _, err := agent.ProcessTask(ctx, "mood_analyzer I feel happy 5"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	first, _ := agent.lastResult(ctx)
+	if _, err := agent.ProcessTask(ctx, "mood_analyzer I feel happy 20"); err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	second, _ := agent.lastResult(ctx)
+
+	for _, track := range first.tracks {
+		if slices.ContainsFunc(second.tracks, func(t spotify.Track) bool { return t.ID == track.ID }) {
+			t.Errorf("recommended %s again within the window", track.ID)
+		}
+	}
+	if len(second.tracks) == 0 {
+		t.Error("second request recommended nothing")
+	}
+}
+
+func TestRecommendationCount(t *testing.T) {
+	tests := []struct {
+		searchCount, recsCount, found int
+		want                          int
+	}{
+		{5, 15, 5, 15},
+		{5, 15, 2, 18},
+		{5, 15, 0, 20},
+		{5, 15, 8, 15},
+		{25, 75, 0, spotify.MaxRecommendationsLimit},
+	}
+	for _, tt := range tests {
+		if got := recommendationCount(tt.searchCount, tt.recsCount, tt.found); got != tt.want {
+			t.Errorf("recommendationCount(%d, %d, %d) = %d, want %d", tt.searchCount, tt.recsCount, tt.found, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeShortSearchResults(t *testing.T) {
+	tests := []struct {
+		name      string
+		search    []spotify.Track
+		wantSeeds []string
+		wantLimit int
+	}{
+		{"one result", fakeTracks("search", 1), []string{"search-1"}, 19},
+		{"partial results", fakeTracks("search", 3), []string{"search-1", "search-2", "search-3"}, 17},
+		{"result without an ID", []spotify.Track{fakeTrack("", "Local file", "Someone"), fakeTrack("search-2", "Song", "Artist")}, []string{"search-2"}, 18},
+	}
+	for _, tt := range tests {
+		client := &fakeSpotifyClient{searchTracks: tt.search, recommendations: fakeTracks("rec", 20)}
+
+		_, tracks, err := newTestAgent(client).Analyze(context.Background(), "I feel happy")
+		if err != nil {
+			t.Fatalf("%s: Analyze() error = %v", tt.name, err)
+		}
+		if len(client.recommendSeeds) != 1 {
+			t.Fatalf("%s: made %d recommendation requests, want 1", tt.name, len(client.recommendSeeds))
+		}
+		seeds := client.recommendSeeds[0]
+		if !slices.Equal(seeds[:len(tt.wantSeeds)], tt.wantSeeds) || slices.Contains(seeds, "") {
+			t.Errorf("%s: seeds = %q, want the found tracks %q first and no empty seeds", tt.name, seeds, tt.wantSeeds)
+		}
+		if client.recommendLimits[0] != tt.wantLimit {
+			t.Errorf("%s: asked for %d recommendations, want %d to make up the shortfall", tt.name, client.recommendLimits[0], tt.wantLimit)
+		}
+		if len(tracks) == 0 {
+			t.Errorf("%s: Analyze() found no tracks", tt.name)
+		}
+	}
+}
+
+func TestAnalyzeNoSearchResults(t *testing.T) {
+	client := &fakeSpotifyClient{recommendations: fakeTracks("rec", 20)}
+
+	_, tracks, err := newTestAgent(client).Analyze(context.Background(), "I feel happy")
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(tracks) != 0 {
+		t.Errorf("Analyze() = %d tracks, want none", len(tracks))
+	}
+	if len(client.recommendSeeds) != 0 {
+		t.Errorf("made recommendation requests with seeds %v, want none without search results", client.recommendSeeds)
+	}
+}
+
+func TestRecommendMusicCollaborativePlaylist(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy save:collab")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.createdOptions) != 1 || !client.createdOptions[0].Collaborative || client.createdOptions[0].Public {
+		t.Errorf("created playlists with %+v, want one private collaborative playlist", client.createdOptions)
+	}
+	if strings.Contains(response, "still just yours") {
+		t.Errorf("response says the new playlist isn't collaborative:\n%s", response)
+	}
+}
+
+func TestRecommendMusicCollaborativeReusesPlaylist(t *testing.T) {
+	existing := testPlaylist("p1", "Mood Analyst: Happy Vibes", "me")
+	client := &fakeSpotifyClient{
+		user:            &spotify.User{ID: "me"},
+		playlists:       []spotify.Playlist{existing},
+		searchTracks:    fakeTracks("search", 5),
+		recommendations: fakeTracks("rec", 15),
+	}
+
+	response, err := newTestAgent(client).ProcessTask(context.Background(), "mood_analyzer I feel happy save:collab")
+	if err != nil {
+		t.Fatalf("ProcessTask() error = %v", err)
+	}
+	if len(client.createdOptions) != 0 {
+		t.Errorf("created %d playlists, want the existing one reused", len(client.createdOptions))
+	}
+	if !strings.Contains(response, "still just yours to edit") {
+		t.Errorf("response doesn't say the reused playlist isn't collaborative:\n%s", response)
+	}
+}
+
+func TestRecommendMusicLocalized(t *testing.T) {
+	tests := []struct {
+		name        string
+		agentLocale mood.Locale
+		task        string
+		want        mood.Locale
+	}{
+		{"default", "", "mood_analyzer I feel sad play:now", mood.LocaleEnglish},
+		{"asked for", "", "mood_analyzer I feel sad play:now lang:es", mood.LocaleSpanish},
+		{"agent's", mood.LocaleFrench, "mood_analyzer I feel sad play:now", mood.LocaleFrench},
+		{"asked over agent's", mood.LocaleFrench, "mood_analyzer I feel sad play:now lang:es", mood.LocaleSpanish},
+	}
+
+	profile := mood.NewMoodAnalyzer().AnalyzeMood("I feel sad")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Without recommendations or a signed-in user the reply has a warning and a playback note
+			client := &fakeSpotifyClient{searchTracks: fakeTracks("search", 5), recommendationsErr: spotify.ErrNotFound}
+			agent := newTestAgent(client)
+			agent.locale = tt.agentLocale
+			response, err := agent.ProcessTask(context.Background(), tt.task)
+			if err != nil {
+				t.Fatalf("ProcessTask(%q) error = %v", tt.task, err)
+			}
+
+			msgs := mood.MessagesFor(tt.want)
+			for _, want := range []string{
+				mood.FormatLocalizedIntensitySummary(profile, tt.want),
+				fmt.Sprintf(msgs.RecommendationsHeader, profile.Mood),
+				fmt.Sprintf("🎵 Song search-1 %s Artist search-1", msgs.By),
+				msgs.PlaybackSignIn,
+				msgs.Note + " " + msgs.WarnSearchOnly,
+			} {
+				if !strings.Contains(response, want) {
+					t.Errorf("ProcessTask(%q) missing %q:\n%s", tt.task, want, response)
+				}
+			}
+			if tt.want != mood.LocaleEnglish && strings.Contains(response, englishMessages.Note) {
+				t.Errorf("ProcessTask(%q) has English notes:\n%s", tt.task, response)
+			}
+		})
+	}
+}
+
+func TestProcessTaskLocalizedReplies(t *testing.T) {
+	tests := []struct {
+		task        string
+		agentLocale mood.Locale
+		want        string
+	}{
+		{"", "", "No command provided. Available commands: " + availableCommands},
+		{"", mood.LocaleFrench, "Aucune commande indiquée. Commandes disponibles : " + availableCommands},
+		{"dance lang:es", "", "No conozco el comando 'dance'. Comandos disponibles: " + availableCommands},
+		{"mood_analyzer lang:es", "", "Describe tu estado de ánimo. Ejemplo: 'mood_analyzer me siento feliz'"},
+		{"mood_only", mood.LocaleFrench, "Décris ton humeur. Exemple : 'mood_only je suis joyeux'"},
+		{"mood_only the weather lang:es", "", mood.MessagesFor(mood.LocaleSpanish).NoMood},
+		{"genre_radio lang:fr", "", "Quel genre dois-je jouer ? Exemple : 'genre_radio jazz'"},
+		{"clear_playlist lang:es", "", "¿Qué lista de estado de ánimo vacío? Ejemplo: 'clear_playlist feliz'"},
+		{"clear_playlist happy lang:es", "", mood.MessagesFor(mood.LocaleSpanish).ClearNoAccess},
+		{"clear_playlist happy", mood.LocaleFrench, mood.MessagesFor(mood.LocaleFrench).ClearNoAccess},
+		{"mood_analyzer more like 2 lang:fr", "", mood.MessagesFor(mood.LocaleFrench).NoEarlierList},
+	}
+
+	for _, tt := range tests {
+		agent := newTestAgent(&fakeSpotifyClient{})
+		agent.locale = tt.agentLocale
+		got, err := agent.ProcessTask(context.Background(), tt.task)
+		if err != nil {
+			t.Fatalf("ProcessTask(%q) error = %v", tt.task, err)
+		}
+		if got != tt.want {
+			t.Errorf("ProcessTask(%q) with locale %q = %q, want %q", tt.task, tt.agentLocale, got, tt.want)
+		}
+	}
+}
+
+func TestFormatLength(t *testing.T) {
+	tests := []struct {
+		length time.Duration
+		want   string
+		wantES string
+	}{
+		{time.Minute, "1 minute", "1 minuto"},
+		{45 * time.Minute, "45 minutes", "45 minutos"},
+		{time.Hour, "1 hour", "1 hora"},
+		{90 * time.Minute, "1 hour 30 minutes", "1 hora 30 minutos"},
+		{2*time.Hour + time.Minute, "2 hours 1 minute", "2 horas 1 minuto"},
+	}
+
+	for _, tt := range tests {
+		if got := formatLength(tt.length, englishMessages); got != tt.want {
+			t.Errorf("formatLength(%v) = %q, want %q", tt.length, got, tt.want)
+		}
+		if got := formatLength(tt.length, mood.MessagesFor(mood.LocaleSpanish)); got != tt.wantES {
+			t.Errorf("formatLength(%v, es) = %q, want %q", tt.length, got, tt.wantES)
+		}
+	}
+}
+
+func TestSaveMoodPlaylistLocalizedDescription(t *testing.T) {
+	client := &fakeSpotifyClient{
+		user:      &spotify.User{ID: "me"},
+		playlists: []spotify.Playlist{testPlaylist("mine", "Mood Analyst: Happy Vibes", "me")},
+	}
+	msgs := mood.MessagesFor(mood.LocaleSpanish)
+	if _, _, _, err := newTestAgent(client).saveMoodPlaylist(context.Background(), mood.MoodProfile{Mood: "happy"}, []string{"spotify:track:1"}, false, msgs); err != nil {
+		t.Fatalf("saveMoodPlaylist() error = %v", err)
+	}
+
+	want := "Una lista seleccionada para tu estado de ánimo happy. Actualizada el "
+	if got := client.updatedDetails["mine"]; !strings.HasPrefix(got, want) {
+		t.Errorf("description = %q, want it to start with %q", got, want)
+	}
+}
@@ -0,0 +1,82 @@
+package mood
+
+import "slices"
+
+// activity describes a time of day or activity that implies an energy level
+// beyond the mood itself, e.g. "winding down before bed" or "morning run"
+type activity struct {
+	name        string
+	terms       []string
+	energyShift float32 // added to the mood's energy
+	tempo       float32 // target BPM replacing the mood's tempo
+}
+
+// activities are checked in order and only the first one found in a description applies
+var activities = []activity{
+	{
+		name:        "bedtime",
+		terms:       []string{"bedtime", "bed", "sleep", "winding down", "wind down", "lullaby"},
+		energyShift: -0.3,
+		tempo:       70,
+	},
+	{
+		name:        "workout",
+		terms:       []string{"workout", "work out", "working out", "gym", "exercise", "exercising", "running", "jogging", "lifting", "training"},
+		energyShift: 0.3,
+		tempo:       140,
+	},
+	{
+		name:        "party",
+		terms:       []string{"party", "partying", "pregame", "club", "clubbing"},
+		energyShift: 0.25,
+		tempo:       125,
+	},
+	{
+		name:        "commute",
+		terms:       []string{"commute", "commuting", "driving", "road trip"},
+		energyShift: 0.05,
+		tempo:       110,
+	},
+	{
+		name:        "morning",
+		terms:       []string{"morning", "wake up", "waking up", "breakfast", "sunrise"},
+		energyShift: 0.1,
+		tempo:       105,
+	},
+}
+
+// applyActivity adjusts the energy and tempo for the first activity found in
+// the tokens and records it on the profile. It is applied after the mood is
+// classified so e.g. a workout raises the energy whatever the mood.
+func applyActivity(profile *MoodProfile, tokens []string) {
+	for _, a := range activities {
+		if positions, _ := matchTerms(tokens, a.terms); len(positions) == 0 {
+			continue
+		}
+		profile.Activity = a.name
+		profile.Energy = clamp01(profile.Energy + a.energyShift)
+		profile.Tempo = a.tempo
+		return
+	}
+}
+
+// maskActivityPhrases returns a copy of the tokens with the words of multi-word
+// activity terms blanked out, so "winding down" isn't read as feeling down.
+// Single-word terms are kept, as words like "party" name a mood too.
+func maskActivityPhrases(tokens []string) []string {
+	masked := slices.Clone(tokens)
+	for _, a := range activities {
+		for _, term := range a.terms {
+			length := len(tokenize(term))
+			if length < 2 {
+				continue
+			}
+			for _, pos := range findTerm(tokens, term) {
+				for i := pos; i < pos+length; i++ {
+					masked[i] = ""
+				}
+			}
+		}
+	}
+	return masked
+}
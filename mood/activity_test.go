@@ -0,0 +1,91 @@
+package mood
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeMoodActivity(t *testing.T) {
+	analyzer := NewMoodAnalyzer()
+
+	tests := []struct {
+		text         string
+		wantMood     string
+		wantActivity string
+		wantTempo    float32
+	}{
+		{"relaxing before bed", "relaxed", "bedtime", 70},
+		{"getting pumped for my workout", "energetic", "workout", 140},
+		{"winding down before bed", "neutral", "bedtime", 70},
+		{"working out", "neutral", "workout", 140},
+		{"happy on my morning commute", "happy", "commute", 110},
+		{"sad", "sad", "", 0},
+	}
+
+	for _, tt := range tests {
+		profile := analyzer.AnalyzeMood(tt.text)
+		if profile.Mood != tt.wantMood || profile.Activity != tt.wantActivity {
+			t.Errorf("AnalyzeMood(%q) = mood %q, activity %q, want %q, %q", tt.text, profile.Mood, profile.Activity, tt.wantMood, tt.wantActivity)
+		}
+		if tt.wantTempo != 0 && profile.Tempo != tt.wantTempo {
+			t.Errorf("AnalyzeMood(%q).Tempo = %v, want %v", tt.text, profile.Tempo, tt.wantTempo)
+		}
+	}
+}
+
+func TestAnalyzeMoodActivityShiftsEnergy(t *testing.T) {
+	analyzer := NewMoodAnalyzer()
+
+	bedtime := analyzer.AnalyzeMood("relaxing before bed")
+	relaxed := analyzer.AnalyzeMood("relaxing")
+	if bedtime.Energy >= relaxed.Energy {
+		t.Errorf("bedtime energy %.2f, want it below the plain mood's %.2f", bedtime.Energy, relaxed.Energy)
+	}
+
+	workout := analyzer.AnalyzeMood("getting pumped for my workout")
+	pumped := analyzer.AnalyzeMood("getting pumped")
+	if workout.Energy <= pumped.Energy {
+		t.Errorf("workout energy %.2f, want it above the plain mood's %.2f", workout.Energy, pumped.Energy)
+	}
+	if workout.Energy <= bedtime.Energy {
+		t.Errorf("workout energy %.2f, want it above bedtime's %.2f", workout.Energy, bedtime.Energy)
+	}
+}
+
+func TestActivityPhrasesArentMoodKeywords(t *testing.T) {
+	analyzer := NewMoodAnalyzer()
+
+	tests := []struct {
+		text      string
+		wantMood  string
+		wantTerms []string
+	}{
+		// "down" in "winding down" is not feeling down
+		{"winding down before bed", "neutral", nil},
+		{"time to wind down", "neutral", nil},
+		// A mood word of its own still counts
+		{"feeling down, need to wind down", "sad", []string{"down"}},
+	}
+
+	for _, tt := range tests {
+		for name, profile := range map[string]MoodProfile{
+			"AnalyzeMood":        analyzer.AnalyzeMood(tt.text),
+			"AnalyzeMoodBlended": analyzer.AnalyzeMoodBlended(tt.text),
+		} {
+			if profile.Mood != tt.wantMood || !slices.Equal(profile.MatchedTerms, tt.wantTerms) {
+				t.Errorf("%s(%q) = %q %v, want %q %v", name, tt.text, profile.Mood, profile.MatchedTerms, tt.wantMood, tt.wantTerms)
+			}
+			if profile.Activity != "bedtime" {
+				t.Errorf("%s(%q).Activity = %q, want bedtime", name, tt.text, profile.Activity)
+			}
+		}
+	}
+}
+
+func TestMaskActivityPhrases(t *testing.T) {
+	got := maskActivityPhrases(tokenize("feeling down while winding down for bed"))
+	want := []string{"feeling", "down", "while", "", "", "for", "bed"}
+	if !slices.Equal(got, want) {
+		t.Errorf("maskActivityPhrases() = %q, want %q", got, want)
+	}
+}
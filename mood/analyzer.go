@@ -2,11 +2,19 @@ package mood
 
 import (
 	"fmt"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
-// MoodAnalyzer analyzes user mood and determines music preferences
-type MoodAnalyzer struct{}
+// MoodAnalyzer analyzes user mood and determines music preferences.
+// The zero value uses the built-in mood categories.
+type MoodAnalyzer struct {
+	categories []MoodCategory
+	languages  map[string]map[string][]string
+}
 
 // MoodProfile represents user mood characteristics
 type MoodProfile struct {
@@ -15,15 +23,444 @@ type MoodProfile struct {
 	Danceability     float32
 	Valence          float32
 	Acousticness     float32
+	Tempo            float32 // target BPM, 0 leaves the tempo unconstrained
+	Instrumentalness float32 // 0 leaves instrumentalness unconstrained
+	Speechiness      float32 // 0 leaves speechiness unconstrained
+	Mode             string  // "major", "minor" or empty to leave the mode unconstrained
+	MinEnergy        float32 // lowest energy to allow, 0 leaves it unbounded
+	MaxEnergy        float32 // highest energy to allow, 0 leaves it unbounded
+	MinValence       float32 // lowest valence to allow, 0 leaves it unbounded
+	MaxValence       float32 // highest valence to allow, 0 leaves it unbounded
+	Polarity         float32 // sentiment in [-1, 1] from positive vs. negative matches
 	SuggestedGenres  []string
+	RequestedGenres  []string // genres the user named, also leading SuggestedGenres
+	SimilarArtist    string   // artist from "like <artist>" in the description
 	SearchQueryTerms string
+	YearRange        string   // release years from a decade or years in the description, e.g. "1990-1999", for a year: search filter
+	Activity         string   // activity or time of day that adjusted energy and tempo, e.g. "workout"
+	MinPopularity    int      // lowest track popularity (0-100) to keep
+	MaxPopularity    int      // highest track popularity (0-100) to keep, 0 leaves popularity unconstrained
+	MatchedTerms     []string // keywords and emoji that triggered the detected mood
+	Ambiguous        bool     // both positive and negative moods matched, e.g. "happy but also kind of sad"
+	Detected         bool     // false when nothing matched and the profile is the neutral fallback
+}
+
+// BittersweetMood is the mood name AnalyzeMoodBlended gives to a blend of opposing moods
+const BittersweetMood = "bittersweet"
+
+// bittersweetQueryTerms are the search terms for a bittersweet blend
+const bittersweetQueryTerms = "bittersweet melancholic hopeful"
+
+// MoodCategory describes the keywords that trigger a mood and the music profile it maps to
+type MoodCategory struct {
+	Mood             string   `json:"mood"`
+	Keywords         []string `json:"keywords"`
+	Energy           float32  `json:"energy"`
+	Danceability     float32  `json:"danceability"`
+	Valence          float32  `json:"valence"`
+	Acousticness     float32  `json:"acousticness"`
+	Tempo            float32  `json:"tempo"`
+	Instrumentalness float32  `json:"instrumentalness"`
+	Speechiness      float32  `json:"speechiness"`
+	Mode             string   `json:"mode"`
+	MinEnergy        float32  `json:"min_energy"`
+	MaxEnergy        float32  `json:"max_energy"`
+	MinValence       float32  `json:"min_valence"`
+	MaxValence       float32  `json:"max_valence"`
+	Polarity         int      `json:"polarity"` // 1 positive, -1 negative, 0 neutral sentiment
+	SuggestedGenres  []string `json:"suggested_genres"`
+	SearchQueryTerms string   `json:"search_query_terms"`
 }
 
-// AnalyzeMood analyzes mood description and returns mood profile
+// defaultCategories lists the built-in mood categories. When two categories match
+// the same number of keywords, the one named in the description wins, and only
+// then the one listed first.
+var defaultCategories = []MoodCategory{
+	{
+		Mood:             "happy",
+		Keywords:         []string{"happy", "joyful", "excited", "upbeat", "great", "fantastic"},
+		Energy:           0.8,
+		Danceability:     0.7,
+		Valence:          0.8,
+		Acousticness:     0.3,
+		Polarity:         1,
+		Tempo:            120,
+		Instrumentalness: 0.05,
+		Mode:             "major",
+		MinValence:       0.5,
+		SuggestedGenres:  []string{"pop", "dance", "electronic", "funk"},
+		SearchQueryTerms: "happy upbeat energetic",
+	},
+	{
+		Mood:             "sad",
+		Keywords:         []string{"sad", "down", "depressed", "lonely", "blue", "heartbroken", "melancholy"},
+		Energy:           0.3,
+		Danceability:     0.2,
+		Valence:          0.2,
+		Acousticness:     0.7,
+		Polarity:         -1,
+		Tempo:            75,
+		Mode:             "minor",
+		MaxValence:       0.45,
+		SuggestedGenres:  []string{"indie", "folk", "soul", "acoustic"},
+		SearchQueryTerms: "sad emotional soulful",
+	},
+	{
+		Mood:             "relaxed",
+		Keywords:         []string{"calm", "relaxed", "chill", "peaceful", "serene", "tranquil", "zen", "tired", "sleepy"},
+		Energy:           0.2,
+		Danceability:     0.3,
+		Valence:          0.5,
+		Acousticness:     0.8,
+		Tempo:            70,
+		Instrumentalness: 0.5,
+		Speechiness:      0.05,
+		MaxEnergy:        0.5,
+		SuggestedGenres:  []string{"ambient", "lo-fi", "jazz", "acoustic"},
+		SearchQueryTerms: "relaxing chill ambient",
+	},
+	{
+		Mood:             "energetic",
+		Keywords:         []string{"pumped", "energetic", "motivated", "fired up", "adrenaline"},
+		Energy:           0.9,
+		Danceability:     0.8,
+		Valence:          0.7,
+		Acousticness:     0.1,
+		Polarity:         1,
+		Tempo:            150,
+		Speechiness:      0.1,
+		Mode:             "major",
+		MinEnergy:        0.6,
+		SuggestedGenres:  []string{"hip-hop", "electronic", "rock", "metal"},
+		SearchQueryTerms: "energetic powerful intense",
+	},
+	{
+		Mood:             "romantic",
+		Keywords:         []string{"romantic", "in love", "loved", "affectionate", "passionate"},
+		Energy:           0.4,
+		Danceability:     0.5,
+		Valence:          0.7,
+		Acousticness:     0.6,
+		Polarity:         1,
+		Tempo:            90,
+		Mode:             "major",
+		SuggestedGenres:  []string{"soul", "r&b", "indie", "acoustic pop"},
+		SearchQueryTerms: "romantic love passionate",
+	},
+	{
+		Mood:             "focused",
+		Keywords:         []string{"focused", "studying", "concentrating", "working", "productive"},
+		Energy:           0.5,
+		Danceability:     0.3,
+		Valence:          0.5,
+		Acousticness:     0.5,
+		Tempo:            100,
+		Instrumentalness: 0.9,
+		Speechiness:      0.03,
+		SuggestedGenres:  []string{"lo-fi", "classical", "ambient", "instrumental"},
+		SearchQueryTerms: "focus study concentration",
+	},
+	{
+		Mood:             "angry",
+		Keywords:         []string{"angry", "furious", "mad", "rage", "frustrated", "pissed", "livid", "irritated"},
+		Energy:           0.9,
+		Danceability:     0.5,
+		Valence:          0.2,
+		Acousticness:     0.1,
+		Polarity:         -1,
+		Tempo:            140,
+		Mode:             "minor",
+		MinEnergy:        0.6,
+		MaxValence:       0.5,
+		SuggestedGenres:  []string{"metal", "punk", "hard rock", "rap"},
+		SearchQueryTerms: "aggressive intense angry",
+	},
+	{
+		// Anxious listeners usually want to be soothed, so the valence leans slightly
+		// positive instead of mirroring the negative sentiment.
+		Mood:             "anxious",
+		Keywords:         []string{"anxious", "nervous", "stressed", "worried", "overwhelmed", "panicky", "tense", "uneasy"},
+		Energy:           0.3,
+		Danceability:     0.3,
+		Valence:          0.55,
+		Acousticness:     0.75,
+		Polarity:         -1,
+		Tempo:            70,
+		Instrumentalness: 0.6,
+		Speechiness:      0.03,
+		MaxEnergy:        0.5,
+		SuggestedGenres:  []string{"ambient", "classical", "lo-fi", "piano"},
+		SearchQueryTerms: "calming soothing peaceful",
+	},
+	{
+		Mood:             "nostalgic",
+		Keywords:         []string{"nostalgic", "nostalgia", "throwback", "memories", "reminiscing", "old times", "good old days"},
+		Energy:           0.5,
+		Danceability:     0.5,
+		Valence:          0.6,
+		Acousticness:     0.5,
+		Tempo:            110,
+		SuggestedGenres:  []string{"oldies", "classic rock", "soul", "pop"},
+		SearchQueryTerms: "throwback classics",
+	},
+	{
+		Mood:             "party",
+		Keywords:         []string{"party", "celebrate", "celebrating", "dancing", "night out", "turnt", "festive"},
+		Energy:           0.85,
+		Danceability:     0.9,
+		Valence:          0.75,
+		Acousticness:     0.1,
+		Polarity:         1,
+		Tempo:            125,
+		Mode:             "major",
+		MinEnergy:        0.6,
+		SuggestedGenres:  []string{"dance", "pop", "edm", "hip-hop", "reggaeton"},
+		SearchQueryTerms: "party dance hits",
+	},
+}
+
+// categoryMatch records how strongly a category matched a mood description
+type categoryMatch struct {
+	category  MoodCategory
+	positions []int
+	terms     []string
+	score     int
+}
+
+// AnalyzeMood analyzes mood description and returns mood profile.
+// Every category is scored by the number of its keywords found in the description
+// and the highest scoring category determines the profile.
 func (ma *MoodAnalyzer) AnalyzeMood(moodDescription string) MoodProfile {
-	description := strings.ToLower(moodDescription)
+	moodDescription = StripPreamble(NormalizeText(moodDescription))
+	tokens := tokenize(moodDescription)
+	matches := ma.matchCategories(moodDescription, maskActivityPhrases(tokens))
+
+	profile := neutralProfile()
+	if best := strongestMatch(matches); best != nil {
+		profile = best.category.profile()
+		profile.Detected = true
+		profile.MatchedTerms = best.terms
+		applyIntensity(&profile, tokens, best.positions)
+	}
+	profile.Polarity = polarity(matches)
+	profile.Ambiguous = ambiguous(matches)
+	applyCues(&profile, moodDescription, tokens)
+
+	return profile
+}
+
+// AnalyzeMoodBlended analyzes mood description like AnalyzeMood, but when several
+// categories match it averages their audio targets weighted by match strength and
+// merges their genres, strongest category first. The mood name and search terms
+// come from the strongest category, except that a blend of opposing moods (e.g.
+// happy and sad) is called "bittersweet".
+func (ma *MoodAnalyzer) AnalyzeMoodBlended(moodDescription string) MoodProfile {
+	moodDescription = StripPreamble(NormalizeText(moodDescription))
+	tokens := tokenize(moodDescription)
+	matches := ma.matchCategories(moodDescription, maskActivityPhrases(tokens))
+
+	best := strongestMatch(matches)
+	if best == nil {
+		profile := neutralProfile()
+		applyCues(&profile, moodDescription, tokens)
+		return profile
+	}
+
+	profile := best.category.profile()
+	profile.Detected = true
+
+	// Strongest first so the merged genre list is ordered by relevance
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+
+	var total, energy, danceability, valence, acousticness float32
+	var tempo, instrumentalness, speechiness optionalAverage
+	var positions []int
+	var terms []string
+	genres := []string{}
+	seen := make(map[string]bool)
+	for _, match := range matches {
+		weight := float32(match.score)
+		total += weight
+		energy += weight * match.category.Energy
+		danceability += weight * match.category.Danceability
+		valence += weight * match.category.Valence
+		acousticness += weight * match.category.Acousticness
+		tempo.add(match.category.Tempo, weight)
+		instrumentalness.add(match.category.Instrumentalness, weight)
+		speechiness.add(match.category.Speechiness, weight)
+		positions = append(positions, match.positions...)
+		terms = append(terms, match.terms...)
+
+		for _, genre := range match.category.SuggestedGenres {
+			if !seen[genre] {
+				seen[genre] = true
+				genres = append(genres, genre)
+			}
+		}
+	}
+
+	profile.Energy = energy / total
+	profile.Danceability = danceability / total
+	profile.Valence = valence / total
+	profile.Acousticness = acousticness / total
+	profile.Tempo = tempo.value()
+	profile.Instrumentalness = instrumentalness.value()
+	profile.Speechiness = speechiness.value()
+	profile.SuggestedGenres = genres
+	profile.MatchedTerms = terms
+	profile.Polarity = polarity(matches)
+	// One mood's bounds would cut off the others in the blend
+	if len(matches) > 1 {
+		profile.MinEnergy, profile.MaxEnergy, profile.MinValence, profile.MaxValence = 0, 0, 0, 0
+	}
+	if profile.Ambiguous = ambiguous(matches); profile.Ambiguous {
+		profile.Mood = BittersweetMood
+		profile.SearchQueryTerms = bittersweetQueryTerms
+	}
+
+	applyIntensity(&profile, tokens, positions)
+	applyCues(&profile, moodDescription, tokens)
+
+	return profile
+}
+
+// matchCategories scores every category against the words and emoji of a description
+// and returns the ones that matched, in category order
+func (ma *MoodAnalyzer) matchCategories(description string, tokens []string) []categoryMatch {
+	emoji := emojiMatches(description)
+
+	var matches []categoryMatch
+	for _, category := range ma.moodCategories() {
+		positions, terms := matchTerms(tokens, ma.keywordsFor(category))
+		terms = append(terms, emoji[category.Mood]...)
+		if len(terms) > 0 {
+			matches = append(matches, categoryMatch{
+				category:  category,
+				positions: positions,
+				terms:     terms,
+				score:     len(terms),
+			})
+		}
+	}
+	return matches
+}
+
+// strongestMatch returns the match with the highest score, or nil if nothing
+// matched. On ties a category matched by its own name ("energetic") beats one
+// matched by another keyword, and after that the earlier category wins.
+func strongestMatch(matches []categoryMatch) *categoryMatch {
+	var best *categoryMatch
+	for i := range matches {
+		switch {
+		case best == nil, matches[i].score > best.score:
+			best = &matches[i]
+		case matches[i].score == best.score && matches[i].namesMood() && !best.namesMood():
+			best = &matches[i]
+		}
+	}
+	return best
+}
+
+// namesMood reports whether the category's own mood name is among the matched terms
+func (m categoryMatch) namesMood() bool {
+	return slices.Contains(m.terms, m.category.Mood)
+}
+
+// polarity returns the sentiment of the matches in [-1, 1]: the difference between
+// positive and negative match counts relative to all matches
+func polarity(matches []categoryMatch) float32 {
+	var total, sentiment int
+	for _, match := range matches {
+		total += match.score
+		sentiment += match.score * match.category.Polarity
+	}
+	if total == 0 {
+		return 0
+	}
+	return clamp(float32(sentiment)/float32(total), -1, 1)
+}
+
+// ambiguous reports whether the matches include both positive and negative moods
+func ambiguous(matches []categoryMatch) bool {
+	var positive, negative bool
+	for _, match := range matches {
+		positive = positive || match.category.Polarity > 0
+		negative = negative || match.category.Polarity < 0
+	}
+	return positive && negative
+}
+
+// Confidence estimates how sure the analysis is of the profile's mood, in [0, 1].
+// Every matched term makes it surer and mixed feelings make it less sure. A mood
+// implied by an activity alone gets little confidence, and one that wasn't
+// matched at all, such as a random pick, gets none.
+func Confidence(profile MoodProfile) float32 {
+	if !profile.Detected && profile.Activity != "" {
+		return 0.3
+	}
+
+	var confidence float32
+	switch len(profile.MatchedTerms) {
+	case 0:
+		return 0
+	case 1:
+		confidence = 0.6
+	case 2:
+		confidence = 0.8
+	default:
+		confidence = 0.9
+	}
+	if profile.Ambiguous {
+		confidence *= 0.6
+	}
+	return confidence
+}
+
+// applyCues applies what the description asks for beyond the mood itself:
+// activities, release years, genres, a similar artist and how popular the songs should be
+func applyCues(profile *MoodProfile, description string, tokens []string) {
+	applyActivity(profile, tokens)
+	profile.YearRange = extractYearRange(description, tokens)
+	applyRequestedGenres(profile, tokens)
+	applyPopularity(profile, tokens)
+	profile.SimilarArtist = extractSimilarArtist(description, slices.Concat(profile.MatchedTerms, profile.RequestedGenres))
+}
+
+// applyIntensity scales the targets away from or towards neutral for "very happy", "slightly sad", etc.
+func applyIntensity(profile *MoodProfile, tokens []string, positions []int) {
+	factor := intensityFactor(tokens, positions)
+	profile.Energy = scaleFromNeutral(profile.Energy, factor)
+	profile.Danceability = scaleFromNeutral(profile.Danceability, factor)
+	profile.Valence = scaleFromNeutral(profile.Valence, factor)
+}
+
+// optionalAverage accumulates a weighted average of a target where 0 means unset
+type optionalAverage struct {
+	sum    float32
+	weight float32
+}
+
+// add includes value in the average if it is set
+func (a *optionalAverage) add(value, weight float32) {
+	if value > 0 {
+		a.sum += weight * value
+		a.weight += weight
+	}
+}
 
-	profile := MoodProfile{
+// value returns the weighted average, or 0 if no value was set
+func (a optionalAverage) value() float32 {
+	if a.weight == 0 {
+		return 0
+	}
+	return a.sum / a.weight
+}
+
+// neutralProfile returns the profile used when no mood is detected
+func neutralProfile() MoodProfile {
+	return MoodProfile{
 		Mood:            "neutral",
 		Energy:          0.5,
 		Danceability:    0.5,
@@ -31,97 +468,393 @@ func (ma *MoodAnalyzer) AnalyzeMood(moodDescription string) MoodProfile {
 		Acousticness:    0.5,
 		SuggestedGenres: []string{},
 	}
+}
 
-	// Detect happy/positive moods
-	if containsAny(description, []string{"happy", "joyful", "excited", "energetic", "upbeat", "great", "fantastic"}) {
-		profile.Mood = "happy"
-		profile.Energy = 0.8
-		profile.Danceability = 0.7
-		profile.Valence = 0.8
-		profile.Acousticness = 0.3
-		profile.SuggestedGenres = []string{"pop", "dance", "electronic", "funk"}
-		profile.SearchQueryTerms = "happy upbeat energetic"
-	}
-
-	// Detect sad/melancholic moods
-	if containsAny(description, []string{"sad", "down", "depressed", "lonely", "blue", "heartbroken", "melancholy"}) {
-		profile.Mood = "sad"
-		profile.Energy = 0.3
-		profile.Danceability = 0.2
-		profile.Valence = 0.2
-		profile.Acousticness = 0.7
-		profile.SuggestedGenres = []string{"indie", "folk", "soul", "acoustic"}
-		profile.SearchQueryTerms = "sad emotional soulful"
-	}
-
-	// Detect relaxed/calm moods
-	if containsAny(description, []string{"calm", "relaxed", "chill", "peaceful", "serene", "tranquil", "zen"}) {
-		profile.Mood = "relaxed"
-		profile.Energy = 0.2
-		profile.Danceability = 0.3
-		profile.Valence = 0.5
-		profile.Acousticness = 0.8
-		profile.SuggestedGenres = []string{"ambient", "lo-fi", "jazz", "acoustic"}
-		profile.SearchQueryTerms = "relaxing chill ambient"
-	}
-
-	// Detect energetic/pumped moods
-	if containsAny(description, []string{"pumped", "energetic", "motivated", "fired up", "adrenaline"}) {
-		profile.Mood = "energetic"
-		profile.Energy = 0.9
-		profile.Danceability = 0.8
-		profile.Valence = 0.7
-		profile.Acousticness = 0.1
-		profile.SuggestedGenres = []string{"hip-hop", "electronic", "rock", "metal"}
-		profile.SearchQueryTerms = "energetic powerful intense"
-	}
-
-	// Detect romantic/loving moods
-	if containsAny(description, []string{"romantic", "in love", "loved", "affectionate", "passionate"}) {
-		profile.Mood = "romantic"
-		profile.Energy = 0.4
-		profile.Danceability = 0.5
-		profile.Valence = 0.7
-		profile.Acousticness = 0.6
-		profile.SuggestedGenres = []string{"soul", "r&b", "indie", "acoustic pop"}
-		profile.SearchQueryTerms = "romantic love passionate"
-	}
-
-	// Detect focus/study moods
-	if containsAny(description, []string{"focused", "studying", "concentrating", "working", "productive"}) {
-		profile.Mood = "focused"
-		profile.Energy = 0.5
-		profile.Danceability = 0.3
-		profile.Valence = 0.5
-		profile.Acousticness = 0.5
-		profile.SuggestedGenres = []string{"lo-fi", "classical", "ambient", "instrumental"}
-		profile.SearchQueryTerms = "focus study concentration"
+// profile builds the mood profile for a category
+func (c MoodCategory) profile() MoodProfile {
+	return MoodProfile{
+		Mood:             c.Mood,
+		Energy:           c.Energy,
+		Danceability:     c.Danceability,
+		Valence:          c.Valence,
+		Acousticness:     c.Acousticness,
+		Tempo:            c.Tempo,
+		Instrumentalness: c.Instrumentalness,
+		Speechiness:      c.Speechiness,
+		Mode:             c.Mode,
+		MinEnergy:        c.MinEnergy,
+		MaxEnergy:        c.MaxEnergy,
+		MinValence:       c.MinValence,
+		MaxValence:       c.MaxValence,
+		SuggestedGenres:  append([]string{}, c.SuggestedGenres...),
+		SearchQueryTerms: c.SearchQueryTerms,
 	}
-
-	return profile
 }
 
 // GetMoodParameters returns Spotify API parameters for mood
 func (ma *MoodAnalyzer) GetMoodParameters(profile MoodProfile) map[string]interface{} {
-	return map[string]interface{}{
+	params := map[string]interface{}{
 		"target_energy":       profile.Energy,
 		"target_danceability": profile.Danceability,
 		"target_valence":      profile.Valence,
 		"target_acousticness": profile.Acousticness,
 	}
+	// Optional targets are only sent when set to avoid over-constraining recommendations
+	if profile.Tempo > 0 {
+		params["target_tempo"] = profile.Tempo
+	}
+	if profile.Instrumentalness > 0 {
+		params["target_instrumentalness"] = profile.Instrumentalness
+	}
+	if profile.Speechiness > 0 {
+		params["target_speechiness"] = profile.Speechiness
+	}
+	switch profile.Mode {
+	case "major":
+		params["target_mode"] = 1
+	case "minor":
+		params["target_mode"] = 0
+	}
+	if profile.MaxPopularity > 0 {
+		params["min_popularity"] = profile.MinPopularity
+		params["max_popularity"] = profile.MaxPopularity
+	}
+	setBounds(params, "energy", profile.Energy, profile.MinEnergy, profile.MaxEnergy)
+	setBounds(params, "valence", profile.Valence, profile.MinValence, profile.MaxValence)
+	return params
+}
+
+// setBounds adds the min_ and max_ parameters for a feature that has bounds.
+// Activities and intensity words can move a target past its mood's bounds, so
+// the bounds are widened to always include the target.
+func setBounds(params map[string]interface{}, feature string, target, lower, upper float32) {
+	if lower > 0 {
+		params["min_"+feature] = min(lower, target)
+	}
+	if upper > 0 {
+		params["max_"+feature] = max(upper, target)
+	}
+}
+
+// negationWords are words that cancel a mood keyword when they appear shortly before it
+var negationWords = map[string]bool{
+	"not": true, "no": true, "never": true, "nor": true, "hardly": true,
+	"isn't": true, "aren't": true, "wasn't": true, "weren't": true,
+	"don't": true, "doesn't": true, "didn't": true, "can't": true, "won't": true,
+	"ain't": true, "dont": true, "cant": true, "isnt": true,
+	// Common negations of registered languages
+	"pas": true, "jamais": true, "nunca": true, "nada": true,
+}
+
+// emojiMoods maps common emoji to the mood category they express. Skin-tone modifiers
+// and variation selectors are separate runes, so only the base emoji needs listing.
+var emojiMoods = map[rune]string{
+	'😀': "happy", '😃': "happy", '😄': "happy", '😁': "happy", '😊': "happy", '🙂': "happy", '🥳': "happy", '🎉': "happy",
+	'😭': "sad", '😢': "sad", '😞': "sad", '😔': "sad", '🥺': "sad", '💔': "sad",
+	'😌': "relaxed", '🧘': "relaxed", '🌿': "relaxed", '☕': "relaxed",
+	'💪': "energetic", '🔥': "energetic", '⚡': "energetic", '🏃': "energetic", '🏋': "energetic",
+	'🥰': "romantic", '😍': "romantic", '😘': "romantic", '❤': "romantic", '💕': "romantic", '💘': "romantic",
+	'📚': "focused", '🧠': "focused", '💻': "focused",
+	'😡': "angry", '😠': "angry", '🤬': "angry", '💢': "angry",
+	'😰': "anxious", '😟': "anxious", '😬': "anxious", '😨': "anxious", '😥': "anxious",
+}
+
+// intensityModifiers scale how far a mood pushes the audio targets away from neutral.
+// Values above 1 intensify the mood, values below 1 soften it.
+var intensityModifiers = map[string]float32{
+	"very":       1.3,
+	"so":         1.3,
+	"really":     1.3,
+	"super":      1.4,
+	"extremely":  1.6,
+	"incredibly": 1.5,
+	"slightly":   0.5,
+	"a bit":      0.6,
+	"a little":   0.6,
+	"kinda":      0.6,
+	"kind of":    0.6,
+	"somewhat":   0.7,
+}
+
+// negationWindow is how many words before a keyword are checked for a negation
+const negationWindow = 3
+
+// tokenize splits text into lowercase words, dropping whitespace and punctuation
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.Trim(field, "'"); field != "" {
+			tokens = append(tokens, field)
+		}
+	}
+	return tokens
+}
+
+// wordMatches checks if a token is the given word, allowing a simple plural
+// suffix and other inflections such as "sadness" for "sad"
+func wordMatches(token, word string) bool {
+	return token == word || token == word+"s" || token == word+"es" || sameStem(token, word)
+}
+
+// findTerm returns the token positions where the (possibly multi-word) term starts
+func findTerm(tokens []string, term string) []int {
+	words := tokenize(term)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var positions []int
+	for i := 0; i+len(words) <= len(tokens); i++ {
+		matched := true
+		for j, word := range words {
+			if !wordMatches(tokens[i+j], word) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			positions = append(positions, i)
+		}
+	}
+	return positions
+}
+
+// matchesAnyWord checks if text contains any of the terms as whole words
+func matchesAnyWord(text string, terms []string) bool {
+	tokens := tokenize(text)
+	for _, term := range terms {
+		if len(findTerm(tokens, term)) > 0 {
+			return true
+		}
+	}
+	return false
 }
 
-// containsAny checks if string contains any of the given substrings
-func containsAny(text string, terms []string) bool {
+// matchTerms returns the terms that appear as whole words in the tokens, along with
+// the token position of the first non-negated occurrence of each
+func matchTerms(tokens []string, terms []string) ([]int, []string) {
+	var positions []int
+	var matched []string
 	for _, term := range terms {
-		if strings.Contains(text, term) {
+		for _, pos := range findTerm(tokens, term) {
+			if !isNegated(tokens, pos) {
+				positions = append(positions, pos)
+				matched = append(matched, term)
+				break
+			}
+		}
+	}
+	return positions, matched
+}
+
+// isNegated checks whether a negation word appears in the few tokens before pos
+func isNegated(tokens []string, pos int) bool {
+	start := pos - negationWindow
+	if start < 0 {
+		start = 0
+	}
+	for _, token := range tokens[start:pos] {
+		if negationWords[token] {
 			return true
 		}
 	}
 	return false
 }
 
-// FormatTrackRecommendation formats a track into a recommendation string
-func FormatTrackRecommendation(trackName, artistName, spotifyURL string) string {
-	return fmt.Sprintf("🎵 %s by %s\n   🔗 %s", trackName, artistName, spotifyURL)
+// emojiMatches collects the mood emoji in text, keyed by mood category
+func emojiMatches(text string) map[string][]string {
+	matches := make(map[string][]string)
+	for _, r := range text {
+		if mood, ok := emojiMoods[r]; ok {
+			matches[mood] = append(matches[mood], string(r))
+		}
+	}
+	return matches
+}
+
+// intensityFactor returns the scaling factor of the first intensity modifier found
+// directly before one of the matched keyword positions, or 1 if there is none
+func intensityFactor(tokens []string, positions []int) float32 {
+	for _, pos := range positions {
+		for modifier, factor := range intensityModifiers {
+			words := tokenize(modifier)
+			start := pos - len(words)
+			if start < 0 {
+				continue
+			}
+			if strings.Join(tokens[start:pos], " ") == strings.Join(words, " ") {
+				return factor
+			}
+		}
+	}
+	return 1
+}
+
+// scaleFromNeutral multiplies the distance of value from 0.5 by factor, clamped to [0, 1]
+func scaleFromNeutral(value, factor float32) float32 {
+	return clamp01(0.5 + (value-0.5)*factor)
+}
+
+// clamp01 limits value to the range [0, 1]
+func clamp01(value float32) float32 {
+	return clamp(value, 0, 1)
+}
+
+// clamp limits value to the range [min, max]
+func clamp(value, min, max float32) float32 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+// extractDecade returns the first decade mentioned in the tokens ("90s", "'80s", "2000s")
+// normalized to four digits, e.g. "1990s", or an empty string if there is none
+func extractDecade(tokens []string) string {
+	for _, token := range tokens {
+		if !strings.HasSuffix(token, "s") {
+			continue
+		}
+		digits := strings.TrimSuffix(token, "s")
+		year, err := strconv.Atoi(digits)
+		if err != nil || year%10 != 0 {
+			continue
+		}
+
+		switch len(digits) {
+		case 2:
+			// Two-digit decades from the 30s onwards refer to the 1900s
+			if year >= 30 {
+				return fmt.Sprintf("19%02ds", year)
+			}
+			return fmt.Sprintf("20%02ds", year)
+		case 4:
+			if year >= 1900 && year <= 2090 {
+				return token
+			}
+		}
+	}
+	return ""
+}
+
+// FormatMatchedTerms describes which terms triggered a mood, e.g. "I picked up on 'heartbroken' and 'lonely'."
+// It returns an empty string when there are no terms.
+func FormatMatchedTerms(terms []string) string {
+	return formatMatchedTerms(terms, MessagesFor(DefaultLocale))
+}
+
+// formatMatchedTerms is FormatMatchedTerms in the language of the messages
+func formatMatchedTerms(terms []string, msgs Messages) string {
+	if len(terms) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = "'" + term + "'"
+	}
+
+	list := quoted[0]
+	if len(quoted) > 1 {
+		list = strings.Join(quoted[:len(quoted)-1], ", ") + " " + msgs.And + " " + quoted[len(quoted)-1]
+	}
+	return fmt.Sprintf(msgs.MatchedTerms, list)
+}
+
+// summaryFeature describes how an audio feature reads at either end of its range
+type summaryFeature struct {
+	name      string
+	value     float32
+	high, low string
+}
+
+// FormatIntensitySummary describes how strongly the profile leans on its key
+// audio features, e.g. "You sound strongly energetic (90% energy) and upbeat
+// (75% positivity)." Features close to neutral are left out, and it returns an
+// empty string when nothing stands out or no mood was detected.
+func FormatIntensitySummary(profile MoodProfile) string {
+	return FormatLocalizedIntensitySummary(profile, DefaultLocale)
+}
+
+// FormatLocalizedIntensitySummary is FormatIntensitySummary in the language of
+// the locale
+func FormatLocalizedIntensitySummary(profile MoodProfile, locale Locale) string {
+	if !profile.Detected {
+		return ""
+	}
+
+	msgs := MessagesFor(locale)
+	features := []summaryFeature{
+		{msgs.EnergyName, profile.Energy, msgs.Energetic, msgs.Mellow},
+		{msgs.PositivityName, profile.Valence, msgs.Upbeat, msgs.Down},
+	}
+	// The strongest feature leads the summary
+	sort.SliceStable(features, func(i, j int) bool {
+		return abs32(features[i].value-0.5) > abs32(features[j].value-0.5)
+	})
+
+	var parts []string
+	for _, f := range features {
+		distance := abs32(f.value - 0.5)
+		if distance < 0.1 {
+			continue
+		}
+
+		word := f.high
+		if f.value < 0.5 {
+			word = f.low
+		}
+		switch {
+		case distance >= 0.35:
+			word = fmt.Sprintf(msgs.Strongly, word)
+		case distance < 0.2:
+			word = fmt.Sprintf(msgs.ALittle, word)
+		}
+		parts = append(parts, fmt.Sprintf(msgs.IntensityFeature, word, f.value*100, f.name))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(msgs.IntensitySummary, strings.Join(parts, " "+msgs.And+" "))
+}
+
+// abs32 returns the absolute value of x
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// FormatTrackRecommendation formats a track into a recommendation string.
+// Multiple artists are joined with ", " and the duration is shown as m:ss
+// unless durationMs is 0. A preview link is added when previewURL is set.
+func FormatTrackRecommendation(trackName string, artistNames []string, durationMs int, spotifyURL, previewURL string) string {
+	return formatTrackRecommendation(trackName, artistNames, durationMs, spotifyURL, previewURL, MessagesFor(DefaultLocale))
+}
+
+// formatTrackRecommendation is FormatTrackRecommendation with the words of msgs
+func formatTrackRecommendation(trackName string, artistNames []string, durationMs int, spotifyURL, previewURL string, msgs Messages) string {
+	name := trackName
+	if durationMs > 0 {
+		name = fmt.Sprintf("%s (%s)", trackName, FormatDuration(durationMs))
+	}
+	recommendation := fmt.Sprintf("🎵 %s %s %s\n   🔗 %s", name, msgs.By, joinArtists(artistNames, msgs), spotifyURL)
+	if previewURL != "" {
+		recommendation += fmt.Sprintf("\n   ▶ %s: %s", msgs.Preview, previewURL)
+	}
+	return recommendation
+}
+
+// FormatDuration formats a duration in milliseconds as m:ss, e.g. 222000 as "3:42"
+func FormatDuration(durationMs int) string {
+	seconds := durationMs / 1000
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
 }
@@ -0,0 +1,684 @@
+package mood
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAnalyzeMoodPicksDominantCategory(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"I'm happy and joyful but a bit focused", "happy"},
+		{"a bit focused but happy and joyful", "happy"},
+		{"studying and productive, but kind of happy", "focused"},
+		{"sad, lonely and heartbroken but excited", "sad"},
+		{"calm and peaceful, a little tired of working", "relaxed"},
+		{"furious and livid, also nervous", "angry"},
+		{"stressed, worried and overwhelmed but a little mad", "anxious"},
+	}
+
+	ma := NewMoodAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := ma.AnalyzeMood(tt.description).Mood; got != tt.want {
+				t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", tt.description, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeMoodTieGoesToNamedMood(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"I feel energetic", "energetic"},
+		{"excited and energetic", "energetic"},
+		{"energetic and excited", "energetic"},
+		{"working but relaxed", "relaxed"},
+	}
+
+	ma := NewMoodAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := ma.AnalyzeMood(tt.description).Mood; got != tt.want {
+				t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", tt.description, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeMoodIgnoresNegatedKeywords(t *testing.T) {
+	tests := []struct {
+		description string
+		wantMood    string
+	}{
+		{"not sad", "neutral"},
+		{"I don't feel energetic", "neutral"},
+		{"never calm", "neutral"},
+		{"I am not happy at all", "neutral"},
+		{"not sad, I am feeling tired", "relaxed"},
+		{"I don't feel energetic, I feel lonely", "sad"},
+	}
+
+	ma := NewMoodAnalyzer()
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			profile := ma.AnalyzeMood(tt.description)
+			if profile.Mood != tt.wantMood {
+				t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", tt.description, profile.Mood, tt.wantMood)
+			}
+		})
+	}
+}
+
+func TestIsNegated(t *testing.T) {
+	tokens := tokenize("i am not really that happy today")
+	if !isNegated(tokens, 5) {
+		t.Errorf("isNegated(%v, 5) = false, want true for a negation within %d words", tokens, negationWindow)
+	}
+
+	tokens = tokenize("not that i mind, i am happy")
+	if isNegated(tokens, 6) {
+		t.Errorf("isNegated(%v, 6) = true, want false for a negation further back than %d words", tokens, negationWindow)
+	}
+}
+
+func TestMatchesAnyWord(t *testing.T) {
+	tests := []struct {
+		text  string
+		terms []string
+		want  bool
+	}{
+		{"download my files", []string{"down"}, false},
+		{"heading downtown", []string{"down"}, false},
+		{"feeling down today", []string{"down"}, true},
+		{"an ungrateful crowd", []string{"great"}, false},
+		{"Great, thanks!", []string{"great"}, true},
+		{"lots of memories", []string{"memory", "memories"}, true},
+		{"good old days", []string{"good old days"}, true},
+		{"good days", []string{"good old days"}, false},
+		{"", []string{"sad"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesAnyWord(tt.text, tt.terms); got != tt.want {
+			t.Errorf("matchesAnyWord(%q, %q) = %v, want %v", tt.text, tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodMatchesWholeWords(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	for _, description := range []string{"download my files", "meet me downtown", "an ungrateful audience"} {
+		if profile := ma.AnalyzeMood(description); profile.Detected {
+			t.Errorf("AnalyzeMood(%q) detected %q from %v, want no mood", description, profile.Mood, profile.MatchedTerms)
+		}
+	}
+
+	if got := ma.AnalyzeMood("feeling down").Mood; got != "sad" {
+		t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", "feeling down", got, "sad")
+	}
+}
+
+func TestIntensityFactor(t *testing.T) {
+	tests := []struct {
+		description string
+		keyword     string
+		want        float32
+	}{
+		{"very happy", "happy", 1.3},
+		{"extremely pumped", "pumped", 1.6},
+		{"slightly sad", "sad", 0.5},
+		{"a bit sad", "sad", 0.6},
+		{"kind of tired", "tired", 0.6},
+		{"happy", "happy", 1},
+		{"very much happy", "happy", 1},
+	}
+
+	for _, tt := range tests {
+		tokens := tokenize(tt.description)
+		positions := findTerm(tokens, tt.keyword)
+		if got := intensityFactor(tokens, positions); got != tt.want {
+			t.Errorf("intensityFactor(%q) = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestScaleFromNeutral(t *testing.T) {
+	tests := []struct {
+		value, factor, want float32
+	}{
+		{0.8, 1.5, 0.95},
+		{0.9, 2, 1},
+		{0.1, 2, 0},
+		{0.2, 0.5, 0.35},
+		{0.5, 3, 0.5},
+	}
+
+	for _, tt := range tests {
+		if got := scaleFromNeutral(tt.value, tt.factor); !approxEqual(got, tt.want) {
+			t.Errorf("scaleFromNeutral(%v, %v) = %v, want %v", tt.value, tt.factor, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodIntensity(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	plain := ma.AnalyzeMood("energetic")
+	extreme := ma.AnalyzeMood("extremely energetic")
+	if extreme.Energy <= plain.Energy || extreme.Energy > 1 {
+		t.Errorf("energy of extremely energetic = %v, want above plain %v and at most 1", extreme.Energy, plain.Energy)
+	}
+
+	sad := ma.AnalyzeMood("sad")
+	slightly := ma.AnalyzeMood("slightly sad")
+	if slightly.Valence <= sad.Valence || slightly.Valence > 0.5 {
+		t.Errorf("valence of slightly sad = %v, want between sad %v and neutral", slightly.Valence, sad.Valence)
+	}
+}
+
+// approxEqual compares floats that went through float32 arithmetic
+func approxEqual(a, b float32) bool {
+	const epsilon = 1e-4
+	return a-b < epsilon && b-a < epsilon
+}
+
+func TestAnalyzeMoodAngry(t *testing.T) {
+	profile := NewMoodAnalyzer().AnalyzeMood("I'm so furious right now")
+	if profile.Mood != "angry" {
+		t.Fatalf("Mood = %q, want %q", profile.Mood, "angry")
+	}
+	if profile.Energy < 0.9 {
+		t.Errorf("Energy = %v, want at least 0.9", profile.Energy)
+	}
+	if profile.Valence > 0.2 {
+		t.Errorf("Valence = %v, want at most 0.2", profile.Valence)
+	}
+	if profile.Acousticness > 0.2 {
+		t.Errorf("Acousticness = %v, want low", profile.Acousticness)
+	}
+	if profile.SearchQueryTerms != "aggressive intense angry" {
+		t.Errorf("SearchQueryTerms = %q, want %q", profile.SearchQueryTerms, "aggressive intense angry")
+	}
+	for _, genre := range []string{"metal", "punk", "hard rock", "rap"} {
+		if !slices.Contains(profile.SuggestedGenres, genre) {
+			t.Errorf("SuggestedGenres = %v, want %q among them", profile.SuggestedGenres, genre)
+		}
+	}
+}
+
+func TestAnalyzeMoodAnxious(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	profile := ma.AnalyzeMood("I'm really stressed and overwhelmed")
+	if profile.Mood != "anxious" {
+		t.Fatalf("Mood = %q, want %q", profile.Mood, "anxious")
+	}
+	if profile.Energy >= 0.5 {
+		t.Errorf("Energy = %v, want moderate-low", profile.Energy)
+	}
+	// Anxious listeners get soothing music, so the valence leans positive
+	if profile.Valence <= 0.5 {
+		t.Errorf("Valence = %v, want slightly positive", profile.Valence)
+	}
+	if profile.Acousticness < 0.6 {
+		t.Errorf("Acousticness = %v, want high", profile.Acousticness)
+	}
+	if !slices.Contains(profile.SuggestedGenres, "ambient") {
+		t.Errorf("SuggestedGenres = %v, want calming genres", profile.SuggestedGenres)
+	}
+
+	for _, description := range []string{"nervous", "so worried", "a bit panicky"} {
+		if got := ma.AnalyzeMood(description).Mood; got != "anxious" {
+			t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", description, got, "anxious")
+		}
+	}
+}
+
+func TestAnalyzeMoodNostalgic(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	for _, description := range []string{"feeling nostalgic", "play some throwback songs", "reminiscing about old times"} {
+		profile := ma.AnalyzeMood(description)
+		if profile.Mood != "nostalgic" {
+			t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", description, profile.Mood, "nostalgic")
+		}
+		if profile.SearchQueryTerms != "throwback classics" {
+			t.Errorf("AnalyzeMood(%q).SearchQueryTerms = %q, want %q", description, profile.SearchQueryTerms, "throwback classics")
+		}
+	}
+}
+
+func TestExtractDecade(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"nostalgic 90s vibes", "1990s"},
+		{"some '80s throwbacks", "1980s"},
+		{"2000s pop", "2000s"},
+		{"10s hits", "2010s"},
+		{"1970s rock", "1970s"},
+		{"95s", ""},
+		{"1850s", ""},
+		{"no decade here", ""},
+	}
+
+	for _, tt := range tests {
+		if got := extractDecade(tokenize(tt.description)); got != tt.want {
+			t.Errorf("extractDecade(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodDetected(t *testing.T) {
+	tests := []struct {
+		description string
+		want        bool
+	}{
+		{"", false},
+		{"   ", false},
+		{"?!... ,;", false},
+		{"what's the weather", false},
+		{"I feel happy", true},
+	}
+
+	ma := NewMoodAnalyzer()
+	for _, tt := range tests {
+		profile := ma.AnalyzeMood(tt.description)
+		if profile.Detected != tt.want {
+			t.Errorf("AnalyzeMood(%q).Detected = %v, want %v", tt.description, profile.Detected, tt.want)
+		}
+		if !tt.want && profile.Mood != "neutral" {
+			t.Errorf("AnalyzeMood(%q).Mood = %q, want the neutral fallback", tt.description, profile.Mood)
+		}
+	}
+}
+
+func TestAnalyzeMoodEmoji(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"feeling 😭 today", "sad"},
+		{"😡😡", "angry"},
+		{"🥰", "romantic"},
+		{"😌", "relaxed"},
+		{"🎉 tonight", "happy"},
+		{"gym time 💪🏽", "energetic"},
+		{"🏋🏿‍♀️", "energetic"},
+		{"love you ❤️", "romantic"},
+		{"🧘🏻‍♀️", "relaxed"},
+	}
+
+	ma := NewMoodAnalyzer()
+	for _, tt := range tests {
+		profile := ma.AnalyzeMood(tt.description)
+		if profile.Mood != tt.want {
+			t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", tt.description, profile.Mood, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodCombinesEmojiAndWords(t *testing.T) {
+	// Two sad signals outweigh one happy word
+	profile := NewMoodAnalyzer().AnalyzeMood("happy on the outside 😭 lonely")
+	if profile.Mood != "sad" {
+		t.Errorf("Mood = %q, want %q", profile.Mood, "sad")
+	}
+	if !slices.Contains(profile.MatchedTerms, "😭") {
+		t.Errorf("MatchedTerms = %v, want the emoji among them", profile.MatchedTerms)
+	}
+}
+
+func TestAnalyzeMoodBlendedAverages(t *testing.T) {
+	ma := NewMoodAnalyzer()
+
+	// Equal weights: the plain average of happy and relaxed
+	profile := ma.AnalyzeMoodBlended("tired but happy")
+	if !approxEqual(profile.Energy, 0.5) || !approxEqual(profile.Valence, 0.65) ||
+		!approxEqual(profile.Danceability, 0.5) || !approxEqual(profile.Acousticness, 0.55) {
+		t.Errorf("blended targets = energy %v, valence %v, danceability %v, acousticness %v; want 0.5, 0.65, 0.5, 0.55",
+			profile.Energy, profile.Valence, profile.Danceability, profile.Acousticness)
+	}
+
+	// Three sad keywords against one relaxed one weigh sad three times as much
+	profile = ma.AnalyzeMoodBlended("sad, lonely and heartbroken but calm")
+	if profile.Mood != "sad" {
+		t.Errorf("Mood = %q, want the strongest category %q", profile.Mood, "sad")
+	}
+	if want := float32(3*0.3+0.2) / 4; !approxEqual(profile.Energy, want) {
+		t.Errorf("Energy = %v, want %v", profile.Energy, want)
+	}
+}
+
+func TestAnalyzeMoodBlendedMergesGenres(t *testing.T) {
+	profile := NewMoodAnalyzer().AnalyzeMoodBlended("calm but lonely")
+	want := []string{"indie", "folk", "soul", "acoustic", "ambient", "lo-fi", "jazz"}
+	if !slices.Equal(profile.SuggestedGenres, want) {
+		t.Errorf("SuggestedGenres = %v, want %v", profile.SuggestedGenres, want)
+	}
+}
+
+func TestAnalyzeMoodBlendedSingleMood(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	blended := ma.AnalyzeMoodBlended("happy")
+	plain := ma.AnalyzeMood("happy")
+	if blended.Energy != plain.Energy || blended.Valence != plain.Valence || !slices.Equal(blended.SuggestedGenres, plain.SuggestedGenres) {
+		t.Errorf("AnalyzeMoodBlended(%q) = %+v, want the same targets as AnalyzeMood %+v", "happy", blended, plain)
+	}
+}
+
+func TestAnalyzeMoodAmbiguous(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"I'm happy but also kind of sad", true},
+		{"excited and heartbroken", true},
+		{"happy and energetic", false},
+		{"sad and lonely", false},
+		{"calm", false},
+	}
+
+	for _, tt := range tests {
+		if got := ma.AnalyzeMood(tt.text).Ambiguous; got != tt.want {
+			t.Errorf("AnalyzeMood(%q).Ambiguous = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodBlendedBittersweet(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	happy, sad := ma.AnalyzeMood("happy"), ma.AnalyzeMood("sad")
+
+	profile := ma.AnalyzeMoodBlended("I'm happy but also kind of sad")
+	if profile.Mood != BittersweetMood || !profile.Ambiguous || !profile.Detected {
+		t.Errorf("AnalyzeMoodBlended() = %q, ambiguous %v, detected %v, want %q, true, true",
+			profile.Mood, profile.Ambiguous, profile.Detected, BittersweetMood)
+	}
+	if profile.Valence <= sad.Valence || profile.Valence >= happy.Valence {
+		t.Errorf("blended valence = %.2f, want it between sad %.2f and happy %.2f", profile.Valence, sad.Valence, happy.Valence)
+	}
+	if Confidence(profile) >= Confidence(ma.AnalyzeMoodBlended("happy and cheerful")) {
+		t.Errorf("Confidence(bittersweet) = %.2f, want it below an unmixed mood with as many terms", Confidence(profile))
+	}
+}
+
+func TestAnalyzeMoodMatchedTerms(t *testing.T) {
+	profile := NewMoodAnalyzer().AnalyzeMood("I'm heartbroken and lonely, not happy")
+	want := []string{"lonely", "heartbroken"}
+	if !slices.Equal(profile.MatchedTerms, want) {
+		t.Errorf("MatchedTerms = %v, want %v", profile.MatchedTerms, want)
+	}
+}
+
+func TestFormatMatchedTerms(t *testing.T) {
+	tests := []struct {
+		terms []string
+		want  string
+	}{
+		{nil, ""},
+		{[]string{"sad"}, "I picked up on 'sad'."},
+		{[]string{"heartbroken", "lonely"}, "I picked up on 'heartbroken' and 'lonely'."},
+		{[]string{"sad", "lonely", "😭"}, "I picked up on 'sad', 'lonely' and '😭'."},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMatchedTerms(tt.terms); got != tt.want {
+			t.Errorf("FormatMatchedTerms(%q) = %q, want %q", tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestGetMoodParametersTempo(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	tests := []struct {
+		description string
+		want        float32
+	}{
+		{"energetic", 150},
+		{"relaxed", 70},
+	}
+
+	for _, tt := range tests {
+		params := ma.GetMoodParameters(ma.AnalyzeMood(tt.description))
+		if got := params["target_tempo"]; got != tt.want {
+			t.Errorf("target_tempo for %q = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+
+	if params := ma.GetMoodParameters(ma.AnalyzeMood("")); params["target_tempo"] != nil {
+		t.Errorf("target_tempo for the neutral profile = %v, want it unset", params["target_tempo"])
+	}
+}
+
+func TestGetMoodParametersOptionalTargets(t *testing.T) {
+	ma := NewMoodAnalyzer()
+
+	focus := ma.GetMoodParameters(ma.AnalyzeMood("focused"))
+	if got := focus["target_instrumentalness"]; got != float32(0.9) {
+		t.Errorf("focus target_instrumentalness = %v, want 0.9", got)
+	}
+	if got := focus["target_speechiness"]; got != float32(0.03) {
+		t.Errorf("focus target_speechiness = %v, want 0.03", got)
+	}
+	if _, ok := focus["target_mode"]; ok {
+		t.Errorf("focus target_mode = %v, want it unset", focus["target_mode"])
+	}
+
+	sad := ma.GetMoodParameters(ma.AnalyzeMood("sad"))
+	if got := sad["target_mode"]; got != 0 {
+		t.Errorf("sad target_mode = %v, want 0 (minor)", got)
+	}
+	for _, key := range []string{"target_instrumentalness", "target_speechiness"} {
+		if _, ok := sad[key]; ok {
+			t.Errorf("sad %s = %v, want it unset", key, sad[key])
+		}
+	}
+
+	if got := ma.GetMoodParameters(ma.AnalyzeMood("happy"))["target_mode"]; got != 1 {
+		t.Errorf("happy target_mode = %v, want 1 (major)", got)
+	}
+}
+
+func TestGetMoodParametersBounds(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	tests := []struct {
+		description string
+		want        map[string]float32 // bounds expected; the others must be unset
+	}{
+		{"sad", map[string]float32{"max_valence": 0.45}},
+		{"happy", map[string]float32{"min_valence": 0.5}},
+		{"relaxed", map[string]float32{"max_energy": 0.5}},
+		{"angry", map[string]float32{"min_energy": 0.6, "max_valence": 0.5}},
+	}
+
+	for _, tt := range tests {
+		params := ma.GetMoodParameters(ma.AnalyzeMood(tt.description))
+		for _, key := range []string{"min_energy", "max_energy", "min_valence", "max_valence"} {
+			want, bounded := tt.want[key]
+			got, ok := params[key]
+			switch {
+			case bounded && got != want:
+				t.Errorf("%s %s = %v, want %v", tt.description, key, got, want)
+			case !bounded && ok:
+				t.Errorf("%s %s = %v, want it unset", tt.description, key, got)
+			}
+		}
+	}
+}
+
+func TestSetBoundsIncludesTarget(t *testing.T) {
+	params := map[string]interface{}{}
+
+	// An intensity word pushed the target past the mood's ceiling
+	setBounds(params, "valence", 0.6, 0, 0.45)
+	if got := params["max_valence"]; got != float32(0.6) {
+		t.Errorf("max_valence = %v, want it widened to the target 0.6", got)
+	}
+	setBounds(params, "energy", 0.4, 0.6, 0)
+	if got := params["min_energy"]; got != float32(0.4) {
+		t.Errorf("min_energy = %v, want it widened to the target 0.4", got)
+	}
+	if _, ok := params["max_energy"]; ok {
+		t.Errorf("max_energy = %v, want it unset", params["max_energy"])
+	}
+}
+
+func TestAnalyzeMoodBlendedDropsBounds(t *testing.T) {
+	params := NewMoodAnalyzer().GetMoodParameters(NewMoodAnalyzer().AnalyzeMoodBlended("sad but calm"))
+	for _, key := range []string{"min_energy", "max_energy", "min_valence", "max_valence"} {
+		if _, ok := params[key]; ok {
+			t.Errorf("blended %s = %v, want no bounds from one of the moods", key, params[key])
+		}
+	}
+}
+
+func TestAnalyzeMoodPolarity(t *testing.T) {
+	tests := []struct {
+		description string
+		want        float32
+	}{
+		{"happy and joyful", 1},
+		{"in love", 1},
+		{"sad and lonely", -1},
+		{"furious", -1},
+		{"stressed", -1},
+		{"calm", 0},
+		{"", 0},
+		{"happy but sad", 0},
+		{"happy and joyful but sad", 1.0 / 3},
+		{"sad and lonely but calm", -2.0 / 3},
+	}
+
+	ma := NewMoodAnalyzer()
+	for _, tt := range tests {
+		if got := ma.AnalyzeMood(tt.description).Polarity; !approxEqual(got, tt.want) {
+			t.Errorf("AnalyzeMood(%q).Polarity = %v, want %v", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTrackRecommendation(t *testing.T) {
+	tests := []struct {
+		name    string
+		artists []string
+		want    string
+	}{
+		{"Under Pressure", []string{"Queen", "David Bowie"}, "🎵 Under Pressure by Queen, David Bowie\n   🔗 https://open.spotify.com/track/1"},
+		{"Solo", []string{"Frank Ocean"}, "🎵 Solo by Frank Ocean\n   🔗 https://open.spotify.com/track/1"},
+		{"Unknown Song", nil, "🎵 Unknown Song by Unknown\n   🔗 https://open.spotify.com/track/1"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTrackRecommendation(tt.name, tt.artists, 0, "https://open.spotify.com/track/1", ""); got != tt.want {
+			t.Errorf("FormatTrackRecommendation(%q, %q) = %q, want %q", tt.name, tt.artists, got, tt.want)
+		}
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		ms   int
+		want string
+	}{
+		{222000, "3:42"},
+		{59999, "0:59"},
+		{60000, "1:00"},
+		{605000, "10:05"},
+		{0, "0:00"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDuration(tt.ms); got != tt.want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", tt.ms, got, tt.want)
+		}
+	}
+}
+
+func TestFormatTrackRecommendationDuration(t *testing.T) {
+	got := FormatTrackRecommendation("Everlong", []string{"Foo Fighters"}, 250546, "https://open.spotify.com/track/1", "")
+	want := "🎵 Everlong (4:10) by Foo Fighters\n   🔗 https://open.spotify.com/track/1"
+	if got != want {
+		t.Errorf("FormatTrackRecommendation() = %q, want %q", got, want)
+	}
+}
+
+func TestFormatTrackRecommendationPreview(t *testing.T) {
+	tests := []struct {
+		previewURL string
+		want       string
+	}{
+		{"https://p.scdn.co/mp3-preview/1", "🎵 Everlong by Foo Fighters\n   🔗 https://open.spotify.com/track/1\n   ▶ preview: https://p.scdn.co/mp3-preview/1"},
+		{"", "🎵 Everlong by Foo Fighters\n   🔗 https://open.spotify.com/track/1"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatTrackRecommendation("Everlong", []string{"Foo Fighters"}, 0, "https://open.spotify.com/track/1", tt.previewURL); got != tt.want {
+			t.Errorf("FormatTrackRecommendation(preview %q) = %q, want %q", tt.previewURL, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIntensitySummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile MoodProfile
+		want    string
+	}{
+		{"strong energy leads", MoodProfile{Detected: true, Energy: 0.9, Valence: 0.75}, "You sound strongly energetic (90% energy) and upbeat (75% positivity)."},
+		{"stronger positivity leads", MoodProfile{Detected: true, Energy: 0.35, Valence: 0.1}, "You sound strongly down (10% positivity) and a little mellow (35% energy)."},
+		{"neutral energy left out", MoodProfile{Detected: true, Energy: 0.55, Valence: 0.2}, "You sound down (20% positivity)."},
+		{"nothing stands out", MoodProfile{Detected: true, Energy: 0.5, Valence: 0.45}, ""},
+		{"not detected", MoodProfile{Energy: 0.9, Valence: 0.9}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := FormatIntensitySummary(tt.profile); got != tt.want {
+			t.Errorf("%s: FormatIntensitySummary() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIntensitySummaryLocalized(t *testing.T) {
+	profile := MoodProfile{Detected: true, Energy: 0.9, Valence: 0.75}
+	tests := []struct {
+		locale Locale
+		want   string
+	}{
+		{LocaleSpanish, "Te noto muy enérgico (energía al 90%) y animado (positividad al 75%)."},
+		{LocaleFrench, "Tu as l'air très énergique (énergie à 90 %) et enjoué (positivité à 75 %)."},
+	}
+
+	for _, tt := range tests {
+		if got := FormatLocalizedIntensitySummary(profile, tt.locale); got != tt.want {
+			t.Errorf("FormatLocalizedIntensitySummary(%s) = %q, want %q", tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodParty(t *testing.T) {
+	analyzer := NewMoodAnalyzer()
+
+	for _, text := range []string{"let's celebrate", "festive night out", "turnt tonight", "dancing with friends"} {
+		profile := analyzer.AnalyzeMood(text)
+		if profile.Mood != "party" {
+			t.Errorf("AnalyzeMood(%q).Mood = %q, want party", text, profile.Mood)
+			continue
+		}
+		if profile.SearchQueryTerms != "party dance hits" {
+			t.Errorf("AnalyzeMood(%q).SearchQueryTerms = %q, want %q", text, profile.SearchQueryTerms, "party dance hits")
+		}
+
+		params := analyzer.GetMoodParameters(profile)
+		if danceability, ok := params["target_danceability"].(float32); !ok || danceability < 0.85 {
+			t.Errorf("AnalyzeMood(%q) target_danceability = %v, want at least 0.85", text, params["target_danceability"])
+		}
+		if energy, ok := params["target_energy"].(float32); !ok || energy < 0.8 {
+			t.Errorf("AnalyzeMood(%q) target_energy = %v, want at least 0.8", text, params["target_energy"])
+		}
+	}
+}
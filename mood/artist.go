@@ -0,0 +1,71 @@
+package mood
+
+import (
+	"regexp"
+	"strings"
+)
+
+// similarArtistPattern finds "like <artist>" and "similar to <artist>", taking
+// the word before and the artist name up to the end of the clause
+var similarArtistPattern = regexp.MustCompile(`(?i)(\w+'?\w*\s+)?\b(like|similar to)\s+([^,.!?;]+)`)
+
+// musicNouns may lead "like <artist>" along with mood words, genres and decades,
+// as in "sad songs like Adele". Any other word before "like", such as "I",
+// "really" or "don't", means it's a verb or comparison rather than a request
+// for an artist.
+var musicNouns = map[string]bool{
+	"songs": true, "song": true, "music": true, "tracks": true, "track": true,
+	"tunes": true, "vibes": true, "stuff": true, "something": true, "playlist": true,
+}
+
+// artistPhraseStops end an artist name, e.g. "adele but more upbeat"
+var artistPhraseStops = map[string]bool{
+	"but": true, "and": true, "please": true, "for": true, "with": true,
+	"because": true, "so": true, "or": true, "while": true, "when": true,
+	"-": true, // a dash between words, as NormalizeText leaves em dashes
+}
+
+// notArtists are what "like" refers to when it points at something else, as in
+// "more like this" after a pasted track link
+var notArtists = map[string]bool{
+	"this": true, "that": true, "these": true, "those": true, "it": true,
+	"this one": true, "this song": true, "this track": true, "that one": true, "that song": true,
+}
+
+// extractSimilarArtist returns the artist named in "<mood> like <artist>" or
+// "similar to <artist>" in the description, or an empty string if there is
+// none. terms are the mood words and genres found in the description.
+func extractSimilarArtist(description string, terms []string) string {
+	for _, match := range similarArtistPattern.FindAllStringSubmatch(description, -1) {
+		if strings.EqualFold(match[2], "like") && !leadsLike(strings.ToLower(strings.TrimSpace(match[1])), terms) {
+			continue
+		}
+
+		var name []string
+		for _, word := range strings.Fields(match[3]) {
+			if artistPhraseStops[strings.ToLower(word)] {
+				break
+			}
+			name = append(name, word)
+		}
+		if len(name) > 0 && !notArtists[strings.ToLower(strings.Join(name, " "))] {
+			return strings.Join(name, " ")
+		}
+	}
+	return ""
+}
+
+// leadsLike reports whether word, the word before "like", makes it ask for
+// music like an artist: one of terms, a decade or a noun such as "songs"
+func leadsLike(word string, terms []string) bool {
+	if musicNouns[word] || extractDecade([]string{word}) != "" {
+		return true
+	}
+	for _, term := range terms {
+		words := tokenize(term)
+		if len(words) > 0 && wordMatches(word, words[len(words)-1]) {
+			return true
+		}
+	}
+	return false
+}
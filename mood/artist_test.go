@@ -0,0 +1,51 @@
+package mood
+
+import "testing"
+
+func TestExtractSimilarArtist(t *testing.T) {
+	tests := []struct {
+		description string
+		terms       []string
+		want        string
+	}{
+		{"sad like Adele", []string{"sad"}, "Adele"},
+		{"something similar to Daft Punk, please", nil, "Daft Punk"},
+		{"chill like Bon Iver but more upbeat", []string{"chill"}, "Bon Iver"},
+		{"happy songs like the beatles and queen", []string{"happy"}, "the beatles"},
+		{"I feel like dancing", nil, ""},
+		{"feeling like crying", nil, ""},
+		{"just sad", []string{"sad"}, ""},
+		{"mellow jazz like Norah Jones", []string{"jazz"}, "Norah Jones"},
+		{"rock from the 90s like Blur", nil, "Blur"},
+		{"more like this", nil, ""},
+		{"sad songs like this one", []string{"sad"}, ""},
+		{"I like Adele", nil, ""},
+		{"we like to party", nil, ""},
+		{"you like jazz", nil, ""},
+		{"happy but I don't like mornings", []string{"happy"}, ""},
+		{"I really like sad songs", []string{"sad"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := extractSimilarArtist(tt.description, tt.terms); got != tt.want {
+			t.Errorf("extractSimilarArtist(%q, %q) = %q, want %q", tt.description, tt.terms, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodSimilarArtist(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	profile := ma.AnalyzeMood("sad like Adele")
+	if profile.Mood != "sad" || profile.SimilarArtist != "Adele" {
+		t.Errorf("AnalyzeMood() = %q like %q, want sad like Adele", profile.Mood, profile.SimilarArtist)
+	}
+
+	for _, description := range []string{"chill like Bon Iver", "calm songs like Bon Iver"} {
+		if got := ma.AnalyzeMood(description).SimilarArtist; got != "Bon Iver" {
+			t.Errorf("AnalyzeMood(%q).SimilarArtist = %q, want %q", description, got, "Bon Iver")
+		}
+	}
+	if got := ma.AnalyzeMood("happy and I really like Adele").SimilarArtist; got != "" {
+		t.Errorf("AnalyzeMood().SimilarArtist = %q, want none when the user just likes an artist", got)
+	}
+}
@@ -0,0 +1,29 @@
+package mood
+
+// AudioFeatures are the measured audio features of a track or the average of several
+type AudioFeatures struct {
+	Energy       float32
+	Danceability float32
+	Valence      float32
+	Acousticness float32
+	Tempo        float32
+}
+
+// BlendAudioFeatures mixes measured audio features into the targets of a profile.
+// weight is the share of the features in [0, 1]; 0 keeps the profile unchanged
+// and 1 uses the features alone. Unset targets such as a zero tempo stay unset.
+func BlendAudioFeatures(profile MoodProfile, features AudioFeatures, weight float32) MoodProfile {
+	weight = clamp01(weight)
+	mix := func(target, feature float32) float32 {
+		return target*(1-weight) + feature*weight
+	}
+
+	profile.Energy = mix(profile.Energy, features.Energy)
+	profile.Danceability = mix(profile.Danceability, features.Danceability)
+	profile.Valence = mix(profile.Valence, features.Valence)
+	profile.Acousticness = mix(profile.Acousticness, features.Acousticness)
+	if profile.Tempo > 0 && features.Tempo > 0 {
+		profile.Tempo = mix(profile.Tempo, features.Tempo)
+	}
+	return profile
+}
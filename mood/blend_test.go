@@ -0,0 +1,54 @@
+package mood
+
+import (
+	"testing"
+)
+
+func TestBlendAudioFeatures(t *testing.T) {
+	profile := MoodProfile{Mood: "happy", Energy: 0.8, Danceability: 0.6, Valence: 1, Acousticness: 0.2, Tempo: 120}
+	features := AudioFeatures{Energy: 0.4, Danceability: 0.2, Valence: 0, Acousticness: 0.6, Tempo: 80}
+
+	tests := []struct {
+		weight                                         float32
+		energy, danceability, valence, acoustic, tempo float32
+	}{
+		{0, 0.8, 0.6, 1, 0.2, 120},
+		{0.3, 0.68, 0.48, 0.7, 0.32, 108},
+		{0.5, 0.6, 0.4, 0.5, 0.4, 100},
+		{1, 0.4, 0.2, 0, 0.6, 80},
+		// Out of range weights are clamped
+		{-1, 0.8, 0.6, 1, 0.2, 120},
+		{2, 0.4, 0.2, 0, 0.6, 80},
+	}
+	for _, tt := range tests {
+		got := BlendAudioFeatures(profile, features, tt.weight)
+		for _, f := range []struct {
+			name      string
+			got, want float32
+		}{
+			{"Energy", got.Energy, tt.energy},
+			{"Danceability", got.Danceability, tt.danceability},
+			{"Valence", got.Valence, tt.valence},
+			{"Acousticness", got.Acousticness, tt.acoustic},
+			{"Tempo", got.Tempo, tt.tempo},
+		} {
+			if !approxEqual(f.got, f.want) {
+				t.Errorf("BlendAudioFeatures(weight %v).%s = %v, want %v", tt.weight, f.name, f.got, f.want)
+			}
+		}
+		if got.Mood != "happy" {
+			t.Errorf("BlendAudioFeatures(weight %v).Mood = %q, want it kept", tt.weight, got.Mood)
+		}
+	}
+}
+
+func TestBlendAudioFeaturesKeepsUnsetTempo(t *testing.T) {
+	got := BlendAudioFeatures(MoodProfile{Energy: 0.5}, AudioFeatures{Tempo: 90}, 0.5)
+	if got.Tempo != 0 {
+		t.Errorf("Tempo = %v, want it left unset", got.Tempo)
+	}
+	got = BlendAudioFeatures(MoodProfile{Tempo: 100}, AudioFeatures{}, 0.5)
+	if got.Tempo != 100 {
+		t.Errorf("Tempo = %v, want 100 when the features have none", got.Tempo)
+	}
+}
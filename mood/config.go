@@ -0,0 +1,88 @@
+package mood
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// categoryConfig is the JSON layout of a mood categories file
+type categoryConfig struct {
+	Categories []MoodCategory `json:"categories"`
+}
+
+// NewMoodAnalyzer creates a mood analyzer using the built-in mood categories
+func NewMoodAnalyzer() *MoodAnalyzer {
+	return &MoodAnalyzer{categories: defaultCategories}
+}
+
+// NewMoodAnalyzerFromFile creates a mood analyzer with the categories defined in a JSON file.
+// An empty path falls back to the built-in categories.
+func NewMoodAnalyzerFromFile(path string) (*MoodAnalyzer, error) {
+	if path == "" {
+		return NewMoodAnalyzer(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mood categories file: %w", err)
+	}
+
+	var config categoryConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode mood categories file: %w", err)
+	}
+
+	if len(config.Categories) == 0 {
+		return nil, fmt.Errorf("mood categories file %s defines no categories", path)
+	}
+
+	for i, category := range config.Categories {
+		if category.Mood == "" {
+			return nil, fmt.Errorf("mood category %d has no mood name", i)
+		}
+		if len(category.Keywords) == 0 {
+			return nil, fmt.Errorf("mood category %q has no keywords", category.Mood)
+		}
+	}
+
+	return &MoodAnalyzer{categories: config.Categories}, nil
+}
+
+// moodCategories returns the categories used for detection, defaulting to the built-in ones
+func (ma *MoodAnalyzer) moodCategories() []MoodCategory {
+	if len(ma.categories) == 0 {
+		return defaultCategories
+	}
+	return ma.categories
+}
+
+// RegisterLanguage adds keywords in another language, keyed by mood category name.
+// AnalyzeMood consults the keywords of every registered language alongside the
+// category's own keywords. Registering the same language again replaces its keywords.
+func (ma *MoodAnalyzer) RegisterLanguage(lang string, categories map[string][]string) {
+	if ma.languages == nil {
+		ma.languages = make(map[string]map[string][]string)
+	}
+	ma.languages[lang] = categories
+}
+
+// keywordsFor returns the keywords of a category plus those of every registered language
+func (ma *MoodAnalyzer) keywordsFor(category MoodCategory) []string {
+	if len(ma.languages) == 0 {
+		return category.Keywords
+	}
+
+	langs := make([]string, 0, len(ma.languages))
+	for lang := range ma.languages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+
+	keywords := append([]string{}, category.Keywords...)
+	for _, lang := range langs {
+		keywords = append(keywords, ma.languages[lang][category.Mood]...)
+	}
+	return keywords
+}
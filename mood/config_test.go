@@ -0,0 +1,116 @@
+package mood
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeCategoriesFile writes a mood categories file to a temporary directory and returns its path
+func writeCategoriesFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "categories.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestNewMoodAnalyzerFromFile(t *testing.T) {
+	path := writeCategoriesFile(t, `{
+		"categories": [{
+			"mood": "cozy",
+			"keywords": ["cozy", "snug", "blanket"],
+			"energy": 0.25,
+			"valence": 0.7,
+			"tempo": 80,
+			"suggested_genres": ["folk", "acoustic"],
+			"search_query_terms": "cozy warm acoustic"
+		}]
+	}`)
+
+	ma, err := NewMoodAnalyzerFromFile(path)
+	if err != nil {
+		t.Fatalf("NewMoodAnalyzerFromFile: %v", err)
+	}
+
+	profile := ma.AnalyzeMood("under a blanket feeling snug")
+	if profile.Mood != "cozy" || !profile.Detected {
+		t.Fatalf("Mood = %q (detected %v), want the custom %q", profile.Mood, profile.Detected, "cozy")
+	}
+	if profile.Energy != 0.25 || profile.Tempo != 80 || profile.SearchQueryTerms != "cozy warm acoustic" {
+		t.Errorf("profile = %+v, want the targets from the file", profile)
+	}
+
+	// The file replaces the built-in categories
+	if profile := ma.AnalyzeMood("happy"); profile.Detected {
+		t.Errorf("AnalyzeMood(%q) detected %q, want only the file's categories", "happy", profile.Mood)
+	}
+}
+
+func TestNewMoodAnalyzerFromFileEmptyPath(t *testing.T) {
+	ma, err := NewMoodAnalyzerFromFile("")
+	if err != nil {
+		t.Fatalf("NewMoodAnalyzerFromFile(\"\"): %v", err)
+	}
+	if got := ma.AnalyzeMood("happy").Mood; got != "happy" {
+		t.Errorf("Mood = %q, want the built-in %q", got, "happy")
+	}
+}
+
+func TestNewMoodAnalyzerFromFileErrors(t *testing.T) {
+	tests := map[string]string{
+		"invalid json":  `{"categories": [`,
+		"no categories": `{"categories": []}`,
+		"no mood name":  `{"categories": [{"keywords": ["x"]}]}`,
+		"no keywords":   `{"categories": [{"mood": "x"}]}`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewMoodAnalyzerFromFile(writeCategoriesFile(t, content)); err == nil {
+				t.Error("NewMoodAnalyzerFromFile succeeded, want an error")
+			}
+		})
+	}
+
+	if _, err := NewMoodAnalyzerFromFile(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("NewMoodAnalyzerFromFile succeeded for a missing file, want an error")
+	}
+}
+
+func TestRegisterLanguage(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	ma.RegisterLanguage("es", SpanishKeywords)
+	ma.RegisterLanguage("fr", FrenchKeywords)
+
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"estoy triste", "sad"},
+		{"estoy muy feliz", "happy"},
+		{"je suis content", "happy"},
+		{"je suis triste et seul", "sad"},
+		{"je ne suis pas content", "neutral"},
+	}
+
+	for _, tt := range tests {
+		if got := ma.AnalyzeMood(tt.description).Mood; got != tt.want {
+			t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestRegisterLanguageReplaces(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	ma.RegisterLanguage("es", map[string][]string{"sad": {"triste"}})
+	ma.RegisterLanguage("es", map[string][]string{"happy": {"feliz"}})
+
+	if profile := ma.AnalyzeMood("triste"); profile.Detected {
+		t.Errorf("AnalyzeMood(%q) detected %q after its language was replaced", "triste", profile.Mood)
+	}
+	if got := ma.AnalyzeMood("feliz").Mood; got != "happy" {
+		t.Errorf("AnalyzeMood(%q).Mood = %q, want %q", "feliz", got, "happy")
+	}
+}
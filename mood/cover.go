@@ -0,0 +1,56 @@
+package mood
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+)
+
+// coverSize is the width and height of generated playlist covers in pixels
+const coverSize = 300
+
+// CoverImage renders a JPEG playlist cover for a mood profile: a vertical
+// gradient that is warmer for positive moods and brighter for energetic ones
+func CoverImage(profile MoodProfile) ([]byte, error) {
+	top := color.RGBA{
+		R: uint8(60 + 195*clamp01(profile.Valence)),
+		G: uint8(40 + 140*clamp01(profile.Energy)),
+		B: uint8(60 + 195*(1-clamp01(profile.Valence))),
+		A: 255,
+	}
+	bottom := shade(top, 0.3+0.4*clamp01(profile.Energy))
+
+	img := image.NewRGBA(image.Rect(0, 0, coverSize, coverSize))
+	for y := 0; y < coverSize; y++ {
+		c := mix(top, bottom, float32(y)/float32(coverSize-1))
+		for x := 0; x < coverSize; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("failed to encode cover image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// shade darkens a color by factor, where 1 keeps it as is
+func shade(c color.RGBA, factor float32) color.RGBA {
+	return color.RGBA{
+		R: uint8(float32(c.R) * factor),
+		G: uint8(float32(c.G) * factor),
+		B: uint8(float32(c.B) * factor),
+		A: c.A,
+	}
+}
+
+// mix blends from a to b, t in [0, 1]
+func mix(a, b color.RGBA, t float32) color.RGBA {
+	blend := func(x, y uint8) uint8 {
+		return uint8(float32(x) + (float32(y)-float32(x))*t)
+	}
+	return color.RGBA{R: blend(a.R, b.R), G: blend(a.G, b.G), B: blend(a.B, b.B), A: 255}
+}
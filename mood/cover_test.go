@@ -0,0 +1,36 @@
+package mood
+
+import (
+	"bytes"
+	"image/jpeg"
+	"testing"
+)
+
+func TestCoverImage(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	happy, err := CoverImage(ma.AnalyzeMood("happy"))
+	if err != nil {
+		t.Fatalf("CoverImage(happy) error = %v", err)
+	}
+	sad, err := CoverImage(ma.AnalyzeMood("sad"))
+	if err != nil {
+		t.Fatalf("CoverImage(sad) error = %v", err)
+	}
+
+	img, err := jpeg.Decode(bytes.NewReader(happy))
+	if err != nil {
+		t.Fatalf("CoverImage() isn't a valid JPEG: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != coverSize || b.Dy() != coverSize {
+		t.Errorf("cover is %dx%d, want %dx%d", b.Dx(), b.Dy(), coverSize, coverSize)
+	}
+	if bytes.Equal(happy, sad) {
+		t.Error("happy and sad covers are the same, want them to differ")
+	}
+
+	// Warmer for positive moods: more red than blue at the top
+	r, _, b, _ := img.At(coverSize/2, 0).RGBA()
+	if r <= b {
+		t.Errorf("happy cover top is r=%d b=%d, want it warm", r>>8, b>>8)
+	}
+}
@@ -0,0 +1,169 @@
+package mood
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// OutputFormat selects how recommendations are rendered
+type OutputFormat string
+
+const (
+	// FormatPlain renders a numbered list with emoji and links on their own lines
+	FormatPlain OutputFormat = "plain"
+	// FormatMarkdown renders a numbered markdown list with [name](url) links
+	FormatMarkdown OutputFormat = "markdown"
+	// FormatMinimal renders one "name - artists url" line per track without a header
+	FormatMinimal OutputFormat = "minimal"
+	// FormatJSON renders a machine-readable payload, see MarshalRecommendations
+	FormatJSON OutputFormat = "json"
+)
+
+// ParseOutputFormat returns the output format with the given name
+func ParseOutputFormat(name string) (OutputFormat, bool) {
+	switch format := OutputFormat(strings.ToLower(name)); format {
+	case FormatPlain, FormatMarkdown, FormatMinimal, FormatJSON:
+		return format, true
+	}
+	return "", false
+}
+
+// FormatRecommendations renders the full recommendation response for a mood profile
+func FormatRecommendations(tracks []TrackSummary, profile MoodProfile, format OutputFormat) string {
+	var sb strings.Builder
+	WriteRecommendations(&sb, tracks, profile, format) // writing to a strings.Builder can't fail
+	return sb.String()
+}
+
+// WriteRecommendations writes the full recommendation response for a mood
+// profile to w a track at a time, so long lists can be streamed. It returns
+// the first error from w.
+func WriteRecommendations(w io.Writer, tracks []TrackSummary, profile MoodProfile, format OutputFormat) error {
+	return WriteLocalizedRecommendations(w, tracks, profile, format, DefaultLocale)
+}
+
+// WriteLocalizedRecommendations is WriteRecommendations with the header in the
+// language of the locale
+func WriteLocalizedRecommendations(w io.Writer, tracks []TrackSummary, profile MoodProfile, format OutputFormat, locale Locale) error {
+	msgs := MessagesFor(locale)
+	ew := &errWriter{w: w}
+	if format != FormatMinimal {
+		if matched := formatMatchedTerms(profile.MatchedTerms, msgs); matched != "" {
+			ew.printf("%s ", matched)
+		}
+		moodName := profile.Mood
+		if format == FormatMarkdown {
+			moodName = "**" + moodName + "**"
+		}
+		ew.printf(msgs.RecommendationsHeader+"\n\n", moodName)
+	}
+	if ew.err != nil {
+		return ew.err
+	}
+	return WriteLocalizedTrackList(w, tracks, format, locale)
+}
+
+// FormatTrackList renders the list of tracks without any header
+func FormatTrackList(tracks []TrackSummary, format OutputFormat) string {
+	var sb strings.Builder
+	WriteTrackList(&sb, tracks, format)
+	return sb.String()
+}
+
+// WriteTrackList writes the list of tracks without any header to w, a line
+// per track. It stops at and returns the first error from w.
+func WriteTrackList(w io.Writer, tracks []TrackSummary, format OutputFormat) error {
+	return WriteLocalizedTrackList(w, tracks, format, DefaultLocale)
+}
+
+// WriteLocalizedTrackList is WriteTrackList in the language of the locale
+func WriteLocalizedTrackList(w io.Writer, tracks []TrackSummary, format OutputFormat, locale Locale) error {
+	msgs := MessagesFor(locale)
+	ew := &errWriter{w: w}
+	for i, track := range tracks {
+		switch format {
+		case FormatMarkdown:
+			ew.printf("%d. [%s](%s) %s %s", i+1, track.Name, track.URL, msgs.By, joinArtists(track.Artists, msgs))
+			if track.DurationMs > 0 {
+				ew.printf(" (%s)", FormatDuration(track.DurationMs))
+			}
+			if track.PreviewURL != "" {
+				ew.printf(" · [▶ %s](%s)", msgs.Preview, track.PreviewURL)
+			}
+			ew.printf("\n")
+		case FormatMinimal:
+			ew.printf("%s - %s %s\n", track.Name, joinArtists(track.Artists, msgs), track.URL)
+		default:
+			recommendation := formatTrackRecommendation(track.Name, track.Artists, track.DurationMs, track.URL, track.PreviewURL, msgs)
+			ew.printf("%d. %s\n", i+1, recommendation)
+		}
+		if ew.err != nil {
+			return ew.err
+		}
+	}
+	return nil
+}
+
+// errWriter keeps the first error from writing to w and skips the writes
+// after it, so a run of writes needs checking only once
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
+
+// FormatMoodProfile renders the detected mood and its audio feature targets
+// as a short block, without any tracks
+func FormatMoodProfile(profile MoodProfile, format OutputFormat) string {
+	return FormatLocalizedMoodProfile(profile, format, DefaultLocale)
+}
+
+// FormatLocalizedMoodProfile is FormatMoodProfile in the language of the locale
+func FormatLocalizedMoodProfile(profile MoodProfile, format OutputFormat, locale Locale) string {
+	if format == FormatMinimal {
+		return fmt.Sprintf("%s %.0f%%\n", profile.Mood, Confidence(profile)*100)
+	}
+
+	label := func(name string) string {
+		if format == FormatMarkdown {
+			return "**" + name + ":**"
+		}
+		return name + ":"
+	}
+
+	msgs := MessagesFor(locale)
+	var sb strings.Builder
+	confidence := fmt.Sprintf(msgs.ProfileConfidence, Confidence(profile)*100)
+	sb.WriteString(fmt.Sprintf("%s %s %s\n", label(msgs.ProfileMood), profile.Mood, confidence))
+	if len(profile.MatchedTerms) > 0 {
+		sb.WriteString(fmt.Sprintf("%s %s\n", label(msgs.ProfileMatched), strings.Join(profile.MatchedTerms, ", ")))
+	}
+	sb.WriteString(fmt.Sprintf("%s %.0f%%\n", label(msgs.ProfileEnergy), profile.Energy*100))
+	sb.WriteString(fmt.Sprintf("%s %.0f%%\n", label(msgs.ProfileDanceability), profile.Danceability*100))
+	sb.WriteString(fmt.Sprintf("%s %.0f%%\n", label(msgs.ProfileValence), profile.Valence*100))
+	sb.WriteString(fmt.Sprintf("%s %.0f%%\n", label(msgs.ProfileAcousticness), profile.Acousticness*100))
+	if profile.Tempo > 0 {
+		sb.WriteString(fmt.Sprintf("%s %.0f BPM\n", label(msgs.ProfileTempo), profile.Tempo))
+	}
+	if profile.Activity != "" {
+		sb.WriteString(fmt.Sprintf("%s %s\n", label(msgs.ProfileActivity), profile.Activity))
+	}
+	if len(profile.SuggestedGenres) > 0 {
+		sb.WriteString(fmt.Sprintf("%s %s\n", label(msgs.ProfileGenres), strings.Join(profile.SuggestedGenres, ", ")))
+	}
+	return sb.String()
+}
+
+// joinArtists joins artist names with ", ", falling back to msgs.UnknownArtist
+func joinArtists(artistNames []string, msgs Messages) string {
+	if len(artistNames) == 0 {
+		return msgs.UnknownArtist
+	}
+	return strings.Join(artistNames, ", ")
+}
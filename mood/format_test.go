@@ -0,0 +1,214 @@
+package mood
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// testTrack returns a track with the given name and artists, linked to url
+func testTrack(name, url string, artists ...string) TrackSummary {
+	return TrackSummary{Name: name, Artists: artists, URL: url}
+}
+
+func TestFormatRecommendations(t *testing.T) {
+	tracks := []TrackSummary{
+		testTrack("Walking on Sunshine", "https://open.spotify.com/track/1", "Katrina and the Waves"),
+		testTrack("Under Pressure", "https://open.spotify.com/track/2", "Queen", "David Bowie"),
+	}
+	tracks[0].DurationMs = 238000
+	profile := MoodProfile{Mood: "happy", MatchedTerms: []string{"happy"}}
+
+	tests := []struct {
+		format OutputFormat
+		want   string
+	}{
+		{FormatPlain, "I picked up on 'happy'. Based on your mood (happy), here are some song recommendations:\n\n" +
+			"1. 🎵 Walking on Sunshine (3:58) by Katrina and the Waves\n   🔗 https://open.spotify.com/track/1\n" +
+			"2. 🎵 Under Pressure by Queen, David Bowie\n   🔗 https://open.spotify.com/track/2\n"},
+		{FormatMarkdown, "I picked up on 'happy'. Based on your mood (**happy**), here are some song recommendations:\n\n" +
+			"1. [Walking on Sunshine](https://open.spotify.com/track/1) by Katrina and the Waves (3:58)\n" +
+			"2. [Under Pressure](https://open.spotify.com/track/2) by Queen, David Bowie\n"},
+		{FormatMinimal, "Walking on Sunshine - Katrina and the Waves https://open.spotify.com/track/1\n" +
+			"Under Pressure - Queen, David Bowie https://open.spotify.com/track/2\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			if got := FormatRecommendations(tracks, profile, tt.format); got != tt.want {
+				t.Errorf("FormatRecommendations(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatRecommendationsPreview(t *testing.T) {
+	tracks := []TrackSummary{
+		testTrack("Walking on Sunshine", "https://open.spotify.com/track/1", "Katrina and the Waves"),
+		testTrack("Under Pressure", "https://open.spotify.com/track/2", "Queen", "David Bowie"),
+	}
+	tracks[0].PreviewURL = "https://p.scdn.co/mp3-preview/1"
+	profile := MoodProfile{Mood: "happy"}
+
+	tests := []struct {
+		format OutputFormat
+		want   string
+	}{
+		{FormatPlain, "Based on your mood (happy), here are some song recommendations:\n\n" +
+			"1. 🎵 Walking on Sunshine by Katrina and the Waves\n   🔗 https://open.spotify.com/track/1\n   ▶ preview: https://p.scdn.co/mp3-preview/1\n" +
+			"2. 🎵 Under Pressure by Queen, David Bowie\n   🔗 https://open.spotify.com/track/2\n"},
+		{FormatMarkdown, "Based on your mood (**happy**), here are some song recommendations:\n\n" +
+			"1. [Walking on Sunshine](https://open.spotify.com/track/1) by Katrina and the Waves · [▶ preview](https://p.scdn.co/mp3-preview/1)\n" +
+			"2. [Under Pressure](https://open.spotify.com/track/2) by Queen, David Bowie\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			if got := FormatRecommendations(tracks, profile, tt.format); got != tt.want {
+				t.Errorf("FormatRecommendations(%s) =\n%s\nwant\n%s", tt.format, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   OutputFormat
+		wantOK bool
+	}{
+		{"plain", FormatPlain, true},
+		{"Markdown", FormatMarkdown, true},
+		{"MINIMAL", FormatMinimal, true},
+		{"json", FormatJSON, true},
+		{"html", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseOutputFormat(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseOutputFormat(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestFormatMoodProfile(t *testing.T) {
+	profile := MoodProfile{
+		Mood: "relaxed", Detected: true, MatchedTerms: []string{"calm", "chill"},
+		Energy: 0.3, Danceability: 0.4, Valence: 0.6, Acousticness: 0.7, Tempo: 70,
+		SuggestedGenres: []string{"ambient", "lo-fi"},
+	}
+
+	tests := []struct {
+		format OutputFormat
+		locale Locale
+		want   string
+	}{
+		{FormatPlain, LocaleEnglish, "Mood: relaxed (confidence 80%)\nMatched: calm, chill\nEnergy: 30%\nDanceability: 40%\n" +
+			"Valence: 60%\nAcousticness: 70%\nTempo: 70 BPM\nGenres: ambient, lo-fi\n"},
+		{FormatMarkdown, LocaleEnglish, "**Mood:** relaxed (confidence 80%)\n**Matched:** calm, chill\n**Energy:** 30%\n**Danceability:** 40%\n" +
+			"**Valence:** 60%\n**Acousticness:** 70%\n**Tempo:** 70 BPM\n**Genres:** ambient, lo-fi\n"},
+		{FormatMinimal, LocaleEnglish, "relaxed 80%\n"},
+		{FormatPlain, LocaleSpanish, "Estado de ánimo: relaxed (confianza 80%)\nCoincidencias: calm, chill\nEnergía: 30%\nBailabilidad: 40%\n" +
+			"Positividad: 60%\nAcústica: 70%\nTempo: 70 BPM\nGéneros: ambient, lo-fi\n"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatLocalizedMoodProfile(profile, tt.format, tt.locale); got != tt.want {
+			t.Errorf("FormatLocalizedMoodProfile(%s, %s) =\n%s\nwant\n%s", tt.format, tt.locale, got, tt.want)
+		}
+	}
+}
+
+func TestWriteLocalizedTrackList(t *testing.T) {
+	track := testTrack("Everlong", "https://open.spotify.com/track/1", "Foo Fighters")
+	track.PreviewURL = "https://p.scdn.co/mp3-preview/1"
+	tracks := []TrackSummary{track, testTrack("Untitled", "https://open.spotify.com/track/2")}
+
+	tests := []struct {
+		format OutputFormat
+		locale Locale
+		want   string
+	}{
+		{FormatPlain, DefaultLocale, "1. 🎵 Everlong by Foo Fighters\n   🔗 https://open.spotify.com/track/1\n   ▶ preview: https://p.scdn.co/mp3-preview/1\n" +
+			"2. 🎵 Untitled by Unknown\n   🔗 https://open.spotify.com/track/2\n"},
+		{FormatPlain, LocaleFrench, "1. 🎵 Everlong par Foo Fighters\n   🔗 https://open.spotify.com/track/1\n   ▶ extrait: https://p.scdn.co/mp3-preview/1\n" +
+			"2. 🎵 Untitled par Inconnu\n   🔗 https://open.spotify.com/track/2\n"},
+		{FormatMarkdown, LocaleSpanish, "1. [Everlong](https://open.spotify.com/track/1) de Foo Fighters · [▶ avance](https://p.scdn.co/mp3-preview/1)\n" +
+			"2. [Untitled](https://open.spotify.com/track/2) de Desconocido\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := WriteLocalizedTrackList(&buf, tracks, tt.format, tt.locale); err != nil {
+			t.Fatalf("WriteLocalizedTrackList(%s, %s) error = %v", tt.format, tt.locale, err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("WriteLocalizedTrackList(%s, %s) =\n%s\nwant\n%s", tt.format, tt.locale, got, tt.want)
+		}
+	}
+}
+
+// chunkWriter records each write it gets and fails the write after failAfter
+// writes, when failAfter is positive
+type chunkWriter struct {
+	writes    []string
+	failAfter int
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	if cw.failAfter > 0 && len(cw.writes) >= cw.failAfter {
+		return 0, errWriteFailed
+	}
+	cw.writes = append(cw.writes, string(p))
+	return len(p), nil
+}
+
+func TestWriteRecommendations(t *testing.T) {
+	tracks := []TrackSummary{
+		testTrack("Walking on Sunshine", "https://open.spotify.com/track/1", "Katrina and the Waves"),
+		testTrack("Under Pressure", "https://open.spotify.com/track/2", "Queen", "David Bowie"),
+		testTrack("Good as Hell", "https://open.spotify.com/track/3", "Lizzo"),
+	}
+	profile := MoodProfile{Mood: "happy", MatchedTerms: []string{"happy"}}
+
+	for _, format := range []OutputFormat{FormatPlain, FormatMarkdown, FormatMinimal} {
+		var buf bytes.Buffer
+		if err := WriteRecommendations(&buf, tracks, profile, format); err != nil {
+			t.Fatalf("WriteRecommendations(%s) error = %v", format, err)
+		}
+		if want := FormatRecommendations(tracks, profile, format); buf.String() != want {
+			t.Errorf("WriteRecommendations(%s) =\n%s\nwant\n%s", format, buf.String(), want)
+		}
+	}
+
+	// Each track is written as soon as it's rendered rather than all at the end
+	cw := &chunkWriter{}
+	if err := WriteRecommendations(cw, tracks, profile, FormatMinimal); err != nil {
+		t.Fatalf("WriteRecommendations() error = %v", err)
+	}
+	if len(cw.writes) != len(tracks) || cw.writes[1] != "Under Pressure - Queen, David Bowie https://open.spotify.com/track/2\n" {
+		t.Errorf("writes = %q, want one per track", cw.writes)
+	}
+}
+
+func TestWriteRecommendationsStopsAtError(t *testing.T) {
+	tracks := []TrackSummary{
+		testTrack("One", "https://open.spotify.com/track/1", "A"),
+		testTrack("Two", "https://open.spotify.com/track/2", "B"),
+		testTrack("Three", "https://open.spotify.com/track/3", "C"),
+	}
+
+	for _, failAfter := range []int{1, 2} {
+		cw := &chunkWriter{failAfter: failAfter}
+		err := WriteRecommendations(cw, tracks, MoodProfile{Mood: "happy"}, FormatPlain)
+		if !errors.Is(err, errWriteFailed) {
+			t.Errorf("WriteRecommendations() failing after %d writes error = %v, want the write error", failAfter, err)
+		}
+		if len(cw.writes) != failAfter {
+			t.Errorf("WriteRecommendations() failing after %d writes kept writing: %q", failAfter, cw.writes)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package mood
+
+import (
+	"slices"
+	"sort"
+)
+
+// genreTerm maps a way of naming a genre in a message to the genre it stands for
+type genreTerm struct {
+	term  string
+	genre string
+}
+
+// knownGenres are the genres recognized when a user names one in their message.
+// Words that are as often used for something else ("house", "soul", "dance")
+// are left out so they aren't mistaken for a genre request.
+var knownGenres = []genreTerm{
+	{"rock", "rock"},
+	{"hard rock", "hard rock"},
+	{"classic rock", "classic rock"},
+	{"indie rock", "indie rock"},
+	{"pop", "pop"},
+	{"k-pop", "k-pop"},
+	{"kpop", "k-pop"},
+	{"jazz", "jazz"},
+	{"blues", "blues"},
+	{"country", "country"},
+	{"folk", "folk"},
+	{"funk", "funk"},
+	{"metal", "metal"},
+	{"punk", "punk"},
+	{"grunge", "grunge"},
+	{"hip-hop", "hip-hop"},
+	{"hiphop", "hip-hop"},
+	{"rap", "rap"},
+	{"r&b", "r&b"},
+	{"rnb", "r&b"},
+	{"reggae", "reggae"},
+	{"reggaeton", "reggaeton"},
+	{"latin", "latin"},
+	{"salsa", "salsa"},
+	{"afrobeat", "afrobeat"},
+	{"afrobeats", "afrobeat"},
+	{"electronic", "electronic"},
+	{"edm", "edm"},
+	{"techno", "techno"},
+	{"disco", "disco"},
+	{"drum and bass", "drum-and-bass"},
+	{"dubstep", "dubstep"},
+	{"indie", "indie"},
+	{"ambient", "ambient"},
+	{"classical", "classical"},
+	{"lo-fi", "lo-fi"},
+	{"lofi", "lo-fi"},
+	{"gospel", "gospel"},
+	{"opera", "opera"},
+}
+
+// extractGenres returns the genres explicitly named in the tokens, in the order
+// they are mentioned. Longer names win over the genres they contain, so "hard
+// rock" doesn't also count as "rock", and negated mentions ("no rap") are skipped.
+func extractGenres(tokens []string) []string {
+	terms := append([]genreTerm{}, knownGenres...)
+	sort.SliceStable(terms, func(i, j int) bool {
+		return len(tokenize(terms[i].term)) > len(tokenize(terms[j].term))
+	})
+
+	type mention struct {
+		pos   int
+		genre string
+	}
+	var mentions []mention
+	covered := make(map[int]bool)
+	for _, t := range terms {
+		length := len(tokenize(t.term))
+		for _, pos := range findTerm(tokens, t.term) {
+			if covered[pos] || isNegated(tokens, pos) {
+				continue
+			}
+			for i := pos; i < pos+length; i++ {
+				covered[i] = true
+			}
+			mentions = append(mentions, mention{pos, t.genre})
+		}
+	}
+
+	sort.SliceStable(mentions, func(i, j int) bool {
+		return mentions[i].pos < mentions[j].pos
+	})
+
+	var genres []string
+	seen := make(map[string]bool)
+	for _, m := range mentions {
+		if !seen[m.genre] {
+			seen[m.genre] = true
+			genres = append(genres, m.genre)
+		}
+	}
+	return genres
+}
+
+// applyRequestedGenres records the genres named in the tokens on the profile and
+// moves them to the front of its suggested genres
+func applyRequestedGenres(profile *MoodProfile, tokens []string) {
+	requested := extractGenres(tokens)
+	if len(requested) == 0 {
+		return
+	}
+
+	genres := append([]string{}, requested...)
+	for _, genre := range profile.SuggestedGenres {
+		if !slices.Contains(requested, genre) {
+			genres = append(genres, genre)
+		}
+	}
+	profile.RequestedGenres = requested
+	profile.SuggestedGenres = genres
+}
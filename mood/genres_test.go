@@ -0,0 +1,59 @@
+package mood
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractGenres(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"happy but I want rock", []string{"rock"}},
+		{"sad, some jazz or blues please", []string{"jazz", "blues"}},
+		{"chill hard rock", []string{"hard rock"}},
+		{"kpop and hiphop", []string{"k-pop", "hip-hop"}},
+		{"drum and bass then lofi then more lo-fi", []string{"drum-and-bass", "lo-fi"}},
+		{"happy, no rap", nil},
+		{"feeling happy", nil},
+	}
+
+	for _, tt := range tests {
+		if got := extractGenres(tokenize(tt.text)); !slices.Equal(got, tt.want) {
+			t.Errorf("extractGenres(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodRequestedGenres(t *testing.T) {
+	analyzer := NewMoodAnalyzer()
+
+	tests := []struct {
+		text          string
+		wantRequested []string
+		wantLeading   []string
+	}{
+		{"happy but I want rock", []string{"rock"}, []string{"rock", "pop"}},
+		{"sad, some jazz or blues please", []string{"jazz", "blues"}, []string{"jazz", "blues", "indie"}},
+	}
+
+	for _, tt := range tests {
+		profile := analyzer.AnalyzeMood(tt.text)
+		if !slices.Equal(profile.RequestedGenres, tt.wantRequested) {
+			t.Errorf("AnalyzeMood(%q).RequestedGenres = %v, want %v", tt.text, profile.RequestedGenres, tt.wantRequested)
+		}
+		if len(profile.SuggestedGenres) < len(tt.wantLeading) || !slices.Equal(profile.SuggestedGenres[:len(tt.wantLeading)], tt.wantLeading) {
+			t.Errorf("AnalyzeMood(%q).SuggestedGenres = %v, want it to start with %v", tt.text, profile.SuggestedGenres, tt.wantLeading)
+		}
+	}
+}
+
+func TestApplyRequestedGenresNoDuplicates(t *testing.T) {
+	profile := MoodProfile{SuggestedGenres: []string{"pop", "dance", "funk"}}
+	applyRequestedGenres(&profile, tokenize("some funk and pop"))
+
+	if want := []string{"funk", "pop", "dance"}; !slices.Equal(profile.SuggestedGenres, want) {
+		t.Errorf("SuggestedGenres = %v, want %v", profile.SuggestedGenres, want)
+	}
+}
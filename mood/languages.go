@@ -0,0 +1,29 @@
+package mood
+
+// SpanishKeywords are Spanish mood keywords for use with RegisterLanguage
+var SpanishKeywords = map[string][]string{
+	"happy":     {"feliz", "contento", "contenta", "alegre", "emocionado", "emocionada"},
+	"sad":       {"triste", "deprimido", "deprimida", "solo", "sola", "desanimado", "desanimada"},
+	"relaxed":   {"tranquilo", "tranquila", "relajado", "relajada", "calmado", "calmada", "cansado", "cansada"},
+	"energetic": {"motivado", "motivada", "enérgico", "enérgica", "activo", "activa"},
+	"romantic":  {"romántico", "romántica", "enamorado", "enamorada"},
+	"focused":   {"concentrado", "concentrada", "estudiando", "trabajando"},
+	"angry":     {"enojado", "enojada", "furioso", "furiosa", "enfadado", "enfadada"},
+	"anxious":   {"ansioso", "ansiosa", "nervioso", "nerviosa", "estresado", "estresada", "preocupado", "preocupada"},
+	"nostalgic": {"nostálgico", "nostálgica", "recuerdos"},
+	"party":     {"fiesta", "celebrar", "bailar", "de marcha"},
+}
+
+// FrenchKeywords are French mood keywords for use with RegisterLanguage
+var FrenchKeywords = map[string][]string{
+	"happy":     {"content", "contente", "heureux", "heureuse", "joyeux", "joyeuse"},
+	"sad":       {"triste", "déprimé", "déprimée", "seul", "seule", "malheureux", "malheureuse"},
+	"relaxed":   {"calme", "détendu", "détendue", "serein", "sereine", "fatigué", "fatiguée"},
+	"energetic": {"motivé", "motivée", "énergique"},
+	"romantic":  {"romantique", "amoureux", "amoureuse"},
+	"focused":   {"concentré", "concentrée", "étudie", "travaille"},
+	"angry":     {"fâché", "fâchée", "furieux", "furieuse", "énervé", "énervée"},
+	"anxious":   {"anxieux", "anxieuse", "nerveux", "nerveuse", "stressé", "stressée", "inquiet", "inquiète"},
+	"nostalgic": {"nostalgique", "souvenirs"},
+	"party":     {"fête", "faire la fête", "danser", "soirée"},
+}
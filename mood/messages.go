@@ -0,0 +1,503 @@
+package mood
+
+import "strings"
+
+// Locale names a language responses are written in, such as "en" or "es"
+type Locale string
+
+// The locales responses can be written in, matching the languages of the built-in keywords
+const (
+	LocaleEnglish Locale = "en"
+	LocaleSpanish Locale = "es"
+	LocaleFrench  Locale = "fr"
+
+	// DefaultLocale is used when no locale is asked for or the one asked for is unknown
+	DefaultLocale = LocaleEnglish
+)
+
+// Messages are the user-facing strings of a response in one language. Those
+// with a %s, %d or %f are fmt formats taking the values described, in order.
+type Messages struct {
+	MatchedTerms          string // the quoted terms a mood was detected from
+	And                   string // joins the last two matched terms, or two parts of a summary
+	Or                    string // joins alternatives, such as genres
+	RecommendationsHeader string // the mood name
+	Surprise              string // the mood picked at random
+	Ambiguous             string
+	NoMood                string
+	NoTracks              string // the mood name
+	NoSimilarTracks       string
+	PlaylistCreated       string // the playlist URL
+	PlaylistRefreshed     string // the playlist URL
+	TotalLength           string // the length, e.g. "45 minutes"
+	Note                  string // labels the warnings at the end of a response
+
+	// Replies to a task that can't be carried out as asked
+	NoCommand      string // the available commands
+	UnknownCommand string // the command, the available commands
+	DescribeMood   string // the command
+	WhichGenre     string
+	WhichPlaylist  string
+
+	// Labels of the mood profile, without the colon
+	ProfileMood         string
+	ProfileConfidence   string // the confidence as a percentage
+	ProfileMatched      string
+	ProfileEnergy       string
+	ProfileDanceability string
+	ProfileValence      string
+	ProfileAcousticness string
+	ProfileTempo        string
+	ProfileActivity     string
+	ProfileGenres       string
+
+	// The intensity summary, e.g. "You sound strongly energetic (90% energy)."
+	IntensitySummary string // the described features
+	IntensityFeature string // the word, the percentage, the feature name
+	Strongly         string // the word
+	ALittle          string // the word
+	Energetic        string
+	Mellow           string
+	Upbeat           string
+	Down             string
+	EnergyName       string
+	PositivityName   string
+
+	// Track lines
+	By            string // between a track and its artists
+	Preview       string // labels the preview link
+	UnknownArtist string
+
+	// Sequences and variations of a mood
+	SequenceHeader   string // the sequence name
+	SequencePart     string // the part number, the mood name
+	SequenceTo       string // joins the moods of a sequence name
+	SequenceNoMood   string // the description of the part
+	SequenceNoTracks string // the sequence name
+	VariationsHeader string // the number of variations, the mood name
+	VariationTitle   string // the label, the variation name
+	NoVariations     string // the mood name
+
+	// Genre radio and "more like"
+	GenreRadioHeader string // the genre
+	UnknownGenre     string // the genre
+	DidYouMean       string // the similar genres
+	NoGenreTracks    string // the genre
+	NoEarlierList    string
+	PickTrackNumber  string // the number of tracks in the last list
+	MoreLike         string // the track name, its artists
+
+	// How the tracks were found, with verbose:on
+	SearchQuery     string // the search query
+	SearchQueryNone string
+
+	// Failed searches
+	SearchRateLimited string // the mood name
+	SearchAuthFailed  string // the mood name
+	SearchFailed      string // the mood name
+
+	// Playback and Liked Songs
+	PlaybackSignIn   string
+	PlaybackStarted  string
+	PlaybackNoDevice string
+	PlaybackFailed   string
+	LikedSignIn      string
+	LikedSaved       string
+	LikedPartlySaved string // the number of tracks saved
+	LikedFailed      string
+
+	// Clearing a mood playlist
+	ClearWhichMood    string
+	ClearNoAccess     string
+	ClearLookupFailed string
+	ClearNoPlaylist   string // the mood name, used twice
+	ClearAlreadyEmpty string // the playlist name
+	ClearFailed       string // the playlist name
+	ClearedOne        string // the playlist name
+	ClearedMany       string // the number of tracks, the playlist name
+
+	// Playlist details
+	PlaylistDescription string // the mood name
+	PlaylistRefreshedOn string // the description, the date
+	DateLayout          string // a time layout for the date
+
+	// Lengths of music
+	OneMinute string
+	Minutes   string // the number of minutes
+	OneHour   string
+	Hours     string // the number of hours
+
+	// Warnings, listed after Note
+	WarnArtistNotFound   string // the artist name
+	WarnSearchOnly       string
+	WarnFewTracks        string // the number of tracks
+	WarnLooserFit        string
+	WarnPopularity       string
+	WarnGenreSearchOnly  string // the genres
+	WarnUnknownLengths   string // the length asked for
+	WarnShortLength      string // the length found
+	WarnNotCollaborative string
+	WarnPlaylistNotSaved string
+	WarnSignInToExclude  string
+	WarnSavedCheckFailed string
+	WarnAllSaved         string
+}
+
+// messages holds the strings of every supported locale
+var messages = map[Locale]Messages{
+	LocaleEnglish: {
+		MatchedTerms:          "I picked up on %s.",
+		And:                   "and",
+		Or:                    "or",
+		RecommendationsHeader: "Based on your mood (%s), here are some song recommendations:",
+		Surprise:              "🎲 Surprise! Let's go with something %s.",
+		Ambiguous:             "You sound a little torn, so here's a mix for both sides of it.",
+		NoMood:                "I couldn't pick up a mood from that. Could you tell me a bit more about how you're feeling? Example: 'mood_analyzer I feel calm and relaxed'",
+		NoTracks:              "I understand you're feeling %s, but I couldn't find any matching songs right now.",
+		NoSimilarTracks:       "I couldn't find songs like that track right now. Try again later!",
+		PlaylistCreated:       "✨ I've also created a playlist for you: %s",
+		PlaylistRefreshed:     "✨ I've refreshed your playlist with these songs: %s",
+		TotalLength:           "⏱️ %s of music in all.",
+		Note:                  "Note:",
+
+		NoCommand:      "No command provided. Available commands: %s",
+		UnknownCommand: "Unknown command '%s'. Available commands: %s",
+		DescribeMood:   "Please describe your mood. Example: '%s I feel happy and energetic'",
+		WhichGenre:     "Which genre should I play? Example: 'genre_radio jazz'",
+		WhichPlaylist:  "Which mood's playlist should I clear? Example: 'clear_playlist happy'",
+
+		ProfileMood:         "Mood",
+		ProfileConfidence:   "(confidence %.0f%%)",
+		ProfileMatched:      "Matched",
+		ProfileEnergy:       "Energy",
+		ProfileDanceability: "Danceability",
+		ProfileValence:      "Valence",
+		ProfileAcousticness: "Acousticness",
+		ProfileTempo:        "Tempo",
+		ProfileActivity:     "Activity",
+		ProfileGenres:       "Genres",
+
+		IntensitySummary: "You sound %s.",
+		IntensityFeature: "%s (%.0f%% %s)",
+		Strongly:         "strongly %s",
+		ALittle:          "a little %s",
+		Energetic:        "energetic",
+		Mellow:           "mellow",
+		Upbeat:           "upbeat",
+		Down:             "down",
+		EnergyName:       "energy",
+		PositivityName:   "positivity",
+
+		By:            "by",
+		Preview:       "preview",
+		UnknownArtist: "Unknown",
+
+		SequenceHeader:   "Here's a journey from %s:",
+		SequencePart:     "Part %d: %s",
+		SequenceTo:       "to",
+		SequenceNoMood:   "I couldn't pick up a mood from '%s'. Example: 'mood_analyzer happy and then relaxed'",
+		SequenceNoTracks: "I couldn't find any songs to go from %s right now.",
+		VariationsHeader: "Here are %d takes on your %s mood:",
+		VariationTitle:   "Variation %s (%s)",
+		NoVariations:     "I understand you're feeling %s, but I couldn't put together any variations right now.",
+
+		GenreRadioHeader: "📻 %s radio:",
+		UnknownGenre:     "Spotify doesn't know the genre '%s'.",
+		DidYouMean:       "Did you mean %s?",
+		NoGenreTracks:    "I couldn't find any %s songs right now. Try again later!",
+		NoEarlierList:    "I don't have an earlier list to pick from yet. Try 'mood_analyzer I feel happy' first.",
+		PickTrackNumber:  "Pick a song number between 1 and %d from the last list.",
+		MoreLike:         "🔁 More like %s by %s.",
+
+		SearchQuery:     "Search query: %s",
+		SearchQueryNone: "Search query: none, the songs are all recommendations",
+
+		SearchRateLimited: "I detected your mood as '%s', but Spotify is getting too many requests right now. Give it a minute and try again!",
+		SearchAuthFailed:  "I detected your mood as '%s', but I'm having trouble signing in to Spotify. Please check the agent's Spotify credentials.",
+		SearchFailed:      "I detected your mood as '%s', but I couldn't fetch recommendations right now. Try again later!",
+
+		PlaybackSignIn:   "To play these on Spotify, sign in with a Spotify Premium account.",
+		PlaybackStarted:  "▶️ Playing these on your Spotify now.",
+		PlaybackNoDevice: "I couldn't start playing: open Spotify on one of your devices and try again.",
+		PlaybackFailed:   "I couldn't start playing these right now (playback needs a signed-in Spotify Premium account).",
+		LikedSignIn:      "To save these to your Liked Songs, sign in with your Spotify account.",
+		LikedSaved:       "❤️ I've saved these songs to your Liked Songs.",
+		LikedPartlySaved: "❤️ I've saved %d of these songs to your Liked Songs.",
+		LikedFailed:      "I couldn't save these to your Liked Songs right now (that needs a signed-in Spotify account).",
+
+		ClearWhichMood:    "I couldn't tell which mood's playlist you mean. Example: 'clear_playlist happy'",
+		ClearNoAccess:     "I need access to your Spotify account to clear playlists. Please sign in and try again.",
+		ClearLookupFailed: "I couldn't look up your playlists right now. Try again later!",
+		ClearNoPlaylist:   "You don't have a %[1]s playlist yet. Try 'mood_analyzer I feel %[1]s' to make one.",
+		ClearAlreadyEmpty: "Your playlist '%s' is already empty.",
+		ClearFailed:       "I couldn't clear '%s' right now. Try again later!",
+		ClearedOne:        "🧹 Cleared 1 track from '%s'.",
+		ClearedMany:       "🧹 Cleared %d tracks from '%s'.",
+
+		PlaylistDescription: "A playlist curated for your %s mood.",
+		PlaylistRefreshedOn: "%s Refreshed on %s.",
+		DateLayout:          "Jan 2, 2006",
+
+		OneMinute: "1 minute",
+		Minutes:   "%d minutes",
+		OneHour:   "1 hour",
+		Hours:     "%d hours",
+
+		WarnArtistNotFound:   "I couldn't find %s on Spotify, so these aren't based on their music.",
+		WarnSearchOnly:       "Spotify's recommendations weren't available, so these songs all come from search.",
+		WarnFewTracks:        "I could only find %d songs this time.",
+		WarnLooserFit:        "There weren't enough songs for exactly what you asked, so some are a looser fit.",
+		WarnPopularity:       "None of the songs were as popular (or obscure) as you asked, so I kept them all.",
+		WarnGenreSearchOnly:  "Spotify doesn't recommend by %s, so I could only use it to search.",
+		WarnUnknownLengths:   "I couldn't tell how long these songs are, so the playlist may not be %s long.",
+		WarnShortLength:      "I could only find %s of music this time.",
+		WarnNotCollaborative: "Your playlist for this mood already existed, so it's still just yours to edit.",
+		WarnPlaylistNotSaved: "I couldn't save these to a playlist this time.",
+		WarnSignInToExclude:  "Sign in with your Spotify account to leave out songs you've already saved.",
+		WarnSavedCheckFailed: "I couldn't check which of these you've already saved.",
+		WarnAllSaved:         "You've already saved all of these songs, so I kept them anyway.",
+	},
+	LocaleSpanish: {
+		MatchedTerms:          "Noté %s.",
+		And:                   "y",
+		Or:                    "o",
+		RecommendationsHeader: "Según tu estado de ánimo (%s), te recomiendo estas canciones:",
+		Surprise:              "🎲 ¡Sorpresa! Vamos con algo %s.",
+		Ambiguous:             "Pareces algo dividido, así que aquí tienes una mezcla de ambos lados.",
+		NoMood:                "No logré captar tu estado de ánimo. ¿Puedes contarme un poco más sobre cómo te sientes? Ejemplo: 'mood_analyzer me siento tranquilo'",
+		NoTracks:              "Entiendo que te sientes %s, pero ahora mismo no encontré canciones que encajen.",
+		NoSimilarTracks:       "Ahora mismo no encontré canciones parecidas a esa. ¡Inténtalo más tarde!",
+		PlaylistCreated:       "✨ También te creé una lista de reproducción: %s",
+		PlaylistRefreshed:     "✨ Actualicé tu lista de reproducción con estas canciones: %s",
+		TotalLength:           "⏱️ %s de música en total.",
+		Note:                  "Nota:",
+
+		NoCommand:      "No indicaste ningún comando. Comandos disponibles: %s",
+		UnknownCommand: "No conozco el comando '%s'. Comandos disponibles: %s",
+		DescribeMood:   "Describe tu estado de ánimo. Ejemplo: '%s me siento feliz'",
+		WhichGenre:     "¿Qué género pongo? Ejemplo: 'genre_radio jazz'",
+		WhichPlaylist:  "¿Qué lista de estado de ánimo vacío? Ejemplo: 'clear_playlist feliz'",
+
+		ProfileMood:         "Estado de ánimo",
+		ProfileConfidence:   "(confianza %.0f%%)",
+		ProfileMatched:      "Coincidencias",
+		ProfileEnergy:       "Energía",
+		ProfileDanceability: "Bailabilidad",
+		ProfileValence:      "Positividad",
+		ProfileAcousticness: "Acústica",
+		ProfileTempo:        "Tempo",
+		ProfileActivity:     "Actividad",
+		ProfileGenres:       "Géneros",
+
+		IntensitySummary: "Te noto %s.",
+		IntensityFeature: "%[1]s (%[3]s al %.0[2]f%%)",
+		Strongly:         "muy %s",
+		ALittle:          "un poco %s",
+		Energetic:        "enérgico",
+		Mellow:           "tranquilo",
+		Upbeat:           "animado",
+		Down:             "decaído",
+		EnergyName:       "energía",
+		PositivityName:   "positividad",
+
+		By:            "de",
+		Preview:       "avance",
+		UnknownArtist: "Desconocido",
+
+		SequenceHeader:   "Aquí tienes un viaje de %s:",
+		SequencePart:     "Parte %d: %s",
+		SequenceTo:       "a",
+		SequenceNoMood:   "No logré captar un estado de ánimo en '%s'. Ejemplo: 'mood_analyzer happy and then relaxed'",
+		SequenceNoTracks: "Ahora mismo no encontré canciones para ir de %s.",
+		VariationsHeader: "Aquí tienes %d versiones de tu estado de ánimo %s:",
+		VariationTitle:   "Variación %s (%s)",
+		NoVariations:     "Entiendo que te sientes %s, pero ahora mismo no pude armar ninguna variación.",
+
+		GenreRadioHeader: "📻 Radio %s:",
+		UnknownGenre:     "Spotify no conoce el género '%s'.",
+		DidYouMean:       "¿Quisiste decir %s?",
+		NoGenreTracks:    "Ahora mismo no encontré canciones de %s. ¡Inténtalo más tarde!",
+		NoEarlierList:    "Todavía no tengo una lista anterior de la que elegir. Prueba primero 'mood_analyzer me siento feliz'.",
+		PickTrackNumber:  "Elige un número de canción entre 1 y %d de la última lista.",
+		MoreLike:         "🔁 Más como %s de %s.",
+
+		SearchQuery:     "Búsqueda: %s",
+		SearchQueryNone: "Búsqueda: ninguna, todas las canciones son recomendaciones",
+
+		SearchRateLimited: "Detecté tu estado de ánimo como '%s', pero Spotify está recibiendo demasiadas solicitudes ahora mismo. ¡Espera un minuto y vuelve a intentarlo!",
+		SearchAuthFailed:  "Detecté tu estado de ánimo como '%s', pero tengo problemas para iniciar sesión en Spotify. Revisa las credenciales de Spotify del agente.",
+		SearchFailed:      "Detecté tu estado de ánimo como '%s', pero ahora mismo no pude obtener recomendaciones. ¡Inténtalo más tarde!",
+
+		PlaybackSignIn:   "Para reproducirlas en Spotify, inicia sesión con una cuenta de Spotify Premium.",
+		PlaybackStarted:  "▶️ Reproduciéndolas ahora en tu Spotify.",
+		PlaybackNoDevice: "No pude empezar a reproducir: abre Spotify en uno de tus dispositivos y vuelve a intentarlo.",
+		PlaybackFailed:   "Ahora mismo no pude reproducirlas (hace falta haber iniciado sesión con una cuenta de Spotify Premium).",
+		LikedSignIn:      "Para guardarlas en Canciones que te gustan, inicia sesión con tu cuenta de Spotify.",
+		LikedSaved:       "❤️ Guardé estas canciones en Canciones que te gustan.",
+		LikedPartlySaved: "❤️ Guardé %d de estas canciones en Canciones que te gustan.",
+		LikedFailed:      "Ahora mismo no pude guardarlas en Canciones que te gustan (hace falta haber iniciado sesión en Spotify).",
+
+		ClearWhichMood:    "No supe de qué lista de estado de ánimo hablas. Ejemplo: 'clear_playlist feliz'",
+		ClearNoAccess:     "Necesito acceso a tu cuenta de Spotify para vaciar listas. Inicia sesión y vuelve a intentarlo.",
+		ClearLookupFailed: "Ahora mismo no pude consultar tus listas. ¡Inténtalo más tarde!",
+		ClearNoPlaylist:   "Todavía no tienes una lista %[1]s. Prueba 'mood_analyzer me siento %[1]s' para crear una.",
+		ClearAlreadyEmpty: "Tu lista '%s' ya está vacía.",
+		ClearFailed:       "Ahora mismo no pude vaciar '%s'. ¡Inténtalo más tarde!",
+		ClearedOne:        "🧹 Quité 1 canción de '%s'.",
+		ClearedMany:       "🧹 Quité %d canciones de '%s'.",
+
+		PlaylistDescription: "Una lista seleccionada para tu estado de ánimo %s.",
+		PlaylistRefreshedOn: "%s Actualizada el %s.",
+		DateLayout:          "2/1/2006",
+
+		OneMinute: "1 minuto",
+		Minutes:   "%d minutos",
+		OneHour:   "1 hora",
+		Hours:     "%d horas",
+
+		WarnArtistNotFound:   "No encontré a %s en Spotify, así que estas no se basan en su música.",
+		WarnSearchOnly:       "Las recomendaciones de Spotify no estaban disponibles, así que todas estas canciones vienen de la búsqueda.",
+		WarnFewTracks:        "Esta vez solo encontré %d canciones.",
+		WarnLooserFit:        "No había suficientes canciones para exactamente lo que pediste, así que algunas encajan menos.",
+		WarnPopularity:       "Ninguna de las canciones era tan popular (o desconocida) como pediste, así que las dejé todas.",
+		WarnGenreSearchOnly:  "Spotify no recomienda por %s, así que solo pude usarlo para buscar.",
+		WarnUnknownLengths:   "No supe cuánto duran estas canciones, así que puede que la lista no dure %s.",
+		WarnShortLength:      "Esta vez solo encontré %s de música.",
+		WarnNotCollaborative: "Tu lista para este estado de ánimo ya existía, así que solo tú puedes editarla.",
+		WarnPlaylistNotSaved: "Esta vez no pude guardarlas en una lista de reproducción.",
+		WarnSignInToExclude:  "Inicia sesión con tu cuenta de Spotify para dejar fuera las canciones que ya guardaste.",
+		WarnSavedCheckFailed: "No pude comprobar cuáles de estas ya guardaste.",
+		WarnAllSaved:         "Ya guardaste todas estas canciones, así que las dejé de todos modos.",
+	},
+	LocaleFrench: {
+		MatchedTerms:          "J'ai relevé %s.",
+		And:                   "et",
+		Or:                    "ou",
+		RecommendationsHeader: "D'après ton humeur (%s), voici quelques chansons à écouter :",
+		Surprise:              "🎲 Surprise ! Partons sur quelque chose de %s.",
+		Ambiguous:             "Tu sembles un peu partagé, alors voici un mélange des deux.",
+		NoMood:                "Je n'ai pas réussi à cerner ton humeur. Peux-tu m'en dire un peu plus sur ce que tu ressens ? Exemple : 'mood_analyzer je suis calme'",
+		NoTracks:              "Je comprends que tu te sens %s, mais je n'ai pas trouvé de chansons qui correspondent pour l'instant.",
+		NoSimilarTracks:       "Je n'ai pas trouvé de chansons comme celle-là pour l'instant. Réessaie plus tard !",
+		PlaylistCreated:       "✨ Je t'ai aussi créé une playlist : %s",
+		PlaylistRefreshed:     "✨ J'ai mis à jour ta playlist avec ces chansons : %s",
+		TotalLength:           "⏱️ %s de musique en tout.",
+		Note:                  "Remarque :",
+
+		NoCommand:      "Aucune commande indiquée. Commandes disponibles : %s",
+		UnknownCommand: "Commande inconnue '%s'. Commandes disponibles : %s",
+		DescribeMood:   "Décris ton humeur. Exemple : '%s je suis joyeux'",
+		WhichGenre:     "Quel genre dois-je jouer ? Exemple : 'genre_radio jazz'",
+		WhichPlaylist:  "Quelle playlist d'humeur dois-je vider ? Exemple : 'clear_playlist joyeux'",
+
+		ProfileMood:         "Humeur",
+		ProfileConfidence:   "(confiance %.0f %%)",
+		ProfileMatched:      "Termes relevés",
+		ProfileEnergy:       "Énergie",
+		ProfileDanceability: "Dansabilité",
+		ProfileValence:      "Positivité",
+		ProfileAcousticness: "Acoustique",
+		ProfileTempo:        "Tempo",
+		ProfileActivity:     "Activité",
+		ProfileGenres:       "Genres",
+
+		IntensitySummary: "Tu as l'air %s.",
+		IntensityFeature: "%[1]s (%[3]s à %.0[2]f %%)",
+		Strongly:         "très %s",
+		ALittle:          "un peu %s",
+		Energetic:        "énergique",
+		Mellow:           "calme",
+		Upbeat:           "enjoué",
+		Down:             "abattu",
+		EnergyName:       "énergie",
+		PositivityName:   "positivité",
+
+		By:            "par",
+		Preview:       "extrait",
+		UnknownArtist: "Inconnu",
+
+		SequenceHeader:   "Voici un voyage de %s :",
+		SequencePart:     "Partie %d : %s",
+		SequenceTo:       "à",
+		SequenceNoMood:   "Je n'ai pas réussi à cerner une humeur dans '%s'. Exemple : 'mood_analyzer happy and then relaxed'",
+		SequenceNoTracks: "Je n'ai pas trouvé de chansons pour aller de %s pour l'instant.",
+		VariationsHeader: "Voici %d variantes de ton humeur %s :",
+		VariationTitle:   "Variante %s (%s)",
+		NoVariations:     "Je comprends que tu te sens %s, mais je n'ai pas pu préparer de variantes pour l'instant.",
+
+		GenreRadioHeader: "📻 Radio %s :",
+		UnknownGenre:     "Spotify ne connaît pas le genre '%s'.",
+		DidYouMean:       "Voulais-tu dire %s ?",
+		NoGenreTracks:    "Je n'ai pas trouvé de chansons %s pour l'instant. Réessaie plus tard !",
+		NoEarlierList:    "Je n'ai pas encore de liste précédente où choisir. Essaie d'abord 'mood_analyzer je suis joyeux'.",
+		PickTrackNumber:  "Choisis un numéro de chanson entre 1 et %d dans la dernière liste.",
+		MoreLike:         "🔁 Plus dans le style de %s par %s.",
+
+		SearchQuery:     "Recherche : %s",
+		SearchQueryNone: "Recherche : aucune, toutes les chansons sont des recommandations",
+
+		SearchRateLimited: "J'ai détecté ton humeur comme '%s', mais Spotify reçoit trop de requêtes en ce moment. Attends une minute et réessaie !",
+		SearchAuthFailed:  "J'ai détecté ton humeur comme '%s', mais j'ai du mal à me connecter à Spotify. Vérifie les identifiants Spotify de l'agent.",
+		SearchFailed:      "J'ai détecté ton humeur comme '%s', mais je n'ai pas pu récupérer de recommandations pour l'instant. Réessaie plus tard !",
+
+		PlaybackSignIn:   "Pour les écouter sur Spotify, connecte-toi avec un compte Spotify Premium.",
+		PlaybackStarted:  "▶️ Lecture en cours sur ton Spotify.",
+		PlaybackNoDevice: "Je n'ai pas pu lancer la lecture : ouvre Spotify sur un de tes appareils et réessaie.",
+		PlaybackFailed:   "Je n'ai pas pu les lancer pour l'instant (la lecture nécessite d'être connecté à un compte Spotify Premium).",
+		LikedSignIn:      "Pour les ajouter à tes Titres likés, connecte-toi avec ton compte Spotify.",
+		LikedSaved:       "❤️ J'ai ajouté ces chansons à tes Titres likés.",
+		LikedPartlySaved: "❤️ J'ai ajouté %d de ces chansons à tes Titres likés.",
+		LikedFailed:      "Je n'ai pas pu les ajouter à tes Titres likés pour l'instant (il faut être connecté à un compte Spotify).",
+
+		ClearWhichMood:    "Je n'ai pas compris de quelle playlist d'humeur tu parles. Exemple : 'clear_playlist joyeux'",
+		ClearNoAccess:     "J'ai besoin d'accéder à ton compte Spotify pour vider les playlists. Connecte-toi et réessaie.",
+		ClearLookupFailed: "Je n'ai pas pu consulter tes playlists pour l'instant. Réessaie plus tard !",
+		ClearNoPlaylist:   "Tu n'as pas encore de playlist %[1]s. Essaie 'mood_analyzer je suis %[1]s' pour en créer une.",
+		ClearAlreadyEmpty: "Ta playlist '%s' est déjà vide.",
+		ClearFailed:       "Je n'ai pas pu vider '%s' pour l'instant. Réessaie plus tard !",
+		ClearedOne:        "🧹 1 titre retiré de '%s'.",
+		ClearedMany:       "🧹 %d titres retirés de '%s'.",
+
+		PlaylistDescription: "Une playlist choisie pour ton humeur %s.",
+		PlaylistRefreshedOn: "%s Mise à jour le %s.",
+		DateLayout:          "02/01/2006",
+
+		OneMinute: "1 minute",
+		Minutes:   "%d minutes",
+		OneHour:   "1 heure",
+		Hours:     "%d heures",
+
+		WarnArtistNotFound:   "Je n'ai pas trouvé %s sur Spotify, donc ces chansons ne s'inspirent pas de sa musique.",
+		WarnSearchOnly:       "Les recommandations de Spotify n'étaient pas disponibles, donc toutes ces chansons viennent de la recherche.",
+		WarnFewTracks:        "Je n'ai trouvé que %d chansons cette fois.",
+		WarnLooserFit:        "Il n'y avait pas assez de chansons pour exactement ce que tu demandais, donc certaines correspondent moins bien.",
+		WarnPopularity:       "Aucune des chansons n'était aussi populaire (ou confidentielle) que demandé, donc je les ai toutes gardées.",
+		WarnGenreSearchOnly:  "Spotify ne recommande pas par %s, donc je n'ai pu l'utiliser que pour la recherche.",
+		WarnUnknownLengths:   "Je n'ai pas pu savoir combien de temps durent ces chansons, donc la playlist ne durera peut-être pas %s.",
+		WarnShortLength:      "Je n'ai trouvé que %s de musique cette fois.",
+		WarnNotCollaborative: "Ta playlist pour cette humeur existait déjà, donc tu es encore le seul à pouvoir la modifier.",
+		WarnPlaylistNotSaved: "Je n'ai pas pu les enregistrer dans une playlist cette fois.",
+		WarnSignInToExclude:  "Connecte-toi avec ton compte Spotify pour écarter les chansons que tu as déjà enregistrées.",
+		WarnSavedCheckFailed: "Je n'ai pas pu vérifier lesquelles tu as déjà enregistrées.",
+		WarnAllSaved:         "Tu as déjà enregistré toutes ces chansons, donc je les ai gardées quand même.",
+	},
+}
+
+// ParseLocale returns the supported locale with the given name, ignoring case
+// and any region, so "es-MX" is Spanish
+func ParseLocale(name string) (Locale, bool) {
+	language, _, _ := strings.Cut(strings.ToLower(name), "-")
+	language, _, _ = strings.Cut(language, "_")
+	locale := Locale(language)
+	_, ok := messages[locale]
+	return locale, ok
+}
+
+// MessagesFor returns the strings of a locale, falling back to DefaultLocale
+// for an unknown one
+func MessagesFor(locale Locale) Messages {
+	if m, ok := messages[locale]; ok {
+		return m
+	}
+	return messages[DefaultLocale]
+}
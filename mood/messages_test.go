@@ -0,0 +1,91 @@
+package mood
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   Locale
+		wantOK bool
+	}{
+		{"en", LocaleEnglish, true},
+		{"ES", LocaleSpanish, true},
+		{"es-MX", LocaleSpanish, true},
+		{"fr_CA", LocaleFrench, true},
+		{"de", "de", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := ParseLocale(tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseLocale(%q) = %q, %v, want %q, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMessagesForUnknownLocale(t *testing.T) {
+	if got := MessagesFor("de"); got != MessagesFor(DefaultLocale) {
+		t.Errorf("MessagesFor(%q) = %+v, want the %s messages", "de", got, DefaultLocale)
+	}
+}
+
+// formatVerb matches a fmt verb such as %s, %.0f or %[2]d, but not %%
+var formatVerb = regexp.MustCompile(`%(?:\[(\d+)\])?[.\d]*([sdf%])`)
+
+// formatArgs returns placeholder arguments of the types the verbs of an
+// English message take
+func formatArgs(format string) []interface{} {
+	var args []interface{}
+	next := 0
+	for _, match := range formatVerb.FindAllStringSubmatch(format, -1) {
+		if match[2] == "%" {
+			continue
+		}
+		i := next
+		if match[1] != "" {
+			i, _ = strconv.Atoi(match[1])
+			i--
+		}
+		for len(args) <= i {
+			args = append(args, nil)
+		}
+		switch match[2] {
+		case "s":
+			args[i] = "x"
+		case "d":
+			args[i] = 1
+		case "f":
+			args[i] = 1.0
+		}
+		next = i + 1
+	}
+	return args
+}
+
+func TestMessagesTranslateEveryString(t *testing.T) {
+	english := reflect.ValueOf(messages[LocaleEnglish])
+	for locale, msgs := range messages {
+		translated := reflect.ValueOf(msgs)
+		for i := 0; i < translated.NumField(); i++ {
+			name := translated.Type().Field(i).Name
+			format := translated.Field(i).String()
+			if format == "" {
+				t.Errorf("%s: %s is empty", locale, name)
+				continue
+			}
+			// A translation takes the same arguments as the English message
+			args := formatArgs(english.Field(i).String())
+			if got := fmt.Sprintf(format, args...); strings.Contains(got, "%!") {
+				t.Errorf("%s: %s = %q, which doesn't fit the arguments %v", locale, name, got, args)
+			}
+		}
+	}
+}
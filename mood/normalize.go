@@ -0,0 +1,33 @@
+package mood
+
+import "strings"
+
+// punctuationReplacer maps the typographic punctuation phones and word processors
+// insert to the ASCII the keyword matching expects. Dashes used between words
+// become a spaced hyphen so "happy—but tired" still splits into words.
+var punctuationReplacer = strings.NewReplacer(
+	"‘", "'", // left single quote
+	"’", "'", // right single quote, the curly apostrophe in "I’m"
+	"‛", "'", // reversed single quote
+	"′", "'", // prime
+	"“", `"`, // left double quote
+	"”", `"`, // right double quote
+	"„", `"`, // low double quote
+	"″", `"`, // double prime
+	"‐", "-", // hyphen
+	"‑", "-", // non-breaking hyphen
+	"‒", " - ", // figure dash
+	"–", " - ", // en dash
+	"—", " - ", // em dash
+	"―", " - ", // horizontal bar
+	"−", "-", // minus sign
+	"…", "...", // ellipsis
+	" ", " ", // non-breaking space
+	" ", " ", // narrow non-breaking space
+)
+
+// NormalizeText replaces curly quotes, unicode dashes and similar punctuation
+// with their ASCII equivalents, e.g. "I’m happy—really" becomes "I'm happy - really"
+func NormalizeText(text string) string {
+	return punctuationReplacer.Replace(text)
+}
@@ -0,0 +1,46 @@
+package mood
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"I’m so happy", "I'm so happy"},
+		{"‘quoted’ and “double”", `'quoted' and "double"`},
+		{"happy—but tired", "happy - but tired"},
+		{"sad – really", "sad  -  really"},
+		{"well…", "well..."},
+		{"plain ASCII", "plain ASCII"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeText(tt.text); got != tt.want {
+			t.Errorf("NormalizeText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodTypographicPunctuation(t *testing.T) {
+	ma := NewMoodAnalyzer()
+
+	profile := ma.AnalyzeMood("I’m so happy")
+	if profile.Mood != "happy" || !profile.Detected {
+		t.Errorf("AnalyzeMood(curly quote) = %q, want happy", profile.Mood)
+	}
+
+	// The dash separates the words, so "tired" still counts
+	profile = ma.AnalyzeMoodBlended("happy—tired")
+	if !slices.Contains(profile.MatchedTerms, "happy") || !slices.Contains(profile.MatchedTerms, "tired") {
+		t.Errorf("AnalyzeMoodBlended(em dash) matched %v, want happy and tired", profile.MatchedTerms)
+	}
+
+	// "don’t" must still negate what follows
+	if profile := ma.AnalyzeMood("I don’t feel sad, I’m calm"); profile.Mood != "relaxed" {
+		t.Errorf("AnalyzeMood(curly negation) = %q, want relaxed", profile.Mood)
+	}
+}
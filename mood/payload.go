@@ -0,0 +1,117 @@
+package mood
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RecommendationsPayload is the machine-readable form of a recommendation response
+type RecommendationsPayload struct {
+	Mood         string         `json:"mood"`
+	Detected     bool           `json:"detected"`
+	MatchedTerms []string       `json:"matched_terms,omitempty"`
+	Features     FeatureTargets `json:"features"`
+	Genres       []string       `json:"genres"`
+	Tracks       []TrackSummary `json:"tracks"`
+	PlaylistURL  string         `json:"playlist_url,omitempty"`
+	Warnings     []string       `json:"warnings,omitempty"`     // problems that didn't stop the recommendations
+	SearchQuery  string         `json:"search_query,omitempty"` // the Spotify search used, when asked for
+}
+
+// FeatureTargets are the audio feature targets of a mood profile
+type FeatureTargets struct {
+	Energy           float32 `json:"energy"`
+	Danceability     float32 `json:"danceability"`
+	Valence          float32 `json:"valence"`
+	Acousticness     float32 `json:"acousticness"`
+	Tempo            float32 `json:"tempo,omitempty"`
+	Instrumentalness float32 `json:"instrumentalness,omitempty"`
+	Speechiness      float32 `json:"speechiness,omitempty"`
+	Mode             string  `json:"mode,omitempty"`
+	MinEnergy        float32 `json:"min_energy,omitempty"`
+	MaxEnergy        float32 `json:"max_energy,omitempty"`
+	MinValence       float32 `json:"min_valence,omitempty"`
+	MaxValence       float32 `json:"max_valence,omitempty"`
+	Polarity         float32 `json:"polarity"`
+}
+
+// TrackSummary is the machine-readable form of a recommended track
+type TrackSummary struct {
+	Name       string   `json:"name"`
+	Artists    []string `json:"artists"`
+	Album      string   `json:"album,omitempty"`
+	DurationMs int      `json:"duration_ms,omitempty"`
+	URL        string   `json:"url"`
+	URI        string   `json:"uri"`
+	PreviewURL string   `json:"preview_url,omitempty"`
+	AlbumImage string   `json:"album_image,omitempty"` // cover art sized for cards
+}
+
+// NewRecommendationsPayload builds the machine-readable recommendations for a mood profile
+func NewRecommendationsPayload(profile MoodProfile, tracks []TrackSummary) RecommendationsPayload {
+	payload := RecommendationsPayload{
+		Mood:         profile.Mood,
+		Detected:     profile.Detected,
+		MatchedTerms: profile.MatchedTerms,
+		Features: FeatureTargets{
+			Energy:           profile.Energy,
+			Danceability:     profile.Danceability,
+			Valence:          profile.Valence,
+			Acousticness:     profile.Acousticness,
+			Tempo:            profile.Tempo,
+			Instrumentalness: profile.Instrumentalness,
+			Speechiness:      profile.Speechiness,
+			Mode:             profile.Mode,
+			MinEnergy:        profile.MinEnergy,
+			MaxEnergy:        profile.MaxEnergy,
+			MinValence:       profile.MinValence,
+			MaxValence:       profile.MaxValence,
+			Polarity:         profile.Polarity,
+		},
+		Genres: append([]string{}, profile.SuggestedGenres...),
+		Tracks: append([]TrackSummary{}, tracks...),
+	}
+
+	return payload
+}
+
+// Marshal encodes the payload as JSON
+func (p RecommendationsPayload) Marshal() (string, error) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal recommendations: %w", err)
+	}
+	return string(data), nil
+}
+
+// MoodPayload is the machine-readable form of a mood analysis without tracks
+type MoodPayload struct {
+	Mood         string         `json:"mood"`
+	Detected     bool           `json:"detected"`
+	Confidence   float32        `json:"confidence"`
+	MatchedTerms []string       `json:"matched_terms,omitempty"`
+	Features     FeatureTargets `json:"features"`
+	Genres       []string       `json:"genres"`
+}
+
+// MarshalMoodProfile encodes the detected mood and its audio feature targets as JSON
+func MarshalMoodProfile(profile MoodProfile) (string, error) {
+	recommendations := NewRecommendationsPayload(profile, nil)
+	data, err := json.Marshal(MoodPayload{
+		Mood:         recommendations.Mood,
+		Detected:     recommendations.Detected,
+		Confidence:   Confidence(profile),
+		MatchedTerms: recommendations.MatchedTerms,
+		Features:     recommendations.Features,
+		Genres:       recommendations.Genres,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal mood profile: %w", err)
+	}
+	return string(data), nil
+}
+
+// MarshalRecommendations encodes the recommendations for a mood profile as JSON
+func MarshalRecommendations(profile MoodProfile, tracks []TrackSummary) (string, error) {
+	return NewRecommendationsPayload(profile, tracks).Marshal()
+}
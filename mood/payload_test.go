@@ -0,0 +1,75 @@
+package mood
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestMarshalRecommendations(t *testing.T) {
+	profile := NewMoodAnalyzer().AnalyzeMood("happy")
+	track := testTrack("Under Pressure", "https://open.spotify.com/track/2", "Queen", "David Bowie")
+	track.URI = "spotify:track:2"
+
+	data, err := MarshalRecommendations(profile, []TrackSummary{track})
+	if err != nil {
+		t.Fatalf("MarshalRecommendations: %v", err)
+	}
+
+	// The shape clients rely on
+	var shape map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &shape); err != nil {
+		t.Fatalf("output isn't JSON: %v\n%s", err, data)
+	}
+	for _, key := range []string{"mood", "detected", "features", "genres", "tracks"} {
+		if _, ok := shape[key]; !ok {
+			t.Errorf("payload has no %q key: %s", key, data)
+		}
+	}
+	tracks, ok := shape["tracks"].([]interface{})
+	if !ok || len(tracks) != 1 {
+		t.Fatalf("tracks = %v, want one track", shape["tracks"])
+	}
+	for _, key := range []string{"name", "artists", "url", "uri"} {
+		if _, ok := tracks[0].(map[string]interface{})[key]; !ok {
+			t.Errorf("track has no %q key: %v", key, tracks[0])
+		}
+	}
+
+	// And it round-trips
+	var payload RecommendationsPayload
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if payload.Mood != "happy" || !payload.Detected || payload.Features.Energy != profile.Energy {
+		t.Errorf("payload = %+v, want the happy profile", payload)
+	}
+	want := TrackSummary{
+		Name:    "Under Pressure",
+		Artists: []string{"Queen", "David Bowie"},
+		URL:     "https://open.spotify.com/track/2",
+		URI:     "spotify:track:2",
+	}
+	if got := payload.Tracks[0]; got.Name != want.Name || !slices.Equal(got.Artists, want.Artists) || got.URL != want.URL || got.URI != want.URI {
+		t.Errorf("track = %+v, want %+v", got, want)
+	}
+}
+
+func TestMarshalRecommendationsNoTracks(t *testing.T) {
+	data, err := MarshalRecommendations(MoodProfile{Mood: "neutral"}, nil)
+	if err != nil {
+		t.Fatalf("MarshalRecommendations: %v", err)
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &payload); err != nil {
+		t.Fatal(err)
+	}
+	// Clients can range over the lists without checking for null
+	if tracks, ok := payload["tracks"].([]interface{}); !ok || len(tracks) != 0 {
+		t.Errorf("tracks = %v, want an empty list", payload["tracks"])
+	}
+	if genres, ok := payload["genres"].([]interface{}); !ok || len(genres) != 0 {
+		t.Errorf("genres = %v, want an empty list", payload["genres"])
+	}
+}
@@ -0,0 +1,34 @@
+package mood
+
+// popularityHint maps words asking for well-known songs or deep cuts to a
+// Spotify popularity range (0-100)
+type popularityHint struct {
+	terms    []string
+	min, max int
+}
+
+// popularityHints are checked in order and only the first one found in a description applies
+var popularityHints = []popularityHint{
+	{
+		terms: []string{"underground", "deep cut", "obscure", "hidden gem", "lesser known", "little known"},
+		min:   0,
+		max:   40,
+	},
+	{
+		terms: []string{"popular", "mainstream", "well known", "hits", "chart", "top 40"},
+		min:   60,
+		max:   100,
+	},
+}
+
+// applyPopularity sets the popularity range for the first popularity hint found in the tokens
+func applyPopularity(profile *MoodProfile, tokens []string) {
+	for _, hint := range popularityHints {
+		if positions, _ := matchTerms(tokens, hint.terms); len(positions) == 0 {
+			continue
+		}
+		profile.MinPopularity = hint.min
+		profile.MaxPopularity = hint.max
+		return
+	}
+}
@@ -0,0 +1,25 @@
+package mood
+
+import "testing"
+
+func TestAnalyzeMoodPopularity(t *testing.T) {
+	analyzer := NewMoodAnalyzer()
+
+	tests := []struct {
+		text             string
+		wantMin, wantMax int
+	}{
+		{"chill underground", 0, 40},
+		{"sad deep cuts", 0, 40},
+		{"happy mainstream hits", 60, 100},
+		{"happy", 0, 0},
+		{"not obscure, just happy", 0, 0},
+	}
+
+	for _, tt := range tests {
+		profile := analyzer.AnalyzeMood(tt.text)
+		if profile.MinPopularity != tt.wantMin || profile.MaxPopularity != tt.wantMax {
+			t.Errorf("AnalyzeMood(%q) popularity = [%d, %d], want [%d, %d]", tt.text, profile.MinPopularity, profile.MaxPopularity, tt.wantMin, tt.wantMax)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package mood
+
+import (
+	"regexp"
+	"strings"
+)
+
+// preamblePattern matches filler that opens most mood descriptions, such as
+// "I feel", "I'm feeling" or "today I am". Only these fixed openings are
+// matched so words that carry meaning, like "not" or "so", are kept.
+var preamblePattern = regexp.MustCompile(`(?i)^\s*(?:(?:today|tonight|right now|honestly|lately),?\s+)?(?:i\s+feel|i\s+am\s+feeling|i'm\s+feeling|im\s+feeling|i've\s+been\s+feeling|i\s+have\s+been\s+feeling|i\s+am|i'm|im|feeling)\b\s*`)
+
+// StripPreamble removes an opening such as "I'm feeling" from a mood description,
+// e.g. "I'm feeling incredibly happy" becomes "incredibly happy". The description
+// is returned unchanged when nothing would be left or the rest starts with "like",
+// as in "I feel like dancing", where the opening changes the meaning.
+func StripPreamble(description string) string {
+	loc := preamblePattern.FindStringIndex(description)
+	if loc == nil {
+		return description
+	}
+
+	rest := description[loc[1]:]
+	if rest == "" || strings.HasPrefix(strings.ToLower(rest), "like ") {
+		return description
+	}
+	return rest
+}
@@ -0,0 +1,53 @@
+package mood
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestStripPreamble(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"I'm feeling incredibly happy", "incredibly happy"},
+		{"I feel sad", "sad"},
+		{"Today, I am so tired", "so tired"},
+		{"honestly i've been feeling down", "down"},
+		{"feeling not great", "not great"},
+		{"im pumped", "pumped"},
+		{"I feel like dancing", "I feel like dancing"},
+		{"I'm", "I'm"},
+		{"happy", "happy"},
+		{"imagine dragons songs", "imagine dragons songs"},
+	}
+
+	for _, tt := range tests {
+		if got := StripPreamble(tt.description); got != tt.want {
+			t.Errorf("StripPreamble(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodIgnoresPreamble(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	tests := []struct {
+		withPreamble string
+		plain        string
+	}{
+		{"I'm feeling incredibly happy", "incredibly happy"},
+		{"I feel happy", "happy"},
+		{"today I am sad and lonely", "sad and lonely"},
+	}
+
+	for _, tt := range tests {
+		got, want := ma.AnalyzeMood(tt.withPreamble), ma.AnalyzeMood(tt.plain)
+		if got.Mood != want.Mood || got.Energy != want.Energy || got.Valence != want.Valence ||
+			got.Danceability != want.Danceability || !slices.Equal(got.MatchedTerms, want.MatchedTerms) {
+			t.Errorf("AnalyzeMood(%q) = %+v, want the same profile as %q: %+v", tt.withPreamble, got, tt.plain, want)
+		}
+		if Confidence(got) != Confidence(want) {
+			t.Errorf("Confidence(%q) = %.2f, want %.2f as for %q", tt.withPreamble, Confidence(got), Confidence(want), tt.plain)
+		}
+	}
+}
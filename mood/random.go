@@ -0,0 +1,25 @@
+package mood
+
+import "math/rand/v2"
+
+// RandomProfile picks a random mood category for "surprise me" recommendations,
+// with its genres shuffled for variety. r supplies the randomness so a seeded
+// source gives reproducible picks; nil uses the global source.
+func (ma *MoodAnalyzer) RandomProfile(r *rand.Rand) MoodProfile {
+	categories := ma.moodCategories()
+	if len(categories) == 0 {
+		return neutralProfile()
+	}
+
+	intN, shuffle := rand.IntN, rand.Shuffle
+	if r != nil {
+		intN, shuffle = r.IntN, r.Shuffle
+	}
+
+	profile := categories[intN(len(categories))].profile()
+	shuffle(len(profile.SuggestedGenres), func(i, j int) {
+		profile.SuggestedGenres[i], profile.SuggestedGenres[j] = profile.SuggestedGenres[j], profile.SuggestedGenres[i]
+	})
+	profile.Detected = true
+	return profile
+}
@@ -0,0 +1,59 @@
+package mood
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+)
+
+func TestRandomProfile(t *testing.T) {
+	ma := NewMoodAnalyzer()
+
+	profile := ma.RandomProfile(rand.New(rand.NewPCG(1, 2)))
+	if !profile.Detected {
+		t.Error("RandomProfile().Detected = false, want true")
+	}
+
+	var category *MoodCategory
+	for _, c := range ma.moodCategories() {
+		if c.Mood == profile.Mood {
+			category = &c
+			break
+		}
+	}
+	if category == nil {
+		t.Fatalf("RandomProfile().Mood = %q, want a known mood", profile.Mood)
+	}
+	if profile.Energy != category.Energy || profile.Valence != category.Valence {
+		t.Errorf("RandomProfile() energy %.2f, valence %.2f, want the %s category's %.2f, %.2f",
+			profile.Energy, profile.Valence, profile.Mood, category.Energy, category.Valence)
+	}
+
+	got, want := slices.Sorted(slices.Values(profile.SuggestedGenres)), slices.Sorted(slices.Values(category.SuggestedGenres))
+	if !slices.Equal(got, want) {
+		t.Errorf("RandomProfile().SuggestedGenres = %v, want a shuffle of %v", profile.SuggestedGenres, category.SuggestedGenres)
+	}
+
+	if again := ma.RandomProfile(rand.New(rand.NewPCG(1, 2))); again.Mood != profile.Mood || !slices.Equal(again.SuggestedGenres, profile.SuggestedGenres) {
+		t.Errorf("RandomProfile() with the same seed = %q %v, want %q %v", again.Mood, again.SuggestedGenres, profile.Mood, profile.SuggestedGenres)
+	}
+}
+
+func TestRandomProfileLeavesCategoriesAlone(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	before := make(map[string][]string)
+	for _, c := range ma.moodCategories() {
+		before[c.Mood] = slices.Clone(c.SuggestedGenres)
+	}
+
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 20 {
+		ma.RandomProfile(r)
+	}
+
+	for _, c := range ma.moodCategories() {
+		if !slices.Equal(c.SuggestedGenres, before[c.Mood]) {
+			t.Errorf("%s genres = %v after shuffling, want %v", c.Mood, c.SuggestedGenres, before[c.Mood])
+		}
+	}
+}
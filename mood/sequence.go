@@ -0,0 +1,90 @@
+package mood
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// sequenceSeparator splits "happy and then relaxed" or "happy, then relaxed" into moods
+var sequenceSeparator = regexp.MustCompile(`(?i)\s*,?\s*\b(?:and\s+)?then\b\s*`)
+
+// SplitMoodSequence splits a description of moods to go through one after the
+// other, such as "happy and then relaxed", into the description of each mood.
+// A description without "then" is returned as its only element.
+func SplitMoodSequence(description string) []string {
+	var parts []string
+	for _, part := range sequenceSeparator.Split(description, -1) {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// MoodSection is the tracks recommended for one mood of a sequence
+type MoodSection struct {
+	Profile MoodProfile
+	Tracks  []TrackSummary
+}
+
+// SequenceName names a sequence of moods, e.g. "happy to relaxed"
+func SequenceName(sections []MoodSection) string {
+	return LocalizedSequenceName(sections, DefaultLocale)
+}
+
+// LocalizedSequenceName is SequenceName in the language of the locale
+func LocalizedSequenceName(sections []MoodSection, locale Locale) string {
+	moods := make([]string, len(sections))
+	for i, section := range sections {
+		moods[i] = section.Profile.Mood
+	}
+	return strings.Join(moods, " "+MessagesFor(locale).SequenceTo+" ")
+}
+
+// FormatSequence renders the recommendations for a sequence of moods, one
+// section per mood in order
+func FormatSequence(sections []MoodSection, format OutputFormat) string {
+	return FormatLocalizedSequence(sections, format, DefaultLocale)
+}
+
+// FormatLocalizedSequence is FormatSequence in the language of the locale
+func FormatLocalizedSequence(sections []MoodSection, format OutputFormat, locale Locale) string {
+	msgs := MessagesFor(locale)
+	var sb strings.Builder
+	if format != FormatMinimal {
+		sb.WriteString(fmt.Sprintf(msgs.SequenceHeader+"\n\n", LocalizedSequenceName(sections, locale)))
+	}
+
+	for i, section := range sections {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		title := fmt.Sprintf(msgs.SequencePart, i+1, section.Profile.Mood)
+		if format == FormatMarkdown {
+			title = "**" + title + "**"
+		}
+		sb.WriteString(title + "\n")
+		WriteLocalizedTrackList(&sb, section.Tracks, format, locale)
+	}
+	return sb.String()
+}
+
+// MarshalSequence encodes the recommendations for a sequence of moods as JSON,
+// one payload per mood in order
+func MarshalSequence(sections []MoodSection, playlistURL string) (string, error) {
+	payloads := make([]RecommendationsPayload, 0, len(sections))
+	for _, section := range sections {
+		payloads = append(payloads, NewRecommendationsPayload(section.Profile, section.Tracks))
+	}
+
+	data, err := json.Marshal(struct {
+		Sections    []RecommendationsPayload `json:"sections"`
+		PlaylistURL string                   `json:"playlist_url,omitempty"`
+	}{payloads, playlistURL})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal mood sequence: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,71 @@
+package mood
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestSplitMoodSequence(t *testing.T) {
+	tests := []struct {
+		description string
+		want        []string
+	}{
+		{"happy and then relaxed", []string{"happy", "relaxed"}},
+		{"pumped, then calm then sleepy", []string{"pumped", "calm", "sleepy"}},
+		{"Happy AND THEN sad", []string{"Happy", "sad"}},
+		{"just happy", []string{"just happy"}},
+		{"authentic vibes", []string{"authentic vibes"}},
+		{"then relaxed", []string{"relaxed"}},
+	}
+
+	for _, tt := range tests {
+		if got := SplitMoodSequence(tt.description); !slices.Equal(got, tt.want) {
+			t.Errorf("SplitMoodSequence(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
+
+func TestFormatSequence(t *testing.T) {
+	sections := []MoodSection{
+		{Profile: MoodProfile{Mood: "happy"}, Tracks: []TrackSummary{testTrack("Happy", "https://open.spotify.com/track/1", "Pharrell Williams")}},
+		{Profile: MoodProfile{Mood: "relaxed"}, Tracks: []TrackSummary{testTrack("Holocene", "https://open.spotify.com/track/2", "Bon Iver")}},
+	}
+
+	if got := SequenceName(sections); got != "happy to relaxed" {
+		t.Errorf("SequenceName() = %q, want %q", got, "happy to relaxed")
+	}
+
+	got := FormatSequence(sections, FormatPlain)
+	want := "Here's a journey from happy to relaxed:\n\n" +
+		"Part 1: happy\n1. 🎵 Happy by Pharrell Williams\n   🔗 https://open.spotify.com/track/1\n" +
+		"\nPart 2: relaxed\n1. 🎵 Holocene by Bon Iver\n   🔗 https://open.spotify.com/track/2\n"
+	if got != want {
+		t.Errorf("FormatSequence() =\n%s\nwant\n%s", got, want)
+	}
+
+	got = FormatLocalizedSequence(sections, FormatPlain, LocaleFrench)
+	want = "Voici un voyage de happy à relaxed :\n\n" +
+		"Partie 1 : happy\n1. 🎵 Happy par Pharrell Williams\n   🔗 https://open.spotify.com/track/1\n" +
+		"\nPartie 2 : relaxed\n1. 🎵 Holocene par Bon Iver\n   🔗 https://open.spotify.com/track/2\n"
+	if got != want {
+		t.Errorf("FormatLocalizedSequence(fr) =\n%s\nwant\n%s", got, want)
+	}
+
+	data, err := MarshalSequence(sections, "https://open.spotify.com/playlist/p1")
+	if err != nil {
+		t.Fatalf("MarshalSequence() error = %v", err)
+	}
+	var decoded struct {
+		Sections []struct {
+			Mood string `json:"mood"`
+		} `json:"sections"`
+		PlaylistURL string `json:"playlist_url"`
+	}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("MarshalSequence() isn't valid JSON: %v", err)
+	}
+	if len(decoded.Sections) != 2 || decoded.Sections[1].Mood != "relaxed" || decoded.PlaylistURL != "https://open.spotify.com/playlist/p1" {
+		t.Errorf("MarshalSequence() = %s, want both sections in order with the playlist", data)
+	}
+}
@@ -0,0 +1,90 @@
+package mood
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// minStemLength is the shortest stem left after stripping a suffix, so short
+// words like "bed" or "sing" aren't cut down to nothing
+const minStemLength = 3
+
+// stemSuffixes are the word endings stripped by stem, with what replaces them.
+// Longer endings come first so "happiness" loses "iness" rather than "ness".
+var stemSuffixes = []struct {
+	suffix      string
+	replacement string
+}{
+	{"iness", "y"}, // happiness -> happy
+	{"ness", ""},   // sadness -> sad
+	{"ily", "y"},   // happily -> happy
+	{"ly", ""},     // calmly -> calm
+	{"ied", "y"},   // worried -> worry
+	{"ed", ""},     // relaxed -> relax
+	{"ing", ""},    // relaxing -> relax
+}
+
+// stemExceptions look like they carry a suffix but stemming them would map
+// them onto an unrelated keyword
+var stemExceptions = map[string]bool{
+	"lovely":  true,
+	"madly":   true,
+	"early":   true,
+	"really":  true,
+	"morning": true,
+	"evening": true,
+	"nothing": true,
+}
+
+// stem strips common inflectional suffixes from a lowercase word so related
+// forms such as "sadness" and "sad" or "relaxing" and "relaxed" compare equal.
+// It reports whether anything was stripped.
+func stem(word string) (string, bool) {
+	if stemExceptions[word] {
+		return word, false
+	}
+
+	stemmed := false
+	for changed := true; changed; {
+		changed = false
+		for _, s := range stemSuffixes {
+			base, ok := strings.CutSuffix(word, s.suffix)
+			if !ok || utf8.RuneCountInString(base) < minStemLength {
+				continue
+			}
+			word = base + s.replacement
+			changed, stemmed = true, true
+			break
+		}
+	}
+
+	// "running" -> "runn" -> "run", but "chilling" stays "chill"
+	if n := len(word); stemmed && n > minStemLength && word[n-1] == word[n-2] && !strings.ContainsRune("aeioulsz", rune(word[n-1])) {
+		word = word[:n-1]
+	}
+	return word, stemmed
+}
+
+// silentEExceptions are words that don't match the inflected forms of a keyword
+// through a lost silent "e": "I love the blues" is about liking something, not
+// about feeling loved
+var silentEExceptions = map[string]bool{
+	"love": true,
+}
+
+// sameStem checks whether two words share a stem. A stripped stem may also
+// have lost a silent "e", so "dancing" matches "dance" and "loved" matches
+// "loving", but silentEExceptions keep their own meaning.
+func sameStem(a, b string) bool {
+	stemA, strippedA := stem(a)
+	stemB, strippedB := stem(b)
+	switch {
+	case stemA == stemB:
+		return true
+	case strippedA && stemA+"e" == stemB && !silentEExceptions[stemB]:
+		return true
+	case strippedB && stemB+"e" == stemA && !silentEExceptions[stemA]:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package mood
+
+import "testing"
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word        string
+		want        string
+		wantStemmed bool
+	}{
+		{"sadness", "sad", true},
+		{"happiness", "happy", true},
+		{"happily", "happy", true},
+		{"relaxing", "relax", true},
+		{"relaxed", "relax", true},
+		{"worried", "worry", true},
+		{"running", "run", true},
+		{"chilling", "chill", true},
+		{"sad", "sad", false},
+		{"bed", "bed", false},
+		{"lovely", "lovely", false},
+		{"morning", "morning", false},
+	}
+
+	for _, tt := range tests {
+		got, stemmed := stem(tt.word)
+		if got != tt.want || stemmed != tt.wantStemmed {
+			t.Errorf("stem(%q) = %q, %v, want %q, %v", tt.word, got, stemmed, tt.want, tt.wantStemmed)
+		}
+	}
+}
+
+func TestSameStem(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"sadness", "sad", true},
+		{"happily", "happy", true},
+		{"relaxing", "relaxed", true},
+		{"dancing", "dance", true},
+		{"celebrated", "celebrate", true},
+		{"loving", "loved", true},
+		{"love", "loved", false},
+		{"loved", "love", false},
+		{"lovely", "loved", false},
+		{"early", "ear", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameStem(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameStem(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodInflections(t *testing.T) {
+	ma := NewMoodAnalyzer()
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"I'm feeling a lot of sadness", "sad"},
+		{"we happily sang along", "happy"},
+		{"just relaxing at home", "relaxed"},
+		{"I feel so loved", "romantic"},
+		{"let's dance", "party"},
+	}
+
+	for _, tt := range tests {
+		if got := ma.AnalyzeMood(tt.text); got.Mood != tt.want || !got.Detected {
+			t.Errorf("AnalyzeMood(%q) = %q, want %q", tt.text, got.Mood, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeMoodLoveIsNotRomantic(t *testing.T) {
+	profile := NewMoodAnalyzer().AnalyzeMood("I love the blues")
+	if profile.Mood != "sad" || profile.Ambiguous {
+		t.Errorf("AnalyzeMood(%q) = %q, ambiguous %v, want sad and not ambiguous", "I love the blues", profile.Mood, profile.Ambiguous)
+	}
+}
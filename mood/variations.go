@@ -0,0 +1,120 @@
+package mood
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MaxVariations is the most variations Variations returns
+const MaxVariations = 3
+
+// Variation is a differently flavoured take on a mood, e.g. an acoustic and an
+// upbeat version of "relaxed"
+type Variation struct {
+	Label   string // "A", "B", ... in the order the variations are given
+	Name    string // what sets the variation apart, e.g. "acoustic"
+	Profile MoodProfile
+}
+
+// variationShift nudges the audio feature targets of a profile in one direction
+type variationShift struct {
+	name             string
+	energy           float32
+	danceability     float32
+	valence          float32
+	acousticness     float32
+	instrumentalness float32 // lowest instrumentalness target, 0 leaves it unchanged
+}
+
+// variationShifts are the takes on a mood offered, in order
+var variationShifts = []variationShift{
+	{name: "acoustic", energy: -0.15, danceability: -0.1, acousticness: 0.35},
+	{name: "upbeat", energy: 0.2, danceability: 0.2, valence: 0.15, acousticness: -0.2},
+	{name: "instrumental", energy: -0.05, instrumentalness: 0.7},
+}
+
+// Variations returns n variations of the profile with their audio feature
+// targets shifted in different directions. n is capped at MaxVariations.
+func Variations(profile MoodProfile, n int) []Variation {
+	n = min(n, len(variationShifts), MaxVariations)
+
+	variations := make([]Variation, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		shift := variationShifts[i]
+		varied := profile
+		varied.Energy = clamp01(profile.Energy + shift.energy)
+		varied.Danceability = clamp01(profile.Danceability + shift.danceability)
+		varied.Valence = clamp01(profile.Valence + shift.valence)
+		varied.Acousticness = clamp01(profile.Acousticness + shift.acousticness)
+		if shift.instrumentalness > profile.Instrumentalness {
+			varied.Instrumentalness = shift.instrumentalness
+		}
+
+		variations = append(variations, Variation{
+			Label:   string(rune('A' + i)),
+			Name:    shift.name,
+			Profile: varied,
+		})
+	}
+	return variations
+}
+
+// VariationTracks are the tracks recommended for one variation
+type VariationTracks struct {
+	Variation Variation
+	Tracks    []TrackSummary
+}
+
+// FormatVariations renders the recommendations of several variations of a mood
+// as labelled groups, "Variation A (acoustic)" and so on
+func FormatVariations(groups []VariationTracks, moodName string, format OutputFormat) string {
+	return FormatLocalizedVariations(groups, moodName, format, DefaultLocale)
+}
+
+// FormatLocalizedVariations is FormatVariations in the language of the locale
+func FormatLocalizedVariations(groups []VariationTracks, moodName string, format OutputFormat, locale Locale) string {
+	msgs := MessagesFor(locale)
+	var sb strings.Builder
+	if format != FormatMinimal {
+		sb.WriteString(fmt.Sprintf(msgs.VariationsHeader+"\n\n", len(groups), moodName))
+	}
+
+	for i, group := range groups {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		title := fmt.Sprintf(msgs.VariationTitle, group.Variation.Label, group.Variation.Name)
+		if format == FormatMarkdown {
+			title = "**" + title + "**"
+		}
+		sb.WriteString(title + "\n")
+		WriteLocalizedTrackList(&sb, group.Tracks, format, locale)
+	}
+	return sb.String()
+}
+
+// VariationPayload is the machine-readable form of one variation's recommendations
+type VariationPayload struct {
+	Variation string `json:"variation"`
+	Name      string `json:"name"`
+	RecommendationsPayload
+}
+
+// MarshalVariations encodes the recommendations of several variations as JSON
+func MarshalVariations(groups []VariationTracks) (string, error) {
+	payloads := make([]VariationPayload, 0, len(groups))
+	for _, group := range groups {
+		payloads = append(payloads, VariationPayload{
+			Variation:              group.Variation.Label,
+			Name:                   group.Variation.Name,
+			RecommendationsPayload: NewRecommendationsPayload(group.Variation.Profile, group.Tracks),
+		})
+	}
+
+	data, err := json.Marshal(map[string][]VariationPayload{"variations": payloads})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal variations: %w", err)
+	}
+	return string(data), nil
+}
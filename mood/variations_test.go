@@ -0,0 +1,95 @@
+package mood
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVariations(t *testing.T) {
+	profile := NewMoodAnalyzer().AnalyzeMood("relaxed")
+
+	variations := Variations(profile, 3)
+	if len(variations) != 3 {
+		t.Fatalf("Variations(3) returned %d variations, want 3", len(variations))
+	}
+
+	wantNames := []string{"acoustic", "upbeat", "instrumental"}
+	for i, v := range variations {
+		if want := string(rune('A' + i)); v.Label != want || v.Name != wantNames[i] {
+			t.Errorf("Variations()[%d] = %s (%s), want %s (%s)", i, v.Label, v.Name, want, wantNames[i])
+		}
+		if v.Profile.Mood != profile.Mood {
+			t.Errorf("Variations()[%d].Profile.Mood = %q, want %q", i, v.Profile.Mood, profile.Mood)
+		}
+	}
+
+	acoustic, upbeat, instrumental := variations[0].Profile, variations[1].Profile, variations[2].Profile
+	if acoustic.Acousticness <= profile.Acousticness || acoustic.Energy >= profile.Energy {
+		t.Errorf("acoustic variation = acousticness %.2f, energy %.2f, want more acoustic and calmer than %.2f, %.2f",
+			acoustic.Acousticness, acoustic.Energy, profile.Acousticness, profile.Energy)
+	}
+	if upbeat.Energy <= profile.Energy || upbeat.Valence <= profile.Valence {
+		t.Errorf("upbeat variation = energy %.2f, valence %.2f, want above %.2f, %.2f", upbeat.Energy, upbeat.Valence, profile.Energy, profile.Valence)
+	}
+	if instrumental.Instrumentalness < 0.7 {
+		t.Errorf("instrumental variation instrumentalness = %.2f, want at least 0.7", instrumental.Instrumentalness)
+	}
+}
+
+func TestVariationsCountAndBounds(t *testing.T) {
+	profile := MoodProfile{Mood: "party", Energy: 0.95, Valence: 0.9, Danceability: 0.9}
+
+	for _, n := range []int{-1, 0, 2, 5} {
+		want := min(max(n, 0), MaxVariations)
+		if got := Variations(profile, n); len(got) != want {
+			t.Errorf("Variations(%d) returned %d variations, want %d", n, len(got), want)
+		}
+	}
+
+	for _, v := range Variations(profile, MaxVariations) {
+		if v.Profile.Energy > 1 || v.Profile.Danceability > 1 || v.Profile.Valence > 1 || v.Profile.Acousticness < 0 {
+			t.Errorf("variation %s = %+v, want targets within [0, 1]", v.Name, v.Profile)
+		}
+	}
+}
+
+func TestFormatVariations(t *testing.T) {
+	variations := Variations(MoodProfile{Mood: "relaxed"}, 2)
+	groups := []VariationTracks{
+		{Variation: variations[0], Tracks: []TrackSummary{testTrack("Holocene", "https://open.spotify.com/track/1", "Bon Iver")}},
+		{Variation: variations[1], Tracks: []TrackSummary{testTrack("Sunday Best", "https://open.spotify.com/track/2", "Surfaces")}},
+	}
+
+	got := FormatVariations(groups, "relaxed", FormatPlain)
+	want := "Here are 2 takes on your relaxed mood:\n\n" +
+		"Variation A (acoustic)\n1. 🎵 Holocene by Bon Iver\n   🔗 https://open.spotify.com/track/1\n" +
+		"\nVariation B (upbeat)\n1. 🎵 Sunday Best by Surfaces\n   🔗 https://open.spotify.com/track/2\n"
+	if got != want {
+		t.Errorf("FormatVariations() =\n%s\nwant\n%s", got, want)
+	}
+
+	if got := FormatVariations(groups, "relaxed", FormatMarkdown); !strings.Contains(got, "**Variation A (acoustic)**") {
+		t.Errorf("FormatVariations(markdown) = %q, want bold titles", got)
+	}
+	if got := FormatLocalizedVariations(groups, "relaxed", FormatPlain, LocaleSpanish); !strings.HasPrefix(got, "Aquí tienes 2 versiones de tu estado de ánimo relaxed:\n\nVariación A (acoustic)\n") {
+		t.Errorf("FormatLocalizedVariations(es) = %q, want a Spanish header and titles", got)
+	}
+
+	data, err := MarshalVariations(groups)
+	if err != nil {
+		t.Fatalf("MarshalVariations() error = %v", err)
+	}
+	var decoded struct {
+		Variations []struct {
+			Variation string `json:"variation"`
+			Name      string `json:"name"`
+		} `json:"variations"`
+	}
+	if err := json.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatalf("MarshalVariations() isn't valid JSON: %v", err)
+	}
+	if len(decoded.Variations) != 2 || decoded.Variations[1].Variation != "B" || decoded.Variations[1].Name != "upbeat" {
+		t.Errorf("MarshalVariations() = %s, want labelled variations", data)
+	}
+}
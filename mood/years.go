@@ -0,0 +1,108 @@
+package mood
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Release years outside this range aren't read as years, so counts and other
+// numbers in a description aren't mistaken for one
+const (
+	minReleaseYear = 1900
+	maxReleaseYear = 2099
+)
+
+// yearRangeWords join the two years of a range, as in "from 1995 to 2000"
+var yearRangeWords = []string{"to", "through", "thru", "until", "till"}
+
+// apostropheDecadePattern finds two-digit decades written with an apostrophe, as in "'90s"
+var apostropheDecadePattern = regexp.MustCompile(`(?i)(?:^|[^\w'])'(\d0s)\b`)
+
+// decadeCues near a two-digit decade such as "90s" show it is about music, not
+// an age as in "in my 30s". Genres count as cues too.
+var decadeCues = map[string]bool{
+	"music": true, "songs": true, "song": true, "tracks": true, "tunes": true,
+	"hits": true, "classics": true, "throwback": true, "throwbacks": true, "oldies": true,
+	"vibes": true, "playlist": true, "era": true, "style": true, "nostalgic": true, "nostalgia": true,
+}
+
+// decadeCueWindow is how many words before a two-digit decade may hold a cue,
+// as in "songs from the 90s". The word right after it counts as well.
+const decadeCueWindow = 3
+
+// DecadeYears returns the range of years in a decade such as "1990s" in the
+// form Spotify's year: search filter takes, e.g. "1990-1999"
+func DecadeYears(decade string) (string, bool) {
+	start, err := strconv.Atoi(strings.TrimSuffix(decade, "s"))
+	if err != nil || !strings.HasSuffix(decade, "s") || start%10 != 0 || !isReleaseYear(start) {
+		return "", false
+	}
+	return fmt.Sprintf("%d-%d", start, start+9), true
+}
+
+// isReleaseYear reports whether n is a plausible release year
+func isReleaseYear(n int) bool {
+	return n >= minReleaseYear && n <= maxReleaseYear
+}
+
+// parseReleaseYear returns the year a token names, if it is one
+func parseReleaseYear(token string) (int, bool) {
+	if len(token) != 4 {
+		return 0, false
+	}
+	year, err := strconv.Atoi(token)
+	return year, err == nil && isReleaseYear(year)
+}
+
+// extractYearRange returns the release years asked for in the description and
+// its tokens as a year: filter value: a decade ("90s" is "1990-1999"), a single
+// year ("2015") or a range of years ("1995-2000", "from 1995 to 2000"). A
+// two-digit decade only counts with an apostrophe ("'90s") or a music cue
+// nearby ("90s hits"). The first mention wins; it returns an empty string when
+// there is none.
+func extractYearRange(description string, tokens []string) string {
+	apostrophes := make(map[string]bool)
+	for _, match := range apostropheDecadePattern.FindAllStringSubmatch(description, -1) {
+		apostrophes[strings.ToLower(match[1])] = true
+	}
+
+	for i, token := range tokens {
+		if decade := extractDecade(tokens[i : i+1]); decade != "" {
+			if len(token) > len("90s") || apostrophes[token] || hasDecadeCue(tokens, i) {
+				years, _ := DecadeYears(decade)
+				return years
+			}
+			continue
+		}
+
+		start, ok := parseReleaseYear(token)
+		if !ok {
+			continue
+		}
+		next := i + 1
+		if next < len(tokens) && slices.Contains(yearRangeWords, tokens[next]) {
+			next++
+		}
+		if next < len(tokens) {
+			if end, ok := parseReleaseYear(tokens[next]); ok {
+				return fmt.Sprintf("%d-%d", min(start, end), max(start, end))
+			}
+		}
+		return strconv.Itoa(start)
+	}
+	return ""
+}
+
+// hasDecadeCue reports whether a music cue is near the decade at tokens[i]
+func hasDecadeCue(tokens []string, i int) bool {
+	nearby := tokens[max(0, i-decadeCueWindow):min(len(tokens), i+2)]
+	for _, token := range nearby {
+		if decadeCues[token] {
+			return true
+		}
+	}
+	return len(extractGenres(nearby)) > 0
+}
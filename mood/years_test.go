@@ -0,0 +1,58 @@
+package mood
+
+import "testing"
+
+func TestDecadeYears(t *testing.T) {
+	tests := []struct {
+		decade string
+		want   string
+		wantOK bool
+	}{
+		{"1990s", "1990-1999", true},
+		{"2000s", "2000-2009", true},
+		{"1900s", "1900-1909", true},
+		{"1995s", "", false},
+		{"1850s", "", false},
+		{"1990", "", false},
+		{"90s", "", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := DecadeYears(tt.decade)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("DecadeYears(%q) = %q, %v, want %q, %v", tt.decade, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestExtractYearRange(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"nostalgic 90s vibes", "1990-1999"},
+		{"some '80s throwbacks", "1980-1989"},
+		{"sad songs from 2015", "2015"},
+		{"hits 1995-2000", "1995-2000"},
+		{"from 1995 to 2000", "1995-2000"},
+		{"2000 through 1995", "1995-2000"},
+		{"2015 and then some", "2015"},
+		{"90s hits or 2015", "1990-1999"},
+		{"90s or 2015", "2015"},
+		{"'90s", "1990-1999"},
+		{"songs from the 80s", "1980-1989"},
+		{"90s rock", "1990-1999"},
+		{"in my 30s", ""},
+		{"happy in my 30s and love 80s pop", "1980-1989"},
+		{"2015 or the 90s", "2015"},
+		{"top 40 1850 hits", ""},
+		{"no years here", ""},
+	}
+
+	for _, tt := range tests {
+		if got := extractYearRange(tt.description, tokenize(tt.description)); got != tt.want {
+			t.Errorf("extractYearRange(%q) = %q, want %q", tt.description, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Artist represents a Spotify artist
+type Artist struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Genres       []string `json:"genres"`
+	Popularity   int      `json:"popularity"`
+	ExternalURLs struct {
+		Spotify string `json:"spotify"`
+	} `json:"external_urls"`
+}
+
+// SearchArtists searches for artists matching the query, best match first
+func (c *Client) SearchArtists(ctx context.Context, query string, limit int) ([]Artist, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("type", "artist")
+	params.Set("limit", fmt.Sprintf("%d", limit))
+
+	resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search artists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("search artists", resp)
+	}
+
+	var result struct {
+		Artists struct {
+			Items []Artist `json:"items"`
+		} `json:"artists"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode artist search response: %w", err)
+	}
+
+	return result.Artists.Items, nil
+}
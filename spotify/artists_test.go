@@ -0,0 +1,46 @@
+package spotify
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestSearchArtists(t *testing.T) {
+	var query, searchType, limit string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query, searchType, limit = r.URL.Query().Get("q"), r.URL.Query().Get("type"), r.URL.Query().Get("limit")
+		writeJSON(w, `{"artists":{"items":[
+			{"id":"a1","name":"Adele","genres":["british soul","pop"],"popularity":85,"external_urls":{"spotify":"https://open.spotify.com/artist/a1"}},
+			{"id":"a2","name":"Adele Tribute","genres":[]}
+		]}}`)
+	})
+
+	artists, err := c.SearchArtists(context.Background(), "adele", 2)
+	if err != nil {
+		t.Fatalf("SearchArtists() error = %v", err)
+	}
+	if query != "adele" || searchType != "artist" || limit != "2" {
+		t.Errorf("searched q=%q type=%q limit=%q, want adele, artist, 2", query, searchType, limit)
+	}
+	if len(artists) != 2 {
+		t.Fatalf("SearchArtists() returned %d artists, want 2", len(artists))
+	}
+
+	got := artists[0]
+	if got.ID != "a1" || got.Name != "Adele" || !slices.Equal(got.Genres, []string{"british soul", "pop"}) ||
+		got.Popularity != 85 || got.ExternalURLs.Spotify != "https://open.spotify.com/artist/a1" {
+		t.Errorf("SearchArtists()[0] = %+v, want the decoded artist", got)
+	}
+}
+
+func TestSearchArtistsError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	if _, err := c.SearchArtists(context.Background(), "adele", 1); err == nil {
+		t.Error("SearchArtists() error = nil, want an error")
+	}
+}
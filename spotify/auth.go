@@ -0,0 +1,313 @@
+package spotify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// DefaultUserScopes are the scopes the agent needs to act on behalf of a user
+var DefaultUserScopes = []string{
+	"playlist-modify-private",
+	"playlist-modify-public",
+	"user-read-private",
+	"user-top-read",
+	"user-read-currently-playing",
+	"user-modify-playback-state",
+	"ugc-image-upload",
+	"user-library-modify",
+	"user-library-read",
+}
+
+// tokenResponse is the response of the Spotify token endpoint
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+}
+
+// Authenticate gets an access token from Spotify
+func (c *Client) Authenticate(ctx context.Context) error {
+	data := url.Values{}
+
+	// Check if we have a refresh token, falling back to the environment
+	c.mu.RLock()
+	refreshToken := c.refreshToken
+	c.mu.RUnlock()
+	if refreshToken == "" {
+		refreshToken = os.Getenv("SPOTIFY_REFRESH_TOKEN")
+	}
+
+	if refreshToken != "" {
+		c.logger().Debug("Using refresh token for user authentication")
+		data.Set("grant_type", "refresh_token")
+		data.Set("refresh_token", refreshToken)
+	} else {
+		c.logger().Info("No refresh token found, using client credentials (limited API access)")
+		data.Set("grant_type", "client_credentials")
+	}
+
+	return c.requestToken(ctx, data)
+}
+
+// ExchangeCode exchanges an authorization code from the consent redirect for
+// user access and refresh tokens
+func (c *Client) ExchangeCode(ctx context.Context, code, redirectURI string) error {
+	data := url.Values{}
+	data.Set("grant_type", "authorization_code")
+	data.Set("code", code)
+	data.Set("redirect_uri", redirectURI)
+
+	return c.requestToken(ctx, data)
+}
+
+// requestToken requests a token from the Spotify token endpoint and stores it
+func (c *Client) requestToken(ctx context.Context, data url.Values) error {
+	auth := base64.StdEncoding.EncodeToString([]byte(c.clientID + ":" + c.clientSecret))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.tokenURL(), strings.NewReader(data.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create auth request: %w", err)
+	}
+
+	req.Header.Add("Authorization", "Basic "+auth)
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.send(req)
+	if err != nil {
+		return fmt.Errorf("failed to authenticate: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError("auth", resp)
+	}
+
+	var result tokenResponse
+	err = decodeJSON(resp, &result)
+	if err != nil {
+		return fmt.Errorf("failed to decode auth response: %w", err)
+	}
+
+	if result.AccessToken == "" {
+		return fmt.Errorf("access token not found in response")
+	}
+
+	// Log the scope we received
+	if result.Scope != "" {
+		c.logger().Debug("Authenticated", "scopes", result.Scope)
+	}
+
+	var expiry time.Time
+	if result.ExpiresIn > 0 {
+		expiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	}
+
+	grantType := data.Get("grant_type")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accessToken = result.AccessToken
+	c.tokenExpiry = expiry
+	c.userToken = grantType == "refresh_token" || grantType == "authorization_code"
+	if result.RefreshToken != "" {
+		c.refreshToken = result.RefreshToken
+	} else if grantType == "refresh_token" {
+		c.refreshToken = data.Get("refresh_token")
+	}
+	return nil
+}
+
+// RefreshToken returns the refresh token of a user-authenticated client, if any
+func (c *Client) RefreshToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.refreshToken
+}
+
+// CanActAsUser reports whether the client acts for a Spotify user, having a
+// refresh token or a token from the Authorization Code flow. With only client
+// credentials, user endpoints such as playlists, Liked Songs and playback fail.
+func (c *Client) CanActAsUser() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.userToken || c.refreshToken != ""
+}
+
+// AuthorizeInteractive runs the Authorization Code flow: it starts a temporary
+// HTTP server on the host and port of redirectURI (which must be registered for
+// the app and have a callback path such as /callback), opens the consent page in a browser and exchanges the returned code
+// for user access and refresh tokens. It waits until the user responds or ctx is done.
+func (c *Client) AuthorizeInteractive(ctx context.Context, scopes []string, redirectURI string) error {
+	redirect, err := url.Parse(redirectURI)
+	if err != nil {
+		return fmt.Errorf("invalid redirect URI: %w", err)
+	}
+	if redirect.Path == "" {
+		return fmt.Errorf("invalid redirect URI %q: missing a callback path such as /callback", redirectURI)
+	}
+
+	state, err := randomState()
+	if err != nil {
+		return fmt.Errorf("failed to generate state: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", redirect.Host)
+	if err != nil {
+		return fmt.Errorf("failed to start callback server: %w", err)
+	}
+
+	type callbackResult struct {
+		code string
+		err  error
+	}
+	results := make(chan callbackResult, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(redirect.Path, func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		// Only the callback itself counts: a "/" path would otherwise also get
+		// stray requests such as /favicon.ico, and those must not use up the
+		// one result the flow waits for
+		if r.URL.Path != redirect.Path || (query.Get("state") == "" && query.Get("code") == "") {
+			http.NotFound(w, r)
+			return
+		}
+
+		var result callbackResult
+		switch {
+		case query.Get("state") != state:
+			result.err = errors.New("authorization state mismatch")
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization denied: %s", query.Get("error"))
+		case query.Get("code") == "":
+			result.err = errors.New("authorization code missing from callback")
+		default:
+			result.code = query.Get("code")
+		}
+
+		if result.err != nil {
+			http.Error(w, "Authorization failed, you can close this window.", http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "Authorization complete, you can close this window.")
+		}
+
+		select {
+		case results <- result:
+		default:
+		}
+	})
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(listener)
+	defer server.Close()
+
+	consentURL := c.AuthorizeURL(scopes, redirectURI, state)
+	c.logger().Info("Open this URL to authorize the agent", "url", consentURL)
+	if err := openBrowser(consentURL); err != nil {
+		c.logger().Warn("Could not open a browser", "error", err)
+	}
+
+	select {
+	case result := <-results:
+		if result.err != nil {
+			return result.err
+		}
+		return c.ExchangeCode(ctx, result.code, redirectURI)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// AuthorizeURL builds the Spotify consent page URL for the Authorization Code flow
+func (c *Client) AuthorizeURL(scopes []string, redirectURI, state string) string {
+	params := url.Values{}
+	params.Set("client_id", c.clientID)
+	params.Set("response_type", "code")
+	params.Set("redirect_uri", redirectURI)
+	params.Set("scope", strings.Join(scopes, " "))
+	params.Set("state", state)
+	return c.authorizeURL() + "?" + params.Encode()
+}
+
+// randomState returns a random value to protect the authorization callback against forgery
+func randomState() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// openBrowser opens a URL in the user's default browser
+func openBrowser(target string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", target).Start()
+	case "darwin":
+		return exec.Command("open", target).Start()
+	default:
+		return exec.Command("xdg-open", target).Start()
+	}
+}
+
+// token returns a valid access token, refreshing it first if it has expired
+func (c *Client) token(ctx context.Context) (string, error) {
+	token, valid := c.currentToken()
+	if token == "" {
+		return "", ErrNotAuthenticated
+	}
+	if valid {
+		return token, nil
+	}
+
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	// Another request may have refreshed the token while we waited
+	if token, valid := c.currentToken(); valid {
+		return token, nil
+	}
+
+	c.logger().Debug("Access token expired, refreshing")
+	if err := c.Authenticate(ctx); err != nil {
+		return "", fmt.Errorf("failed to refresh access token: %w", err)
+	}
+
+	token, _ = c.currentToken()
+	return token, nil
+}
+
+// reauthenticate gets a new access token after Spotify rejected the stale one,
+// unless another request has already replaced it
+func (c *Client) reauthenticate(ctx context.Context, stale string) error {
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+
+	if token, valid := c.currentToken(); valid && token != stale {
+		return nil
+	}
+	return c.Authenticate(ctx)
+}
+
+// currentToken returns the stored access token and whether it is still valid.
+// Tokens are treated as expired slightly early so they don't lapse mid-request.
+func (c *Client) currentToken() (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	valid := c.accessToken != "" && (c.tokenExpiry.IsZero() || time.Now().Add(tokenExpiryMargin).Before(c.tokenExpiry))
+	return c.accessToken, valid
+}
@@ -0,0 +1,299 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tokenIssuer is a mock token endpoint handing out "token-1", "token-2", ...
+type tokenIssuer struct {
+	issued atomic.Int32
+}
+
+func (ti *tokenIssuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := ti.issued.Add(1)
+	writeJSON(w, fmt.Sprintf(`{"access_token":"token-%d","expires_in":3600}`, n))
+}
+
+// newIssuingTestServer starts a mock Spotify server whose token endpoint is
+// issuer; every other request is passed to handler
+func newIssuingTestServer(t *testing.T, issuer *tokenIssuer, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.Handle("/token", issuer)
+	mux.Handle("/", handler)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestExpiredTokenIsRefreshedBeforeRequest(t *testing.T) {
+	issuer := &tokenIssuer{}
+	var gotAuth string
+	srv := newIssuingTestServer(t, issuer, func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	c.mu.Lock()
+	c.tokenExpiry = time.Now().Add(-time.Minute)
+	c.mu.Unlock()
+
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+
+	if got := issuer.issued.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want 2", got)
+	}
+	if gotAuth != "Bearer token-2" {
+		t.Errorf("request sent with Authorization %q, want the refreshed token", gotAuth)
+	}
+}
+
+func TestTokenWithinExpiryMarginIsRefreshed(t *testing.T) {
+	issuer := &tokenIssuer{}
+	srv := newIssuingTestServer(t, issuer, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	c.mu.Lock()
+	c.tokenExpiry = time.Now().Add(tokenExpiryMargin / 2)
+	c.mu.Unlock()
+
+	token, err := c.token(context.Background())
+	if err != nil {
+		t.Fatalf("token() error = %v", err)
+	}
+	if token != "token-2" {
+		t.Errorf("token() = %q, want a refreshed token", token)
+	}
+}
+
+func TestValidTokenIsNotRefreshed(t *testing.T) {
+	issuer := &tokenIssuer{}
+	srv := newIssuingTestServer(t, issuer, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if got := issuer.issued.Load(); got != 1 {
+		t.Errorf("token endpoint called %d times, want 1", got)
+	}
+}
+
+func TestTokenWithoutAuthenticating(t *testing.T) {
+	c := NewClient("id", "secret")
+	if _, err := c.token(context.Background()); !errors.Is(err, ErrNotAuthenticated) {
+		t.Errorf("token() error = %v, want ErrNotAuthenticated", err)
+	}
+}
+
+func TestExchangeCode(t *testing.T) {
+	var form url.Values
+	var user, pass string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, _ = r.BasicAuth()
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parsing token request: %v", err)
+		}
+		form = r.PostForm
+		writeJSON(w, `{"access_token":"user-token","refresh_token":"refresh-1","expires_in":3600,"scope":"playlist-modify-private"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.ExchangeCode(context.Background(), "auth-code", "http://127.0.0.1:8888/callback"); err != nil {
+		t.Fatalf("ExchangeCode() error = %v", err)
+	}
+
+	if user != "client-id" || pass != "client-secret" {
+		t.Errorf("token request credentials = %q:%q, want the client's", user, pass)
+	}
+	wantForm := map[string]string{
+		"grant_type":   "authorization_code",
+		"code":         "auth-code",
+		"redirect_uri": "http://127.0.0.1:8888/callback",
+	}
+	for key, want := range wantForm {
+		if got := form.Get(key); got != want {
+			t.Errorf("token request %s = %q, want %q", key, got, want)
+		}
+	}
+
+	if got := c.RefreshToken(); got != "refresh-1" {
+		t.Errorf("RefreshToken() = %q, want %q", got, "refresh-1")
+	}
+	if !c.CanActAsUser() {
+		t.Error("CanActAsUser() = false after exchanging a code, want true")
+	}
+	if token, valid := c.currentToken(); token != "user-token" || !valid {
+		t.Errorf("currentToken() = %q, %v, want the exchanged token", token, valid)
+	}
+}
+
+func TestExchangeCodeRejected(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":"invalid_grant"}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := newUnauthenticatedClient(t, srv)
+	err := c.ExchangeCode(context.Background(), "stale-code", "http://127.0.0.1:8888/callback")
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ExchangeCode() error = %v, want a 400 *APIError", err)
+	}
+	if c.CanActAsUser() {
+		t.Error("CanActAsUser() = true after a rejected exchange, want false")
+	}
+}
+
+func TestAuthorizeURL(t *testing.T) {
+	c := NewClient("client-id", "secret")
+	c.AuthorizeBaseURL = "https://accounts.example/authorize"
+
+	got, err := url.Parse(c.AuthorizeURL([]string{"playlist-modify-private", "user-top-read"}, "http://127.0.0.1:8888/callback", "xyz"))
+	if err != nil {
+		t.Fatalf("AuthorizeURL() is not a URL: %v", err)
+	}
+
+	if base := got.Scheme + "://" + got.Host + got.Path; base != c.AuthorizeBaseURL {
+		t.Errorf("AuthorizeURL() base = %q, want %q", base, c.AuthorizeBaseURL)
+	}
+	want := map[string]string{
+		"client_id":     "client-id",
+		"response_type": "code",
+		"redirect_uri":  "http://127.0.0.1:8888/callback",
+		"scope":         "playlist-modify-private user-top-read",
+		"state":         "xyz",
+	}
+	for key, value := range want {
+		if got := got.Query().Get(key); got != value {
+			t.Errorf("AuthorizeURL() %s = %q, want %q", key, got, value)
+		}
+	}
+}
+
+func TestAuthorizeInteractiveRequiresCallbackPath(t *testing.T) {
+	c := NewClient("id", "secret")
+	for _, redirectURI := range []string{"http://127.0.0.1:0", "http://127.0.0.1:0?next=1"} {
+		err := c.AuthorizeInteractive(context.Background(), DefaultUserScopes, redirectURI)
+		if err == nil || !strings.Contains(err.Error(), "callback path") {
+			t.Errorf("AuthorizeInteractive(%q) error = %v, want a missing callback path error", redirectURI, err)
+		}
+	}
+}
+
+func TestConcurrentRequestsDuringRefresh(t *testing.T) {
+	issuer := &tokenIssuer{}
+	srv := newIssuingTestServer(t, issuer, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+
+	c := newUnauthenticatedClient(t, srv)
+	c.SearchCacheSize = 0
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	c.mu.Lock()
+	c.tokenExpiry = time.Now().Add(-time.Minute)
+	c.mu.Unlock()
+
+	const workers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, workers+1)
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.SearchTracks(context.Background(), fmt.Sprintf("query %d", i), 5)
+			errs <- err
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs <- c.reauthenticate(context.Background(), "token-1")
+	}()
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("concurrent request error = %v", err)
+		}
+	}
+
+	// Whichever goroutine refreshes first, the others find the new token
+	if got := issuer.issued.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want the expired token refreshed once", got)
+	}
+}
+
+func TestCanActAsUser(t *testing.T) {
+	tests := []struct {
+		name         string
+		refreshToken string
+		want         bool
+	}{
+		{"client credentials", "", false},
+		{"refresh token", "refresh-1", true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("SPOTIFY_REFRESH_TOKEN", "")
+		var grantType string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := r.ParseForm(); err != nil {
+				t.Errorf("parsing token request: %v", err)
+			}
+			grantType = r.PostForm.Get("grant_type")
+			writeJSON(w, `{"access_token":"token-1","expires_in":3600}`)
+		}))
+		t.Cleanup(srv.Close)
+
+		c := newUnauthenticatedClient(t, srv)
+		c.refreshToken = tt.refreshToken
+		if c.CanActAsUser() != tt.want {
+			t.Errorf("%s: CanActAsUser() = %v before authenticating, want %v", tt.name, !tt.want, tt.want)
+		}
+		if err := c.Authenticate(context.Background()); err != nil {
+			t.Fatalf("%s: Authenticate() error = %v", tt.name, err)
+		}
+		if got := c.CanActAsUser(); got != tt.want {
+			t.Errorf("%s: CanActAsUser() = %v after a %s grant, want %v", tt.name, got, grantType, tt.want)
+		}
+	}
+}
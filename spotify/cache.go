@@ -0,0 +1,75 @@
+package spotify
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	defaultSearchCacheTTL  = 5 * time.Minute
+	defaultSearchCacheSize = 100
+)
+
+// searchCache remembers recent search results so repeated identical searches
+// don't hit the API again. The zero value is an empty cache.
+type searchCache struct {
+	mu      sync.Mutex
+	entries map[string]searchCacheEntry
+}
+
+type searchCacheEntry struct {
+	tracks  []Track
+	expires time.Time
+}
+
+// get returns the cached tracks for key if they haven't expired
+func (sc *searchCache) get(key string) ([]Track, bool) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, ok := sc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(sc.entries, key)
+		return nil, false
+	}
+	return slices.Clone(entry.tracks), true
+}
+
+// put caches tracks under key for ttl. When the cache holds maxSize entries,
+// expired entries are dropped first and then the one closest to expiring.
+func (sc *searchCache) put(key string, tracks []Track, ttl time.Duration, maxSize int) {
+	if ttl <= 0 || maxSize <= 0 {
+		return
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.entries == nil {
+		sc.entries = make(map[string]searchCacheEntry)
+	}
+
+	now := time.Now()
+	if _, exists := sc.entries[key]; !exists && len(sc.entries) >= maxSize {
+		for k, entry := range sc.entries {
+			if now.After(entry.expires) {
+				delete(sc.entries, k)
+			}
+		}
+		for len(sc.entries) >= maxSize {
+			var oldest string
+			for k, entry := range sc.entries {
+				if oldest == "" || entry.expires.Before(sc.entries[oldest].expires) {
+					oldest = k
+				}
+			}
+			delete(sc.entries, oldest)
+		}
+	}
+
+	sc.entries[key] = searchCacheEntry{tracks: slices.Clone(tracks), expires: now.Add(ttl)}
+}
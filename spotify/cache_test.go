@@ -0,0 +1,106 @@
+package spotify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSearchCacheHit(t *testing.T) {
+	var requests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1"}]}}`)
+	})
+
+	for range 2 {
+		tracks, err := c.SearchTracks(context.Background(), "happy", 5)
+		if err != nil {
+			t.Fatalf("SearchTracks() error = %v", err)
+		}
+		if len(tracks) != 1 || tracks[0].ID != "t1" {
+			t.Errorf("SearchTracks() = %+v, want the cached track", tracks)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1", requests)
+	}
+
+	// A different limit or market is a different search
+	if _, err := c.SearchTracks(context.Background(), "happy", 10); err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	c.Market = "GB"
+	if _, err := c.SearchTracks(context.Background(), "happy", 10); err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("server got %d requests, want 3", requests)
+	}
+}
+
+func TestSearchCacheDisabled(t *testing.T) {
+	for name, disable := range map[string]func(c *Client){
+		"zero TTL":  func(c *Client) { c.SearchCacheTTL = 0 },
+		"zero size": func(c *Client) { c.SearchCacheSize = 0 },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var requests int
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				writeJSON(w, `{"tracks":{"items":[]}}`)
+			})
+			disable(c)
+
+			for range 2 {
+				if _, err := c.SearchTracks(context.Background(), "happy", 5); err != nil {
+					t.Fatalf("SearchTracks() error = %v", err)
+				}
+			}
+			if requests != 2 {
+				t.Errorf("server got %d requests, want 2", requests)
+			}
+		})
+	}
+}
+
+func TestSearchCacheExpiry(t *testing.T) {
+	var sc searchCache
+	sc.put("expired", []Track{{ID: "t1"}}, time.Nanosecond, 10)
+	time.Sleep(time.Millisecond)
+
+	if _, ok := sc.get("expired"); ok {
+		t.Error("get() of an expired entry reported true")
+	}
+}
+
+func TestSearchCacheEviction(t *testing.T) {
+	var sc searchCache
+	sc.put("first", []Track{{ID: "1"}}, time.Minute, 2)
+	sc.put("second", []Track{{ID: "2"}}, 2*time.Minute, 2)
+	sc.put("third", []Track{{ID: "3"}}, 3*time.Minute, 2)
+
+	if _, ok := sc.get("first"); ok {
+		t.Error("the entry closest to expiring was kept past the size limit")
+	}
+	for _, key := range []string{"second", "third"} {
+		if _, ok := sc.get(key); !ok {
+			t.Errorf("get(%q) = false, want the entry kept", key)
+		}
+	}
+}
+
+func TestSearchCacheReturnsCopies(t *testing.T) {
+	var sc searchCache
+	tracks := []Track{{ID: "t1"}}
+	sc.put("key", tracks, time.Minute, 10)
+	tracks[0].ID = "changed"
+
+	cached, _ := sc.get("key")
+	cached[0].ID = "changed too"
+
+	if again, _ := sc.get("key"); again[0].ID != "t1" {
+		t.Errorf("cached track ID = %q, want it unaffected by callers", again[0].ID)
+	}
+}
@@ -1,36 +1,172 @@
 package spotify
 
 import (
-	"bytes"
-	"encoding/base64"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
 )
 
 const (
-	spotifyAuthURL   = "https://accounts.spotify.com/api/token"
-	spotifySearchURL = "https://api.spotify.com/v1/search"
-	spotifyAPIURL    = "https://api.spotify.com/v1"
+	defaultTokenURL     = "https://accounts.spotify.com/api/token"
+	defaultAuthorizeURL = "https://accounts.spotify.com/authorize"
+	defaultAPIURL       = "https://api.spotify.com/v1"
+
+	// tokenExpiryMargin is how long before its expiry an access token is refreshed
+	tokenExpiryMargin = 30 * time.Second
+
+	// maxSearchResults is how deep Spotify lets a search be paged
+	maxSearchResults = 1000
+
+	// maxPlaylistTracksPerRequest is the most tracks Spotify accepts in one playlist request
+	maxPlaylistTracksPerRequest = 100
+
+	defaultMaxRetries        = 3
+	defaultMaxNetworkRetries = 2
+	defaultRetryBackoff      = time.Second
+	defaultMaxRetryDelay     = 30 * time.Second
+	defaultTimeout           = 15 * time.Second
 )
 
+// MaxSearchLimit is the most tracks Spotify returns from one search request
+const MaxSearchLimit = 50
+
+// MaxSeeds is the most seed tracks, artists and genres combined that Spotify
+// accepts in one recommendations request
+const MaxSeeds = 5
+
+// MaxRecommendationsLimit is the most tracks Spotify returns from one recommendations request
+const MaxRecommendationsLimit = 100
+
 // Track represents a Spotify track
 type Track struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
 	Artists []struct {
+		ID   string `json:"id"`
 		Name string `json:"name"`
 	} `json:"artists"`
 	ExternalURLs struct {
 		Spotify string `json:"spotify"`
 	} `json:"external_urls"`
+	Album      Album  `json:"album"`
+	DurationMs int    `json:"duration_ms"`
+	Popularity int    `json:"popularity"` // 0-100, higher for tracks played more recently and often
 	PreviewURL string `json:"preview_url"`
 	URI        string `json:"uri"`
+
+	// AvailableMarkets are the country codes the track can be played in. Spotify
+	// leaves them out when a request names a market and filters for it instead.
+	AvailableMarkets []string `json:"available_markets"`
+}
+
+// Album represents the album a Spotify track appears on
+type Album struct {
+	Name   string  `json:"name"`
+	Images []Image `json:"images"`
+}
+
+// Image represents cover art, widest first as returned by Spotify
+type Image struct {
+	URL    string `json:"url"`
+	Height int    `json:"height"`
+	Width  int    `json:"width"`
+}
+
+// BestImage returns the smallest album image at least minWidth wide, or the
+// widest one when none is that wide. It reports false when the album has no images.
+func (a Album) BestImage(minWidth int) (Image, bool) {
+	var best Image
+	found := false
+	for _, image := range a.Images {
+		switch {
+		case !found:
+			best, found = image, true
+		case best.Width < minWidth && image.Width > best.Width:
+			// Nothing so far is wide enough, so the wider the better
+			best = image
+		case image.Width >= minWidth && image.Width < best.Width:
+			best = image
+		}
+	}
+	return best, found
+}
+
+// ArtistNames returns the names of all artists on the track
+func (t Track) ArtistNames() []string {
+	names := make([]string, 0, len(t.Artists))
+	for _, artist := range t.Artists {
+		names = append(names, artist.Name)
+	}
+	return names
+}
+
+// key identifies a track for deduplication, by ID or else by name and artists
+func (t Track) key() string {
+	if t.ID != "" {
+		return t.ID
+	}
+	return strings.ToLower(t.Name + "|" + strings.Join(t.ArtistNames(), ","))
+}
+
+// DedupeTracks removes repeated tracks, keeping the first occurrence of each.
+// Tracks are matched by ID, or by name and artists when they have no ID.
+func DedupeTracks(tracks []Track) []Track {
+	seen := make(map[string]bool, len(tracks))
+	unique := make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		key := track.key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, track)
+	}
+	return unique
+}
+
+// FilterByPopularity keeps the tracks whose popularity is within [min, max]
+func FilterByPopularity(tracks []Track, min, max int) []Track {
+	filtered := make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		if track.Popularity >= min && track.Popularity <= max {
+			filtered = append(filtered, track)
+		}
+	}
+	return filtered
+}
+
+// FilterByMarket keeps the tracks that are available in market. Tracks that
+// don't list their markets are kept, as Spotify has already filtered them.
+func FilterByMarket(tracks []Track, market string) []Track {
+	filtered := make([]Track, 0, len(tracks))
+	for _, track := range tracks {
+		if len(track.AvailableMarkets) == 0 || slices.Contains(track.AvailableMarkets, market) {
+			filtered = append(filtered, track)
+		}
+	}
+	return filtered
+}
+
+// PlayableTracks drops the tracks that aren't available in the client's
+// configured Market. Without one, or when the market comes from the user's
+// token, Spotify's own filtering is trusted and the tracks are returned as is.
+func (c *Client) PlayableTracks(tracks []Track) []Track {
+	if c.Market == "" || c.Market == "from_token" {
+		return tracks
+	}
+	return FilterByMarket(tracks, strings.ToUpper(c.Market))
 }
 
 // User represents a Spotify user
@@ -41,11 +177,18 @@ type User struct {
 
 // Playlist represents a Spotify playlist
 type Playlist struct {
-	ID           string `json:"id"`
-	Name         string `json:"name"`
-	ExternalURLs struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Collaborative bool   `json:"collaborative"`
+	ExternalURLs  struct {
 		Spotify string `json:"spotify"`
 	} `json:"external_urls"`
+	Owner struct {
+		ID string `json:"id"`
+	} `json:"owner"`
+	Tracks struct {
+		Total int `json:"total"`
+	} `json:"tracks"`
 }
 
 // SearchResult represents Spotify search results
@@ -57,131 +200,230 @@ type SearchResult struct {
 
 // Client represents a Spotify API client
 type Client struct {
+	// APIURL, TokenURL and AuthorizeBaseURL are the Spotify endpoints the client
+	// talks to. NewClient points them at Spotify; they can be changed to a mock
+	// server for testing.
+	APIURL           string
+	TokenURL         string
+	AuthorizeBaseURL string
+
+	// HTTPClient sends every request so connections are reused across calls.
+	// It can be replaced to customize transport behaviour or for testing.
+	HTTPClient *http.Client
+
+	// Timeout limits how long a single request may take, including reading the
+	// response body, on top of any deadline on the caller's context. 0 disables it.
+	Timeout time.Duration
+
+	// MaxRetries is how many times a rate-limited (429) request is retried
+	MaxRetries int
+	// MaxNetworkRetries is how many times a request that failed with a
+	// transient network error, such as a reset connection, is retried. Error
+	// responses such as 4xx statuses are never retried this way. 0 disables it.
+	MaxNetworkRetries int
+	// RetryBackoff is the wait before the first retry when Spotify sends no
+	// Retry-After header. It doubles with every further retry.
+	RetryBackoff time.Duration
+	// MaxRetryDelay caps the wait before retrying a rate-limited request. The
+	// backoff never grows past it, and when Spotify's Retry-After asks for
+	// longer the request fails with ErrRateLimited straight away rather than
+	// stalling the caller. 0 disables the cap.
+	MaxRetryDelay time.Duration
+
+	// RateLimiter, when set, throttles requests to the Spotify API so bursts
+	// such as several fallback searches don't trip Spotify's rate limits.
+	// Requests wait for it before being sent, retries included. nil disables it.
+	RateLimiter *rate.Limiter
+
+	// Market is the ISO 3166-1 alpha-2 country code used to only return tracks
+	// playable in that country. When empty, user-authenticated clients use the
+	// user's own country ("from_token") and other clients send no market.
+	Market string
+
+	// SearchCacheTTL is how long search results are reused for identical
+	// searches, and SearchCacheSize how many searches are remembered. Setting
+	// either to 0 disables the cache.
+	SearchCacheTTL  time.Duration
+	SearchCacheSize int
+
+	// Logger receives the client's log output. Request details are logged at
+	// debug level. When nil, slog.Default() is used.
+	Logger *slog.Logger
+
+	// Metrics is told about every request sent to the Web API: how long it took
+	// and how it turned out. When nil, metrics are discarded.
+	Metrics Metrics
+
 	clientID     string
 	clientSecret string
+
+	// mu guards the token state and the cached genre seeds, which are shared
+	// between concurrent requests and token refreshes
+	mu           sync.RWMutex
 	accessToken  string
+	refreshToken string
+	tokenExpiry  time.Time
+	userToken    bool // the access token was obtained for a user, not client credentials
+	genreSeeds   []string
+
+	// refreshMu serializes token refreshes so concurrent requests only refresh once
+	refreshMu sync.Mutex
+
+	searchCache searchCache
 }
 
 // NewClient creates a new Spotify client
 func NewClient(clientID, clientSecret string) *Client {
 	return &Client{
-		clientID:     clientID,
-		clientSecret: clientSecret,
+		APIURL:            defaultAPIURL,
+		TokenURL:          defaultTokenURL,
+		AuthorizeBaseURL:  defaultAuthorizeURL,
+		HTTPClient:        &http.Client{},
+		Timeout:           defaultTimeout,
+		MaxRetries:        defaultMaxRetries,
+		MaxNetworkRetries: defaultMaxNetworkRetries,
+		RetryBackoff:      defaultRetryBackoff,
+		MaxRetryDelay:     defaultMaxRetryDelay,
+		SearchCacheTTL:    defaultSearchCacheTTL,
+		SearchCacheSize:   defaultSearchCacheSize,
+		clientID:          clientID,
+		clientSecret:      clientSecret,
 	}
 }
 
-// Authenticate gets an access token from Spotify
-func (c *Client) Authenticate() error {
-	auth := base64.StdEncoding.EncodeToString([]byte(c.clientID + ":" + c.clientSecret))
-
-	data := url.Values{}
-
-	// Check if we have a refresh token in env
-	refreshToken := os.Getenv("SPOTIFY_REFRESH_TOKEN")
-	if refreshToken != "" {
-		log.Printf("Using refresh token for user authentication")
-		data.Set("grant_type", "refresh_token")
-		data.Set("refresh_token", refreshToken)
-	} else {
-		log.Printf("No refresh token found, using client credentials (limited API access)")
-		data.Set("grant_type", "client_credentials")
+// apiURL returns the base URL of the Web API
+func (c *Client) apiURL() string {
+	if c.APIURL == "" {
+		return defaultAPIURL
 	}
+	return c.APIURL
+}
 
-	req, err := http.NewRequest("POST", spotifyAuthURL, strings.NewReader(data.Encode()))
-	if err != nil {
-		return fmt.Errorf("failed to create auth request: %w", err)
+// tokenURL returns the URL of the token endpoint
+func (c *Client) tokenURL() string {
+	if c.TokenURL == "" {
+		return defaultTokenURL
 	}
+	return c.TokenURL
+}
 
-	req.Header.Add("Authorization", "Basic "+auth)
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to authenticate: %w", err)
+// authorizeURL returns the URL of the consent page
+func (c *Client) authorizeURL() string {
+	if c.AuthorizeBaseURL == "" {
+		return defaultAuthorizeURL
 	}
-	defer resp.Body.Close()
+	return c.AuthorizeBaseURL
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("auth failed with status %d: %s", resp.StatusCode, body)
+// httpClient returns the HTTP client used for requests
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient == nil {
+		return http.DefaultClient
 	}
+	return c.HTTPClient
+}
 
-	var result map[string]interface{}
-	err = json.NewDecoder(resp.Body).Decode(&result)
-	if err != nil {
-		return fmt.Errorf("failed to decode auth response: %w", err)
+// logger returns the logger for the client's log output
+func (c *Client) logger() *slog.Logger {
+	if c.Logger == nil {
+		return slog.Default()
 	}
+	return c.Logger
+}
 
-	accessToken, ok := result["access_token"].(string)
-	if !ok {
-		return fmt.Errorf("access token not found in response")
+// market returns the market to send with track requests, or an empty string for none
+func (c *Client) market() string {
+	if c.Market != "" {
+		return c.Market
 	}
 
-	// Log the scope we received
-	if scope, ok := result["scope"].(string); ok {
-		log.Printf("Authenticated with scopes: %s", scope)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.userToken {
+		return "from_token"
 	}
-
-	c.accessToken = accessToken
-	return nil
+	return ""
 }
 
 // SearchTracks searches for tracks on Spotify
-func (c *Client) SearchTracks(query string, limit int) ([]Track, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("not authenticated")
+func (c *Client) SearchTracks(ctx context.Context, query string, limit int) ([]Track, error) {
+	return c.SearchTracksPaged(ctx, query, limit, 0)
+}
+
+// SearchTracksPaged searches for tracks on Spotify, skipping the first offset results.
+// Spotify only serves the first 1000 results of a search.
+func (c *Client) SearchTracksPaged(ctx context.Context, query string, limit, offset int) ([]Track, error) {
+	return c.searchTracks(ctx, query, limit, offset, c.market())
+}
+
+// searchTracks searches for tracks playable in market, or in any market when it is empty
+func (c *Client) searchTracks(ctx context.Context, query string, limit, offset int, market string) ([]Track, error) {
+	if offset < 0 || offset+limit > maxSearchResults {
+		return nil, fmt.Errorf("search offset %d with limit %d exceeds the %d result window", offset, limit, maxSearchResults)
 	}
 
 	params := url.Values{}
 	params.Set("q", query)
 	params.Set("type", "track")
 	params.Set("limit", fmt.Sprintf("%d", limit))
-
-	searchURL := spotifySearchURL + "?" + params.Encode()
-
-	req, err := http.NewRequest("GET", searchURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create search request: %w", err)
+	if offset > 0 {
+		params.Set("offset", fmt.Sprintf("%d", offset))
+	}
+	if market != "" {
+		params.Set("market", market)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.accessToken)
+	searchURL := c.apiURL() + "/search?" + params.Encode()
+	if tracks, ok := c.searchCache.get(searchURL); ok {
+		c.logger().Debug("Using cached search results", "query", query)
+		return tracks, nil
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.doRequest(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search tracks: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("search failed with status %d: %s", resp.StatusCode, body)
+		return nil, newAPIError("search", resp)
 	}
 
 	var result SearchResult
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err = decodeJSON(resp, &result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode search response: %w", err)
 	}
 
+	c.searchCache.put(searchURL, result.Tracks.Items, c.SearchCacheTTL, c.SearchCacheSize)
 	return result.Tracks.Items, nil
 }
 
-// GetRecommendations gets track recommendations based on seed tracks and mood parameters
-func (c *Client) GetRecommendations(seedTracks []string, seedGenres []string, moodParams map[string]interface{}, limit int) ([]Track, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("not authenticated")
+// GetRecommendations gets track recommendations based on seed tracks, artists
+// and genres and mood parameters. Any number of candidate seeds can be given,
+// most relevant first; SelectSeeds picks the MaxSeeds that are sent.
+func (c *Client) GetRecommendations(ctx context.Context, seedTracks, seedArtists, seedGenres []string, moodParams map[string]interface{}, limit int) ([]Track, error) {
+	params := url.Values{}
+
+	// Invalid genres are dropped before selecting so they don't take up seed slots
+	if len(seedGenres) > 0 {
+		seedGenres = c.validGenreSeeds(ctx, seedGenres)
 	}
 
-	params := url.Values{}
+	seeds := SelectSeeds(seedTracks, seedArtists, seedGenres)
+	if seeds.Len() == 0 {
+		return nil, ErrNoSeeds
+	}
+	seedTracks, seedArtists, seedGenres = seeds.Tracks, seeds.Artists, seeds.Genres
 
 	if len(seedTracks) > 0 {
 		// Spotify expects comma-separated IDs, but params.Encode() will URL-encode commas to %2C
 		// We need to manually build this part of the URL
 		params.Set("seed_tracks", strings.Join(seedTracks, ","))
 	}
-
+	if len(seedArtists) > 0 {
+		params.Set("seed_artists", strings.Join(seedArtists, ","))
+	}
 	if len(seedGenres) > 0 {
 		params.Set("seed_genres", strings.Join(seedGenres, ","))
 	}
@@ -192,42 +434,37 @@ func (c *Client) GetRecommendations(seedTracks []string, seedGenres []string, mo
 	}
 
 	params.Set("limit", fmt.Sprintf("%d", limit))
-
-	recURL := spotifyAPIURL + "/recommendations?" + params.Encode()
-
-	// Debug logging
-	log.Printf("Recommendations URL: %s", recURL)
-	log.Printf("Seed tracks: %v, Seed genres: %v", seedTracks, seedGenres)
-
-	req, err := http.NewRequest("GET", recURL, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create recommendations request: %w", err)
+	if market := c.market(); market != "" {
+		params.Set("market", market)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.accessToken)
+	recURL := c.apiURL() + "/recommendations?" + params.Encode()
+
+	c.logger().Debug("Requesting recommendations", "url", recURL, "seed_tracks", seedTracks, "seed_artists", seedArtists, "seed_genres", seedGenres)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := c.doRequest(ctx, "GET", recURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get recommendations: %w", err)
 	}
 	defer resp.Body.Close()
 
+	// Spotify has deprecated recommendations for newer apps, which get a 404
+	if resp.StatusCode == http.StatusNotFound {
+		c.logger().Info("Recommendations endpoint unavailable, falling back to search")
+		return c.searchRecommendations(ctx, seedTracks, seedGenres, moodParams, limit)
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		bodyStr := string(body)
-		if bodyStr == "" {
-			bodyStr = "(empty response)"
-		}
-		// Log the full URL and error for debugging
-		log.Printf("Recommendations API error - Status: %d, Body: %s", resp.StatusCode, bodyStr)
-		return nil, fmt.Errorf("recommendations failed with status %d: %s (URL: %s)", resp.StatusCode, bodyStr, recURL)
+		apiErr := newAPIError("recommendations", resp)
+		c.logger().Warn("Recommendations API error", "status", apiErr.StatusCode, "body", apiErr.Body)
+		c.logger().Debug("Failed recommendations request", "url", recURL)
+		return nil, apiErr
 	}
 
 	var result struct {
 		Tracks []Track `json:"tracks"`
 	}
-	err = json.NewDecoder(resp.Body).Decode(&result)
+	err = decodeJSON(resp, &result)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode recommendations response: %w", err)
 	}
@@ -235,33 +472,174 @@ func (c *Client) GetRecommendations(seedTracks []string, seedGenres []string, mo
 	return result.Tracks, nil
 }
 
-// GetCurrentUser gets the current authenticated user
-func (c *Client) GetCurrentUser() (*User, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("not authenticated")
+// searchRecommendations approximates recommendations with targeted searches built
+// from the seed genres and the mood described by the target audio features.
+// Results are deduplicated and exclude the seed tracks themselves.
+func (c *Client) searchRecommendations(ctx context.Context, seedTracks, seedGenres []string, moodParams map[string]interface{}, limit int) ([]Track, error) {
+	terms := moodTermsFromParams(moodParams)
+
+	var queries []string
+	for _, genre := range seedGenres {
+		queries = append(queries, strings.TrimSpace(fmt.Sprintf("genre:%q %s", genre, terms)))
+	}
+	if terms != "" {
+		queries = append(queries, terms)
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("no genres or mood terms to search for recommendations")
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range seedTracks {
+		seen[id] = true
+	}
+
+	var tracks []Track
+	var lastErr error
+	for _, query := range queries {
+		results, err := c.SearchTracks(ctx, query, min(limit, MaxSearchLimit))
+		if err != nil {
+			c.logger().Warn("Recommendation fallback search failed", "query", query, "error", err)
+			lastErr = err
+			continue
+		}
+
+		for _, track := range results {
+			if track.ID == "" || seen[track.ID] {
+				continue
+			}
+			seen[track.ID] = true
+			tracks = append(tracks, track)
+			if len(tracks) >= limit {
+				return tracks, nil
+			}
+		}
+	}
+
+	if len(tracks) == 0 && lastErr != nil {
+		return nil, fmt.Errorf("recommendation fallback search failed: %w", lastErr)
+	}
+	return tracks, nil
+}
+
+// moodTermsFromParams describes target audio features as search keywords,
+// e.g. high energy and low valence become "energetic sad"
+func moodTermsFromParams(moodParams map[string]interface{}) string {
+	target := func(key string) (float64, bool) {
+		value, ok := moodParams[key]
+		if !ok {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(fmt.Sprintf("%v", value), 64)
+		return f, err == nil
 	}
 
-	req, err := http.NewRequest("GET", spotifyAPIURL+"/me", nil)
+	var terms []string
+	if energy, ok := target("target_energy"); ok {
+		if energy >= 0.7 {
+			terms = append(terms, "energetic")
+		} else if energy <= 0.3 {
+			terms = append(terms, "calm")
+		}
+	}
+	if valence, ok := target("target_valence"); ok {
+		if valence >= 0.7 {
+			terms = append(terms, "happy")
+		} else if valence <= 0.3 {
+			terms = append(terms, "sad")
+		}
+	}
+	if danceability, ok := target("target_danceability"); ok && danceability >= 0.7 {
+		terms = append(terms, "dance")
+	}
+	if acousticness, ok := target("target_acousticness"); ok && acousticness >= 0.7 {
+		terms = append(terms, "acoustic")
+	}
+	return strings.Join(terms, " ")
+}
+
+// GetAvailableGenreSeeds returns the genres Spotify accepts as recommendation seeds.
+// The list is fetched once and cached on the client.
+func (c *Client) GetAvailableGenreSeeds(ctx context.Context) ([]string, error) {
+	c.mu.RLock()
+	cached := c.genreSeeds
+	c.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/recommendations/available-genre-seeds", nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create user request: %w", err)
+		return nil, fmt.Errorf("failed to get genre seeds: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get genre seeds", resp)
+	}
+
+	var result struct {
+		Genres []string `json:"genres"`
+	}
+	err = decodeJSON(resp, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode genre seeds response: %w", err)
+	}
+
+	c.mu.Lock()
+	c.genreSeeds = result.Genres
+	c.mu.Unlock()
+
+	return result.Genres, nil
+}
+
+// validGenreSeeds drops the genres Spotify doesn't accept as seeds. If the
+// available seeds can't be fetched the genres are returned unchanged.
+func (c *Client) validGenreSeeds(ctx context.Context, genres []string) []string {
+	available, err := c.GetAvailableGenreSeeds(ctx)
+	if err != nil {
+		c.logger().Warn("Could not validate seed genres", "error", err)
+		return genres
+	}
+
+	valid, invalid := FilterGenreSeeds(genres, available)
+	if len(invalid) > 0 {
+		c.logger().Info("Dropping invalid seed genres", "genres", invalid)
+	}
+	return valid
+}
+
+// FilterGenreSeeds splits genres into those found in the available seed list and those that aren't
+func FilterGenreSeeds(genres, available []string) (valid, invalid []string) {
+	known := make(map[string]bool, len(available))
+	for _, genre := range available {
+		known[strings.ToLower(genre)] = true
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.accessToken)
+	for _, genre := range genres {
+		if known[strings.ToLower(genre)] {
+			valid = append(valid, genre)
+		} else {
+			invalid = append(invalid, genre)
+		}
+	}
+	return valid, invalid
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// GetCurrentUser gets the current authenticated user
+func (c *Client) GetCurrentUser(ctx context.Context) (*User, error) {
+	resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/me", nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get user failed with status %d: %s", resp.StatusCode, body)
+		return nil, newAPIError("get user", resp)
 	}
 
 	var user User
-	err = json.NewDecoder(resp.Body).Decode(&user)
+	err = decodeJSON(resp, &user)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode user response: %w", err)
 	}
@@ -269,45 +647,56 @@ func (c *Client) GetCurrentUser() (*User, error) {
 	return &user, nil
 }
 
-// CreatePlaylist creates a new playlist for a user
-func (c *Client) CreatePlaylist(userID, name, description string) (*Playlist, error) {
-	if c.accessToken == "" {
-		return nil, fmt.Errorf("not authenticated")
-	}
+// PlaylistOptions are the settings a playlist is created with. Spotify only
+// lets private playlists be collaborative.
+type PlaylistOptions struct {
+	Public        bool
+	Collaborative bool // other users can add and remove tracks
+}
 
-	data := map[string]string{
-		"name":        name,
-		"description": description,
-		"public":      "false",
-	}
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal playlist data: %w", err)
+// createPlaylistRequest is the body of a create playlist request
+type createPlaylistRequest struct {
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Public        bool   `json:"public"`
+	Collaborative bool   `json:"collaborative"`
+}
+
+// CreatePlaylist creates a new private playlist for a user
+func (c *Client) CreatePlaylist(ctx context.Context, userID, name, description string) (*Playlist, error) {
+	return c.CreatePlaylistWithOptions(ctx, userID, name, description, PlaylistOptions{})
+}
+
+// CreatePlaylistWithOptions creates a new playlist for a user with the given
+// options. It fails without a request for a playlist both public and collaborative.
+func (c *Client) CreatePlaylistWithOptions(ctx context.Context, userID, name, description string, opts PlaylistOptions) (*Playlist, error) {
+	if opts.Public && opts.Collaborative {
+		return nil, fmt.Errorf("a collaborative playlist can't be public")
 	}
 
-	url := fmt.Sprintf("%s/users/%s/playlists", spotifyAPIURL, userID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	jsonData, err := json.Marshal(createPlaylistRequest{
+		Name:          name,
+		Description:   description,
+		Public:        opts.Public,
+		Collaborative: opts.Collaborative,
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create playlist request: %w", err)
+		return nil, fmt.Errorf("failed to marshal playlist data: %w", err)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.accessToken)
-	req.Header.Add("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	url := fmt.Sprintf("%s/users/%s/playlists", c.apiURL(), userID)
+	resp, err := c.doRequest(ctx, "POST", url, jsonData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create playlist: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("create playlist failed with status %d: %s", resp.StatusCode, body)
+		return nil, newAPIError("create playlist", resp)
 	}
 
 	var playlist Playlist
-	err = json.NewDecoder(resp.Body).Decode(&playlist)
+	err = decodeJSON(resp, &playlist)
 	if err != nil {
 		return nil, fmt.Errorf("failed to decode playlist response: %w", err)
 	}
@@ -315,44 +704,104 @@ func (c *Client) CreatePlaylist(userID, name, description string) (*Playlist, er
 	return &playlist, nil
 }
 
-// AddTracksToPlaylist adds tracks to a playlist
-func (c *Client) AddTracksToPlaylist(playlistID string, trackURIs []string) error {
-	if c.accessToken == "" {
-		return fmt.Errorf("not authenticated")
+// AddTracksToPlaylist adds tracks to a playlist. Spotify accepts at most 100 tracks
+// per request, so larger lists are sent in batches. If some batches fail the
+// others are still sent and a *BatchError reports what was added.
+func (c *Client) AddTracksToPlaylist(ctx context.Context, playlistID string, trackURIs []string) error {
+	return c.sendBatches(trackURIs, maxPlaylistTracksPerRequest, func(batch []string) error {
+		return c.playlistTracksRequest(ctx, "POST", "add tracks", playlistID, map[string][]string{
+			"uris": batch,
+		})
+	})
+}
+
+// RemoveTracksFromPlaylist removes every occurrence of the tracks from a playlist.
+// Like AddTracksToPlaylist, large lists are sent in batches of 100 and partial
+// failures are reported with a *BatchError.
+func (c *Client) RemoveTracksFromPlaylist(ctx context.Context, playlistID string, trackURIs []string) error {
+	type trackRef struct {
+		URI string `json:"uri"`
 	}
 
-	data := map[string][]string{
-		"uris": trackURIs,
+	return c.sendBatches(trackURIs, maxPlaylistTracksPerRequest, func(batch []string) error {
+		refs := make([]trackRef, len(batch))
+		for i, uri := range batch {
+			refs[i] = trackRef{URI: uri}
+		}
+		return c.playlistTracksRequest(ctx, "DELETE", "remove tracks", playlistID, map[string][]trackRef{
+			"tracks": refs,
+		})
+	})
+}
+
+// sendBatches calls send for consecutive batches of at most size items,
+// continuing past failed batches and reporting them in a *BatchError
+func (c *Client) sendBatches(items []string, size int, send func(batch []string) error) error {
+	batchErr := &BatchError{Total: len(items)}
+	for _, batch := range chunk(items, size) {
+		if err := send(batch); err != nil {
+			batchErr.Errs = append(batchErr.Errs, err)
+			continue
+		}
+		batchErr.Succeeded += len(batch)
 	}
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return fmt.Errorf("failed to marshal tracks data: %w", err)
+
+	if len(batchErr.Errs) > 0 {
+		return batchErr
 	}
+	return nil
+}
 
-	url := fmt.Sprintf("%s/playlists/%s/tracks", spotifyAPIURL, playlistID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+// playlistTracksRequest sends a request with a JSON body to a playlist's tracks endpoint
+func (c *Client) playlistTracksRequest(ctx context.Context, method, operation, playlistID string, data interface{}) error {
+	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("failed to create add tracks request: %w", err)
+		return fmt.Errorf("failed to marshal tracks data: %w", err)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.accessToken)
-	req.Header.Add("Content-Type", "application/json")
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	url := fmt.Sprintf("%s/playlists/%s/tracks", c.apiURL(), playlistID)
+	resp, err := c.doRequest(ctx, method, url, jsonData)
 	if err != nil {
-		return fmt.Errorf("failed to add tracks: %w", err)
+		return fmt.Errorf("failed to %s: %w", operation, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("add tracks failed with status %d: %s", resp.StatusCode, body)
+		return newAPIError(operation, resp)
 	}
 
 	return nil
 }
 
+// BatchError reports a partially failed request that was split into batches
+type BatchError struct {
+	Total     int     // number of items in the request
+	Succeeded int     // number of items in batches that succeeded
+	Errs      []error // errors of the failed batches
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d items failed: %v", e.Total-e.Succeeded, e.Total, errors.Join(e.Errs...))
+}
+
+// Unwrap returns the errors of the failed batches
+func (e *BatchError) Unwrap() []error {
+	return e.Errs
+}
+
+// chunk splits items into consecutive batches of at most size items
+func chunk(items []string, size int) [][]string {
+	var batches [][]string
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		batches = append(batches, items[start:end])
+	}
+	return batches
+}
+
 // LoadFromEnv loads Spotify credentials from environment variables
 func LoadFromEnv() (*Client, error) {
 	clientID := os.Getenv("SPOTIFY_CLIENT_ID")
@@ -362,5 +811,8 @@ func LoadFromEnv() (*Client, error) {
 		return nil, fmt.Errorf("SPOTIFY_CLIENT_ID and SPOTIFY_CLIENT_SECRET environment variables are required")
 	}
 
-	return NewClient(clientID, clientSecret), nil
+	client := NewClient(clientID, clientSecret)
+	client.refreshToken = os.Getenv("SPOTIFY_REFRESH_TOKEN")
+	client.Market = os.Getenv("SPOTIFY_MARKET")
+	return client, nil
 }
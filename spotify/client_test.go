@@ -0,0 +1,719 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestServer starts a mock Spotify server. The token endpoint at /token
+// always grants a token; every other request is passed to handler.
+func newTestServer(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"access_token":"test-token","expires_in":3600}`)
+	})
+	mux.Handle("/", handler)
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newTestClient returns a client authenticated against a mock Spotify server
+// that passes every request but token requests to handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	c := newUnauthenticatedClient(t, newTestServer(t, handler))
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	return c
+}
+
+// newUnauthenticatedClient returns a client pointed at srv that hasn't
+// requested a token yet. Retries don't wait and nothing is logged.
+func newUnauthenticatedClient(t *testing.T, srv *httptest.Server) *Client {
+	t.Helper()
+	t.Setenv("SPOTIFY_REFRESH_TOKEN", "")
+
+	c := NewClient("client-id", "client-secret")
+	c.APIURL = srv.URL
+	c.TokenURL = srv.URL + "/token"
+	c.AuthorizeBaseURL = srv.URL + "/authorize"
+	c.RetryBackoff = time.Millisecond
+	c.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	return c
+}
+
+// writeJSON writes body as a JSON response
+func writeJSON(w http.ResponseWriter, body string) {
+	w.Header().Set("Content-Type", "application/json")
+	fmt.Fprint(w, body)
+}
+
+// countingTransport counts the requests sent through it
+type countingTransport struct {
+	requests atomic.Int32
+}
+
+func (ct *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ct.requests.Add(1)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestNewClientSharesHTTPClient(t *testing.T) {
+	c := NewClient("id", "secret")
+	if c.HTTPClient == nil {
+		t.Fatal("NewClient() HTTPClient = nil, want a client")
+	}
+	if c.httpClient() != c.HTTPClient {
+		t.Error("httpClient() doesn't return the client's HTTPClient")
+	}
+}
+
+func TestClientUsesInjectedHTTPClient(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+
+	transport := &countingTransport{}
+	c.HTTPClient = &http.Client{Transport: transport}
+	c.SearchCacheSize = 0
+
+	for _, query := range []string{"happy", "sad"} {
+		if _, err := c.SearchTracks(context.Background(), query, 5); err != nil {
+			t.Fatalf("SearchTracks(%q) error = %v", query, err)
+		}
+	}
+
+	if got := transport.requests.Load(); got != 2 {
+		t.Errorf("injected client sent %d requests, want 2", got)
+	}
+}
+
+func TestSearchTracksCanceledMidFlight(t *testing.T) {
+	started := make(chan struct{})
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := c.SearchTracks(ctx, "happy", 5)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SearchTracks() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SearchTracks() returned after %v, want it to return promptly", elapsed)
+	}
+}
+
+func TestSearchTracksMarket(t *testing.T) {
+	tests := []struct {
+		name      string
+		market    string
+		userToken bool
+		want      string
+	}{
+		{"configured market", "GB", false, "GB"},
+		{"configured market overrides user", "DE", true, "DE"},
+		{"user token", "", true, "from_token"},
+		{"client credentials", "", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				got = r.URL.Query().Get("market")
+				writeJSON(w, `{"tracks":{"items":[]}}`)
+			})
+			c.Market = tt.market
+			c.userToken = tt.userToken
+
+			if _, err := c.SearchTracks(context.Background(), "happy", 5); err != nil {
+				t.Fatalf("SearchTracks() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("market = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRecommendationsMarket(t *testing.T) {
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(w, `{"tracks":[]}`)
+	})
+	c.Market = "SE"
+
+	if _, err := c.GetRecommendations(context.Background(), []string{"t1"}, nil, nil, nil, 5); err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+	if got := query.Get("market"); got != "SE" {
+		t.Errorf("market = %q, want %q", got, "SE")
+	}
+}
+
+// trackURIs returns n distinct track URIs
+func trackURIs(n int) []string {
+	uris := make([]string, n)
+	for i := range uris {
+		uris[i] = fmt.Sprintf("spotify:track:%d", i)
+	}
+	return uris
+}
+
+func TestAddTracksToPlaylistBatches(t *testing.T) {
+	var batches []int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			URIs []string `json:"uris"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		batches = append(batches, len(body.URIs))
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, `{"snapshot_id":"s"}`)
+	})
+
+	if err := c.AddTracksToPlaylist(context.Background(), "p1", trackURIs(250)); err != nil {
+		t.Fatalf("AddTracksToPlaylist() error = %v", err)
+	}
+	if want := []int{100, 100, 50}; !slices.Equal(batches, want) {
+		t.Errorf("batch sizes = %v, want %v", batches, want)
+	}
+}
+
+func TestAddTracksToPlaylistPartialFailure(t *testing.T) {
+	var requests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	err := c.AddTracksToPlaylist(context.Background(), "p1", trackURIs(250))
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("AddTracksToPlaylist() error = %v, want a *BatchError", err)
+	}
+	if batchErr.Total != 250 || batchErr.Succeeded != 150 || len(batchErr.Errs) != 1 {
+		t.Errorf("BatchError = %+v, want 150 of 250 added with 1 failed batch", batchErr)
+	}
+	if requests != 3 {
+		t.Errorf("server got %d requests, want 3", requests)
+	}
+}
+
+func TestChunk(t *testing.T) {
+	tests := []struct {
+		n, size int
+		want    []int
+	}{
+		{0, 100, nil},
+		{100, 100, []int{100}},
+		{101, 100, []int{100, 1}},
+		{5, 2, []int{2, 2, 1}},
+	}
+
+	for _, tt := range tests {
+		var got []int
+		for _, batch := range chunk(trackURIs(tt.n), tt.size) {
+			got = append(got, len(batch))
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("chunk(%d items, %d) sizes = %v, want %v", tt.n, tt.size, got, tt.want)
+		}
+	}
+}
+
+func TestFilterGenreSeeds(t *testing.T) {
+	available := []string{"acoustic", "pop", "soul", "chill"}
+
+	valid, invalid := FilterGenreSeeds([]string{"Pop", "lo-fi", "soul", "acoustic pop"}, available)
+	if want := []string{"Pop", "soul"}; !slices.Equal(valid, want) {
+		t.Errorf("FilterGenreSeeds() valid = %v, want %v", valid, want)
+	}
+	if want := []string{"lo-fi", "acoustic pop"}; !slices.Equal(invalid, want) {
+		t.Errorf("FilterGenreSeeds() invalid = %v, want %v", invalid, want)
+	}
+}
+
+func TestGetAvailableGenreSeedsCached(t *testing.T) {
+	var requests int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		writeJSON(w, `{"genres":["pop","soul"]}`)
+	})
+
+	for range 2 {
+		genres, err := c.GetAvailableGenreSeeds(context.Background())
+		if err != nil {
+			t.Fatalf("GetAvailableGenreSeeds() error = %v", err)
+		}
+		if want := []string{"pop", "soul"}; !slices.Equal(genres, want) {
+			t.Errorf("GetAvailableGenreSeeds() = %v, want %v", genres, want)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("server got %d requests, want 1", requests)
+	}
+}
+
+func TestGetRecommendationsDropsInvalidGenres(t *testing.T) {
+	var seedGenres string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/recommendations/available-genre-seeds" {
+			writeJSON(w, `{"genres":["pop","soul"]}`)
+			return
+		}
+		seedGenres = r.URL.Query().Get("seed_genres")
+		writeJSON(w, `{"tracks":[]}`)
+	})
+
+	_, err := c.GetRecommendations(context.Background(), nil, nil, []string{"lo-fi", "soul", "pop"}, nil, 5)
+	if err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+	if seedGenres != "soul,pop" {
+		t.Errorf("seed_genres = %q, want %q", seedGenres, "soul,pop")
+	}
+}
+
+func TestGetRecommendationsOnlyInvalidGenres(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/recommendations/available-genre-seeds" {
+			writeJSON(w, `{"genres":["pop"]}`)
+			return
+		}
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	_, err := c.GetRecommendations(context.Background(), nil, nil, []string{"lo-fi"}, nil, 5)
+	if !errors.Is(err, ErrNoSeeds) {
+		t.Errorf("GetRecommendations() error = %v, want ErrNoSeeds", err)
+	}
+}
+
+func TestGetRecommendationsNormalPath(t *testing.T) {
+	var paths []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		writeJSON(w, `{"tracks":[{"id":"r1"},{"id":"r2"}]}`)
+	})
+
+	tracks, err := c.GetRecommendations(context.Background(), []string{"t1"}, nil, nil, map[string]interface{}{"target_energy": 0.8}, 2)
+	if err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+	if len(tracks) != 2 || tracks[0].ID != "r1" {
+		t.Errorf("GetRecommendations() = %+v, want the recommended tracks", tracks)
+	}
+	if want := []string{"/recommendations"}; !slices.Equal(paths, want) {
+		t.Errorf("requested paths = %v, want %v", paths, want)
+	}
+}
+
+func TestGetRecommendationsFallsBackToSearch(t *testing.T) {
+	var queries []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/recommendations":
+			w.WriteHeader(http.StatusNotFound)
+		case "/search":
+			query := r.URL.Query().Get("q")
+			queries = append(queries, query)
+			if strings.HasPrefix(query, "genre:") {
+				writeJSON(w, `{"tracks":{"items":[{"id":"seed"},{"id":"a"},{"id":"b"}]}}`)
+			} else {
+				writeJSON(w, `{"tracks":{"items":[{"id":"b"},{"id":"c"}]}}`)
+			}
+		default:
+			t.Errorf("unexpected request to %s", r.URL.Path)
+		}
+	})
+	c.genreSeeds = []string{"soul"}
+
+	params := map[string]interface{}{"target_energy": 0.2, "target_valence": 0.9}
+	tracks, err := c.GetRecommendations(context.Background(), []string{"seed"}, nil, []string{"soul"}, params, 10)
+	if err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+
+	var ids []string
+	for _, track := range tracks {
+		ids = append(ids, track.ID)
+	}
+	if want := []string{"a", "b", "c"}; !slices.Equal(ids, want) {
+		t.Errorf("GetRecommendations() IDs = %v, want %v", ids, want)
+	}
+	if want := []string{`genre:"soul" calm happy`, "calm happy"}; !slices.Equal(queries, want) {
+		t.Errorf("fallback queries = %q, want %q", queries, want)
+	}
+}
+
+func TestMoodTermsFromParams(t *testing.T) {
+	tests := []struct {
+		params map[string]interface{}
+		want   string
+	}{
+		{map[string]interface{}{"target_energy": 0.9, "target_valence": 0.1}, "energetic sad"},
+		{map[string]interface{}{"target_energy": "0.2"}, "calm"},
+		{map[string]interface{}{"target_danceability": 0.8, "target_acousticness": 0.75}, "dance acoustic"},
+		{map[string]interface{}{"target_energy": 0.5, "target_valence": 0.5}, ""},
+		{nil, ""},
+	}
+
+	for _, tt := range tests {
+		if got := moodTermsFromParams(tt.params); got != tt.want {
+			t.Errorf("moodTermsFromParams(%v) = %q, want %q", tt.params, got, tt.want)
+		}
+	}
+}
+
+// newTrack returns a track with the given ID, name and artists
+func newTrack(id, name string, artists ...string) Track {
+	track := Track{ID: id, Name: name}
+	for _, artist := range artists {
+		track.Artists = append(track.Artists, struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		}{Name: artist})
+	}
+	return track
+}
+
+// trackIDs returns the IDs of tracks, or their names when they have no ID
+func trackIDs(tracks []Track) []string {
+	ids := make([]string, len(tracks))
+	for i, track := range tracks {
+		ids[i] = track.ID
+		if ids[i] == "" {
+			ids[i] = track.Name
+		}
+	}
+	return ids
+}
+
+func TestDedupeTracks(t *testing.T) {
+	seeds := []Track{
+		newTrack("a", "Song A", "Artist"),
+		newTrack("b", "Song B", "Artist"),
+		newTrack("", "Untitled", "Band"),
+	}
+	recommendations := []Track{
+		newTrack("b", "Song B", "Artist"),
+		newTrack("c", "Song C", "Artist"),
+		newTrack("", "untitled", "band"),
+		newTrack("", "Untitled", "Other Band"),
+		newTrack("a", "Song A", "Artist"),
+	}
+
+	got := trackIDs(DedupeTracks(append(seeds, recommendations...)))
+	want := []string{"a", "b", "Untitled", "c", "Untitled"}
+	if !slices.Equal(got, want) {
+		t.Errorf("DedupeTracks() = %v, want %v", got, want)
+	}
+}
+
+func TestSearchTracksPaged(t *testing.T) {
+	var offsets []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		offset := r.URL.Query().Get("offset")
+		offsets = append(offsets, offset)
+		if offset == "" {
+			writeJSON(w, `{"tracks":{"items":[{"id":"first"}]}}`)
+		} else {
+			writeJSON(w, `{"tracks":{"items":[{"id":"second"}]}}`)
+		}
+	})
+
+	first, err := c.SearchTracksPaged(context.Background(), "happy", 10, 0)
+	if err != nil {
+		t.Fatalf("SearchTracksPaged(offset 0) error = %v", err)
+	}
+	second, err := c.SearchTracksPaged(context.Background(), "happy", 10, 10)
+	if err != nil {
+		t.Fatalf("SearchTracksPaged(offset 10) error = %v", err)
+	}
+
+	if want := []string{"", "10"}; !slices.Equal(offsets, want) {
+		t.Errorf("offsets sent = %q, want %q", offsets, want)
+	}
+	if first[0].ID == second[0].ID {
+		t.Errorf("pages returned the same track %q", first[0].ID)
+	}
+}
+
+func TestSearchTracksPagedWindow(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request for offset %s", r.URL.Query().Get("offset"))
+	})
+
+	tests := []struct {
+		limit, offset int
+	}{
+		{10, -1},
+		{10, 991},
+		{50, 1000},
+	}
+	for _, tt := range tests {
+		if _, err := c.SearchTracksPaged(context.Background(), "happy", tt.limit, tt.offset); err == nil {
+			t.Errorf("SearchTracksPaged(limit %d, offset %d) error = nil, want an error", tt.limit, tt.offset)
+		}
+	}
+}
+
+func TestRemoveTracksFromPlaylist(t *testing.T) {
+	type removeBody struct {
+		Tracks []struct {
+			URI string `json:"uri"`
+		} `json:"tracks"`
+	}
+
+	var bodies []removeBody
+	var methods, paths []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		paths = append(paths, r.URL.Path)
+
+		var body removeBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		bodies = append(bodies, body)
+		writeJSON(w, `{"snapshot_id":"s"}`)
+	})
+
+	uris := trackURIs(150)
+	if err := c.RemoveTracksFromPlaylist(context.Background(), "p1", uris); err != nil {
+		t.Fatalf("RemoveTracksFromPlaylist() error = %v", err)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("server got %d requests, want 2", len(bodies))
+	}
+	for i := range bodies {
+		if methods[i] != http.MethodDelete || paths[i] != "/playlists/p1/tracks" {
+			t.Errorf("request %d = %s %s, want DELETE /playlists/p1/tracks", i, methods[i], paths[i])
+		}
+	}
+	if len(bodies[0].Tracks) != 100 || len(bodies[1].Tracks) != 50 {
+		t.Errorf("batch sizes = %d, %d, want 100, 50", len(bodies[0].Tracks), len(bodies[1].Tracks))
+	}
+	if got := bodies[1].Tracks[0].URI; got != uris[100] {
+		t.Errorf("second batch starts with %q, want %q", got, uris[100])
+	}
+}
+
+func TestSearchTracksAgainstMockServer(t *testing.T) {
+	var path string
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path, query = r.URL.Path, r.URL.Query()
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1","name":"Walking on Sunshine","uri":"spotify:track:t1","artists":[{"id":"a1","name":"Katrina"}]}]}}`)
+	})
+
+	tracks, err := c.SearchTracks(context.Background(), "happy upbeat", 7)
+	if err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+
+	if path != "/search" || query.Get("q") != "happy upbeat" || query.Get("type") != "track" || query.Get("limit") != "7" {
+		t.Errorf("request = %s?%s, want a track search for %q with limit 7", path, query.Encode(), "happy upbeat")
+	}
+	if len(tracks) != 1 || tracks[0].Name != "Walking on Sunshine" || !slices.Equal(tracks[0].ArtistNames(), []string{"Katrina"}) {
+		t.Errorf("SearchTracks() = %+v, want the decoded track", tracks)
+	}
+}
+
+func TestDefaultURLs(t *testing.T) {
+	c := &Client{}
+	if c.apiURL() != defaultAPIURL || c.tokenURL() != defaultTokenURL || c.authorizeURL() != defaultAuthorizeURL {
+		t.Errorf("empty client URLs = %q, %q, %q, want the Spotify defaults", c.apiURL(), c.tokenURL(), c.authorizeURL())
+	}
+
+	c = NewClient("id", "secret")
+	if c.APIURL != defaultAPIURL || c.TokenURL != defaultTokenURL || c.AuthorizeBaseURL != defaultAuthorizeURL {
+		t.Errorf("NewClient() URLs = %q, %q, %q, want the Spotify defaults", c.APIURL, c.TokenURL, c.AuthorizeBaseURL)
+	}
+}
+
+func TestDebugLogsSuppressedAtInfo(t *testing.T) {
+	for _, level := range []slog.Level{slog.LevelInfo, slog.LevelDebug} {
+		var buf strings.Builder
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, `{"tracks":[]}`)
+		})
+		c.Logger = slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: level}))
+
+		if _, err := c.GetRecommendations(context.Background(), []string{"seed-track"}, nil, nil, nil, 5); err != nil {
+			t.Fatalf("GetRecommendations() error = %v", err)
+		}
+
+		// The request URL and seeds are only logged for debugging
+		if got := strings.Contains(buf.String(), "seed-track"); got != (level == slog.LevelDebug) {
+			t.Errorf("at %v level, logs include the seeds = %v:\n%s", level, got, buf.String())
+		}
+	}
+}
+
+func TestFilterByPopularity(t *testing.T) {
+	var tracks []Track
+	for i, popularity := range []int{5, 40, 41, 75, 100} {
+		track := newTrack(fmt.Sprintf("t%d", i), "Song")
+		track.Popularity = popularity
+		tracks = append(tracks, track)
+	}
+
+	tests := []struct {
+		min, max int
+		want     []string
+	}{
+		{0, 40, []string{"t0", "t1"}},
+		{60, 100, []string{"t3", "t4"}},
+		{41, 41, []string{"t2"}},
+		{101, 200, []string{}},
+	}
+	for _, tt := range tests {
+		if got := trackIDs(FilterByPopularity(tracks, tt.min, tt.max)); !slices.Equal(got, tt.want) {
+			t.Errorf("FilterByPopularity(%d, %d) = %v, want %v", tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestDecodePopularity(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1","popularity":87},{"id":"t2"}]}}`)
+	})
+
+	tracks, err := c.SearchTracks(context.Background(), "happy", 2)
+	if err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if tracks[0].Popularity != 87 || tracks[1].Popularity != 0 {
+		t.Errorf("popularity = %d, %d, want 87, 0", tracks[0].Popularity, tracks[1].Popularity)
+	}
+}
+
+func TestCreatePlaylistWithOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts PlaylistOptions
+	}{
+		{"private", PlaylistOptions{}},
+		{"public", PlaylistOptions{Public: true}},
+		{"collaborative", PlaylistOptions{Collaborative: true}},
+	}
+
+	for _, tt := range tests {
+		var path string
+		var body map[string]interface{}
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding request body: %v", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(w, `{"id":"p1","name":"Mood Analyst: Happy Vibes","collaborative":`+strconv.FormatBool(tt.opts.Collaborative)+`}`)
+		})
+
+		playlist, err := c.CreatePlaylistWithOptions(context.Background(), "me", "Mood Analyst: Happy Vibes", "For your happy mood", tt.opts)
+		if err != nil {
+			t.Fatalf("%s: CreatePlaylistWithOptions() error = %v", tt.name, err)
+		}
+		if path != "/users/me/playlists" {
+			t.Errorf("%s: requested %s, want /users/me/playlists", tt.name, path)
+		}
+		// JSON booleans decode to bool; the strings "true" and "false" wouldn't
+		if public, ok := body["public"].(bool); !ok || public != tt.opts.Public {
+			t.Errorf("%s: public = %#v, want the boolean %v", tt.name, body["public"], tt.opts.Public)
+		}
+		if collaborative, ok := body["collaborative"].(bool); !ok || collaborative != tt.opts.Collaborative {
+			t.Errorf("%s: collaborative = %#v, want the boolean %v", tt.name, body["collaborative"], tt.opts.Collaborative)
+		}
+		if body["name"] != "Mood Analyst: Happy Vibes" || body["description"] != "For your happy mood" {
+			t.Errorf("%s: body = %v, want the name and description", tt.name, body)
+		}
+		if playlist.ID != "p1" || playlist.Collaborative != tt.opts.Collaborative {
+			t.Errorf("%s: CreatePlaylistWithOptions() = %+v, want the created playlist", tt.name, playlist)
+		}
+	}
+}
+
+func TestCreatePlaylistWithOptionsRejectsPublicCollaborative(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	if _, err := c.CreatePlaylistWithOptions(context.Background(), "me", "Shared", "", PlaylistOptions{Public: true, Collaborative: true}); err == nil {
+		t.Error("CreatePlaylistWithOptions() error = nil, want one for a public collaborative playlist")
+	}
+}
+
+func TestCreatePlaylistFailure(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"status":403,"message":"Insufficient client scope"}}`, http.StatusForbidden)
+	})
+
+	if _, err := c.CreatePlaylist(context.Background(), "me", "Happy", ""); !errors.Is(err, ErrForbidden) {
+		t.Errorf("CreatePlaylist() error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestCreatePlaylistSendsBooleanPublic(t *testing.T) {
+	var body string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		raw, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("reading request body: %v", err)
+		}
+		body = string(raw)
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, `{"id":"p1"}`)
+	})
+
+	if _, err := c.CreatePlaylistWithOptions(context.Background(), "me", "Happy", "", PlaylistOptions{}); err != nil {
+		t.Fatalf("CreatePlaylistWithOptions() error = %v", err)
+	}
+	if !strings.Contains(body, `"public":false`) {
+		t.Errorf("request body = %s, want it to contain \"public\":false", body)
+	}
+	if strings.Contains(body, `"public":"false"`) {
+		t.Errorf("request body = %s, sends public as a string", body)
+	}
+}
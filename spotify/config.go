@@ -0,0 +1,52 @@
+package spotify
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// fileConfig is the JSON config file read by LoadFromFile
+type fileConfig struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+	Market       string `json:"market"`
+}
+
+// LoadFromFile loads Spotify credentials from a JSON config file such as
+//
+//	{"client_id": "...", "client_secret": "...", "refresh_token": "...", "market": "US"}
+//
+// Settings missing from the file fall back to the environment variables read
+// by LoadFromEnv, so secrets can be kept out of the file if preferred.
+func LoadFromFile(path string) (*Client, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Spotify config file: %w", err)
+	}
+
+	var config fileConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to decode Spotify config file: %w", err)
+	}
+
+	clientID := valueOrEnv(config.ClientID, "SPOTIFY_CLIENT_ID")
+	clientSecret := valueOrEnv(config.ClientSecret, "SPOTIFY_CLIENT_SECRET")
+	if clientID == "" || clientSecret == "" {
+		return nil, fmt.Errorf("client_id and client_secret are required in %s or the SPOTIFY_CLIENT_ID and SPOTIFY_CLIENT_SECRET environment variables", path)
+	}
+
+	client := NewClient(clientID, clientSecret)
+	client.refreshToken = valueOrEnv(config.RefreshToken, "SPOTIFY_REFRESH_TOKEN")
+	client.Market = valueOrEnv(config.Market, "SPOTIFY_MARKET")
+	return client, nil
+}
+
+// valueOrEnv returns value, or the environment variable key when value is empty
+func valueOrEnv(value, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
@@ -0,0 +1,76 @@
+package spotify
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeConfigFile writes a config file to a temporary directory and returns its path
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "spotify.json")
+	if err := os.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("writing config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadFromFile(t *testing.T) {
+	t.Setenv("SPOTIFY_CLIENT_ID", "env-id")
+	t.Setenv("SPOTIFY_CLIENT_SECRET", "env-secret")
+	t.Setenv("SPOTIFY_REFRESH_TOKEN", "env-refresh")
+	t.Setenv("SPOTIFY_MARKET", "GB")
+
+	path := writeConfigFile(t, `{"client_id":"file-id","client_secret":"file-secret","refresh_token":"file-refresh","market":"US"}`)
+	c, err := LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if c.clientID != "file-id" || c.clientSecret != "file-secret" || c.RefreshToken() != "file-refresh" || c.Market != "US" {
+		t.Errorf("LoadFromFile() = id %q, secret %q, refresh %q, market %q, want the file's settings",
+			c.clientID, c.clientSecret, c.RefreshToken(), c.Market)
+	}
+	if !c.CanActAsUser() {
+		t.Error("CanActAsUser() = false with a refresh token, want true")
+	}
+}
+
+func TestLoadFromFileFallsBackToEnv(t *testing.T) {
+	t.Setenv("SPOTIFY_CLIENT_ID", "env-id")
+	t.Setenv("SPOTIFY_CLIENT_SECRET", "env-secret")
+	t.Setenv("SPOTIFY_REFRESH_TOKEN", "")
+	t.Setenv("SPOTIFY_MARKET", "GB")
+
+	c, err := LoadFromFile(writeConfigFile(t, `{"client_id":"file-id"}`))
+	if err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if c.clientID != "file-id" || c.clientSecret != "env-secret" || c.Market != "GB" {
+		t.Errorf("LoadFromFile() = id %q, secret %q, market %q, want file-id with the rest from the environment", c.clientID, c.clientSecret, c.Market)
+	}
+	if c.CanActAsUser() {
+		t.Error("CanActAsUser() = true without a refresh token, want false")
+	}
+}
+
+func TestLoadFromFileErrors(t *testing.T) {
+	t.Setenv("SPOTIFY_CLIENT_ID", "")
+	t.Setenv("SPOTIFY_CLIENT_SECRET", "")
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "missing.json")},
+		{"invalid JSON", writeConfigFile(t, "{not json")},
+		{"no credentials", writeConfigFile(t, `{"market":"US"}`)},
+	}
+
+	for _, tt := range tests {
+		if _, err := LoadFromFile(tt.path); err == nil {
+			t.Errorf("%s: LoadFromFile() error = nil, want an error", tt.name)
+		}
+	}
+}
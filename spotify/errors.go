@@ -0,0 +1,65 @@
+package spotify
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+var (
+	// ErrNotAuthenticated is returned when a request is made before authenticating
+	ErrNotAuthenticated = errors.New("not authenticated")
+	// ErrUnauthorized matches APIErrors for a rejected or expired access token (401)
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden matches APIErrors for requests the token lacks the scope for (403)
+	ErrForbidden = errors.New("forbidden")
+	// ErrNotFound matches APIErrors for missing resources or endpoints (404)
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited matches APIErrors for requests rejected by rate limiting (429)
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNoActiveDevice is returned by player requests when the user has no active device to play on
+	ErrNoActiveDevice = errors.New("no active device")
+	// ErrEmptyResponse is returned when Spotify answers without a body where one was expected
+	ErrEmptyResponse = errors.New("empty response")
+	// ErrNoSeeds is returned by GetRecommendations when none of the candidate seeds
+	// are usable; no request is made
+	ErrNoSeeds = errors.New("no valid seed tracks, artists or genres for recommendations")
+)
+
+// APIError is returned when Spotify responds with an unexpected status.
+// It matches the sentinel errors above with errors.Is according to its status code.
+type APIError struct {
+	Operation  string
+	StatusCode int
+	Body       string
+}
+
+// newAPIError builds an APIError from a response, reading its body
+func newAPIError(operation string, resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	bodyStr := string(body)
+	if bodyStr == "" {
+		bodyStr = "(empty response)"
+	}
+	return &APIError{Operation: operation, StatusCode: resp.StatusCode, Body: bodyStr}
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s failed with status %d: %s", e.Operation, e.StatusCode, e.Body)
+}
+
+// Is reports whether the error matches one of the status sentinel errors
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
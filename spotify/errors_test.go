@@ -0,0 +1,82 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrUnauthorized, ErrForbidden, ErrNotFound, ErrRateLimited}
+
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusInternalServerError, nil},
+	}
+
+	for _, tt := range tests {
+		err := fmt.Errorf("wrapped: %w", &APIError{Operation: "test", StatusCode: tt.status})
+		for _, sentinel := range sentinels {
+			if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+				t.Errorf("errors.Is(status %d, %v) = %v, want %v", tt.status, sentinel, got, !got)
+			}
+		}
+	}
+}
+
+func TestRequestErrorsByStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, `{"error":{"message":"nope"}}`)
+			})
+			c.MaxRetries = 0
+
+			_, err := c.GetCurrentUser(context.Background())
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("GetCurrentUser() error = %v, want %v", err, tt.want)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("GetCurrentUser() error = %v, want an *APIError", err)
+			}
+			if apiErr.StatusCode != tt.status || apiErr.Operation != "get user" || apiErr.Body != `{"error":{"message":"nope"}}` {
+				t.Errorf("APIError = %+v, want status %d with the response body", apiErr, tt.status)
+			}
+		})
+	}
+}
+
+func TestAPIErrorEmptyBody(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := c.GetCurrentUser(context.Background())
+	want := "get user failed with status 502: (empty response)"
+
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Error() != want {
+		t.Errorf("GetCurrentUser() error = %v, want %q", err, want)
+	}
+}
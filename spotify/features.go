@@ -0,0 +1,110 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxAudioFeatureIDs is the most track IDs Spotify accepts in one audio features request
+const maxAudioFeatureIDs = 100
+
+// AudioFeatures represents the audio analysis of a Spotify track
+type AudioFeatures struct {
+	ID               string  `json:"id"`
+	Energy           float32 `json:"energy"`
+	Danceability     float32 `json:"danceability"`
+	Valence          float32 `json:"valence"`
+	Acousticness     float32 `json:"acousticness"`
+	Instrumentalness float32 `json:"instrumentalness"`
+	Speechiness      float32 `json:"speechiness"`
+	Liveness         float32 `json:"liveness"`
+	Loudness         float32 `json:"loudness"`
+	Tempo            float32 `json:"tempo"`
+	Key              int     `json:"key"`
+	Mode             int     `json:"mode"`
+	DurationMs       int     `json:"duration_ms"`
+}
+
+// GetAudioFeatures gets the audio features of tracks. Tracks Spotify has no
+// features for are left out of the result.
+func (c *Client) GetAudioFeatures(ctx context.Context, trackIDs []string) ([]AudioFeatures, error) {
+	var features []AudioFeatures
+	for _, batch := range chunk(trackIDs, maxAudioFeatureIDs) {
+		params := url.Values{}
+		params.Set("ids", strings.Join(batch, ","))
+
+		resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/audio-features?"+params.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get audio features: %w", err)
+		}
+
+		batchFeatures, err := decodeAudioFeatures(resp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		features = append(features, batchFeatures...)
+	}
+
+	return features, nil
+}
+
+// decodeAudioFeatures decodes an audio features response, skipping null entries
+func decodeAudioFeatures(resp *http.Response) ([]AudioFeatures, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get audio features", resp)
+	}
+
+	var result struct {
+		AudioFeatures []*AudioFeatures `json:"audio_features"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode audio features response: %w", err)
+	}
+
+	features := make([]AudioFeatures, 0, len(result.AudioFeatures))
+	for _, f := range result.AudioFeatures {
+		if f != nil {
+			features = append(features, *f)
+		}
+	}
+	return features, nil
+}
+
+// AverageAudioFeatures returns the mean of the numeric features. The ID, key
+// and mode of the result are left empty.
+func AverageAudioFeatures(features []AudioFeatures) AudioFeatures {
+	var avg AudioFeatures
+	if len(features) == 0 {
+		return avg
+	}
+
+	for _, f := range features {
+		avg.Energy += f.Energy
+		avg.Danceability += f.Danceability
+		avg.Valence += f.Valence
+		avg.Acousticness += f.Acousticness
+		avg.Instrumentalness += f.Instrumentalness
+		avg.Speechiness += f.Speechiness
+		avg.Liveness += f.Liveness
+		avg.Loudness += f.Loudness
+		avg.Tempo += f.Tempo
+		avg.DurationMs += f.DurationMs
+	}
+
+	n := float32(len(features))
+	avg.Energy /= n
+	avg.Danceability /= n
+	avg.Valence /= n
+	avg.Acousticness /= n
+	avg.Instrumentalness /= n
+	avg.Speechiness /= n
+	avg.Liveness /= n
+	avg.Loudness /= n
+	avg.Tempo /= n
+	avg.DurationMs /= len(features)
+	return avg
+}
@@ -0,0 +1,115 @@
+package spotify
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const sampleAudioFeatures = `{
+  "audio_features": [
+    {
+      "id": "t1",
+      "energy": 0.8,
+      "danceability": 0.6,
+      "valence": 0.9,
+      "acousticness": 0.1,
+      "instrumentalness": 0.0,
+      "speechiness": 0.05,
+      "liveness": 0.2,
+      "loudness": -5.5,
+      "tempo": 128.0,
+      "key": 5,
+      "mode": 1,
+      "duration_ms": 200000
+    },
+    null,
+    {
+      "id": "t3",
+      "energy": 0.4,
+      "danceability": 0.2,
+      "valence": 0.3,
+      "acousticness": 0.7,
+      "tempo": 92.0,
+      "duration_ms": 180000
+    }
+  ]
+}`
+
+func TestGetAudioFeatures(t *testing.T) {
+	var ids string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ids = r.URL.Query().Get("ids")
+		writeJSON(w, sampleAudioFeatures)
+	})
+
+	features, err := c.GetAudioFeatures(context.Background(), []string{"t1", "t2", "t3"})
+	if err != nil {
+		t.Fatalf("GetAudioFeatures() error = %v", err)
+	}
+	if ids != "t1,t2,t3" {
+		t.Errorf("ids = %q, want %q", ids, "t1,t2,t3")
+	}
+	if len(features) != 2 {
+		t.Fatalf("GetAudioFeatures() returned %d features, want 2 with the null entry skipped", len(features))
+	}
+
+	want := AudioFeatures{
+		ID: "t1", Energy: 0.8, Danceability: 0.6, Valence: 0.9, Acousticness: 0.1,
+		Speechiness: 0.05, Liveness: 0.2, Loudness: -5.5, Tempo: 128, Key: 5, Mode: 1, DurationMs: 200000,
+	}
+	if features[0] != want {
+		t.Errorf("GetAudioFeatures()[0] = %+v, want %+v", features[0], want)
+	}
+	if features[1].ID != "t3" {
+		t.Errorf("GetAudioFeatures()[1].ID = %q, want %q", features[1].ID, "t3")
+	}
+}
+
+func TestGetAudioFeaturesBatches(t *testing.T) {
+	var batches []int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		batches = append(batches, len(strings.Split(r.URL.Query().Get("ids"), ",")))
+		writeJSON(w, `{"audio_features":[]}`)
+	})
+
+	if _, err := c.GetAudioFeatures(context.Background(), trackURIs(150)); err != nil {
+		t.Fatalf("GetAudioFeatures() error = %v", err)
+	}
+	if len(batches) != 2 || batches[0] != maxAudioFeatureIDs || batches[1] != 50 {
+		t.Errorf("batch sizes = %v, want [%d 50]", batches, maxAudioFeatureIDs)
+	}
+}
+
+func TestAverageAudioFeatures(t *testing.T) {
+	avg := AverageAudioFeatures([]AudioFeatures{
+		{Energy: 0.8, Valence: 0.9, Tempo: 128, DurationMs: 200000, Key: 5},
+		{Energy: 0.4, Valence: 0.3, Tempo: 92, DurationMs: 180000, Key: 2},
+	})
+
+	tests := []struct {
+		name      string
+		got, want float32
+	}{
+		{"Energy", avg.Energy, 0.6},
+		{"Valence", avg.Valence, 0.6},
+		{"Tempo", avg.Tempo, 110},
+	}
+	for _, tt := range tests {
+		if math.Abs(float64(tt.got-tt.want)) > 1e-4 {
+			t.Errorf("AverageAudioFeatures().%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+	if avg.DurationMs != 190000 {
+		t.Errorf("AverageAudioFeatures().DurationMs = %d, want 190000", avg.DurationMs)
+	}
+	if avg.Key != 0 {
+		t.Errorf("AverageAudioFeatures().Key = %d, want it left empty", avg.Key)
+	}
+
+	if got := AverageAudioFeatures(nil); got != (AudioFeatures{}) {
+		t.Errorf("AverageAudioFeatures(nil) = %+v, want zero features", got)
+	}
+}
@@ -0,0 +1,63 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// SaveTracks saves tracks to the user's Liked Songs. Spotify accepts at most 50
+// IDs per request, so larger lists are sent in batches. If some batches fail
+// the others are still sent and a *BatchError reports what was saved.
+func (c *Client) SaveTracks(ctx context.Context, trackIDs []string) error {
+	return c.sendBatches(trackIDs, maxTrackIDs, func(batch []string) error {
+		jsonData, err := json.Marshal(map[string][]string{"ids": batch})
+		if err != nil {
+			return fmt.Errorf("failed to marshal track IDs: %w", err)
+		}
+
+		resp, err := c.doRequest(ctx, "PUT", c.apiURL()+"/me/tracks", jsonData)
+		if err != nil {
+			return fmt.Errorf("failed to save tracks: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			return newAPIError("save tracks", resp)
+		}
+		return nil
+	})
+}
+
+// TracksAreSaved reports for each track whether it is in the user's Liked
+// Songs, in the order given. Larger lists are checked in batches of 50.
+func (c *Client) TracksAreSaved(ctx context.Context, trackIDs []string) ([]bool, error) {
+	saved := make([]bool, 0, len(trackIDs))
+	for _, batch := range chunk(trackIDs, maxTrackIDs) {
+		params := url.Values{}
+		params.Set("ids", strings.Join(batch, ","))
+
+		resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/me/tracks/contains?"+params.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check saved tracks: %w", err)
+		}
+
+		var batchSaved []bool
+		if resp.StatusCode != http.StatusOK {
+			err = newAPIError("check saved tracks", resp)
+		} else if err = decodeJSON(resp, &batchSaved); err != nil {
+			err = fmt.Errorf("failed to decode saved tracks response: %w", err)
+		} else if len(batchSaved) != len(batch) {
+			err = fmt.Errorf("got %d saved track results for %d tracks", len(batchSaved), len(batch))
+		}
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, batchSaved...)
+	}
+	return saved, nil
+}
@@ -0,0 +1,119 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSaveTracks(t *testing.T) {
+	var methods []string
+	var batches [][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		methods = append(methods, r.Method+" "+r.URL.Path)
+		batches = append(batches, body.IDs)
+	})
+
+	ids := trackURIs(120)
+	if err := c.SaveTracks(context.Background(), ids); err != nil {
+		t.Fatalf("SaveTracks() error = %v", err)
+	}
+
+	if len(batches) != 3 || len(batches[0]) != maxTrackIDs || len(batches[1]) != maxTrackIDs || len(batches[2]) != 20 {
+		t.Fatalf("batch sizes = %d, want [50 50 20]", len(batches))
+	}
+	for _, m := range methods {
+		if m != "PUT /me/tracks" {
+			t.Errorf("sent %s, want PUT /me/tracks", m)
+		}
+	}
+	if got := slices.Concat(batches...); !slices.Equal(got, ids) {
+		t.Errorf("saved IDs = %v, want all of them in order", got)
+	}
+}
+
+func TestSaveTracksPartialFailure(t *testing.T) {
+	requests := 0
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	})
+
+	err := c.SaveTracks(context.Background(), trackURIs(120))
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("SaveTracks() error = %v, want a *BatchError", err)
+	}
+	if batchErr.Total != 120 || batchErr.Succeeded != 70 || len(batchErr.Errs) != 1 {
+		t.Errorf("BatchError = %+v, want 70 of 120 saved with one failed batch", batchErr)
+	}
+	if requests != 3 {
+		t.Errorf("sent %d requests, want all 3 batches tried", requests)
+	}
+}
+
+func TestTracksAreSaved(t *testing.T) {
+	var batches []string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ids := strings.Split(r.URL.Query().Get("ids"), ",")
+		batches = append(batches, r.URL.Path)
+		saved := make([]bool, len(ids))
+		for i, id := range ids {
+			saved[i] = strings.HasSuffix(id, "0")
+		}
+		json.NewEncoder(w).Encode(saved)
+	})
+
+	saved, err := c.TracksAreSaved(context.Background(), trackURIs(60))
+	if err != nil {
+		t.Fatalf("TracksAreSaved() error = %v", err)
+	}
+	if len(batches) != 2 || batches[0] != "/me/tracks/contains" {
+		t.Errorf("requested %v, want two batches to /me/tracks/contains", batches)
+	}
+	if len(saved) != 60 || !saved[10] || saved[11] {
+		t.Errorf("TracksAreSaved() = %v, want one result per track in order", saved)
+	}
+}
+
+func TestTracksAreSavedErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"forbidden", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"error":{"status":403,"message":"Insufficient client scope"}}`, http.StatusForbidden)
+		}},
+		{"wrong length", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, `[true]`)
+		}},
+		{"malformed", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, `{"saved":true}`)
+		}},
+	}
+	for _, tt := range tests {
+		c := newTestClient(t, tt.handler)
+		if saved, err := c.TracksAreSaved(context.Background(), []string{"a", "b"}); err == nil {
+			t.Errorf("%s: TracksAreSaved() = %v, want an error", tt.name, saved)
+		}
+	}
+
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"status":403,"message":"Insufficient client scope"}}`, http.StatusForbidden)
+	})
+	if _, err := c.TracksAreSaved(context.Background(), []string{"a"}); !errors.Is(err, ErrForbidden) {
+		t.Errorf("TracksAreSaved() error = %v, want ErrForbidden", err)
+	}
+}
@@ -0,0 +1,112 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Names of the metrics the client reports
+const (
+	// MetricRequests counts requests sent to the Web API, labelled with
+	// "endpoint" and "outcome"
+	MetricRequests = "spotify_requests"
+	// MetricRequestLatency is how long requests to the Web API took, labelled with "endpoint"
+	MetricRequestLatency = "spotify_request_latency"
+)
+
+// Metrics receives counts and timings of the client's requests, e.g. to export
+// them to a monitoring system. Implementations must be safe for concurrent use.
+type Metrics interface {
+	// IncCounter adds one to the named counter
+	IncCounter(name string, labels map[string]string)
+	// ObserveLatency records a duration for the named metric
+	ObserveLatency(name string, d time.Duration, labels map[string]string)
+}
+
+// NopMetrics discards all metrics
+type NopMetrics struct{}
+
+func (NopMetrics) IncCounter(string, map[string]string)                    {}
+func (NopMetrics) ObserveLatency(string, time.Duration, map[string]string) {}
+
+// metrics returns the client's metrics hook
+func (c *Client) metrics() Metrics {
+	if c.Metrics == nil {
+		return NopMetrics{}
+	}
+	return c.Metrics
+}
+
+// observeRequest reports a request that was sent to endpoint and took d,
+// with the response or error it got
+func (c *Client) observeRequest(method, endpoint string, d time.Duration, resp *http.Response, err error) {
+	name := method + " " + endpointRoute(strings.TrimPrefix(endpoint, c.apiURL()))
+	c.metrics().IncCounter(MetricRequests, map[string]string{"endpoint": name, "outcome": requestOutcome(resp, err)})
+	c.metrics().ObserveLatency(MetricRequestLatency, d, map[string]string{"endpoint": name})
+}
+
+// idCollections are the path segments that can be followed by an ID
+var idCollections = map[string]bool{
+	"users":          true,
+	"playlists":      true,
+	"artists":        true,
+	"albums":         true,
+	"tracks":         true,
+	"audio-features": true,
+}
+
+// endpointRoute turns a request path into a route with its IDs replaced, e.g.
+// "/playlists/{id}/tracks", so metrics aren't split up per playlist or user.
+// Fixed segments such as "contains" in "/me/tracks/contains" are kept.
+func endpointRoute(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i > 0; i-- {
+		if isRouteID(segments[i-1], segments[i]) {
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// spotifyIDPattern matches a Spotify ID, 22 base-62 characters
+var spotifyIDPattern = regexp.MustCompile(`^[0-9A-Za-z]{22}$`)
+
+// isRouteID reports whether segment is an ID following collection. User IDs
+// are free-form, while everything else uses Spotify's base-62 IDs.
+func isRouteID(collection, segment string) bool {
+	if collection == "users" {
+		return segment != ""
+	}
+	return idCollections[collection] && spotifyIDPattern.MatchString(segment)
+}
+
+// requestOutcome categorizes the result of a request: "ok" for a 2xx
+// response, "network_error" when no response came back, or the kind of error status
+func requestOutcome(resp *http.Response, err error) string {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case err != nil:
+		return "network_error"
+	case resp.StatusCode < 300:
+		return "ok"
+	case resp.StatusCode == http.StatusUnauthorized:
+		return "unauthorized"
+	case resp.StatusCode == http.StatusForbidden:
+		return "forbidden"
+	case resp.StatusCode == http.StatusNotFound:
+		return "not_found"
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return "rate_limited"
+	case resp.StatusCode >= 500:
+		return "server_error"
+	default:
+		return "status_" + strconv.Itoa(resp.StatusCode)
+	}
+}
@@ -0,0 +1,169 @@
+package spotify
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMetrics is a Metrics keeping everything reported to it
+type recordingMetrics struct {
+	mu        sync.Mutex
+	counters  []recordedMetric
+	latencies []recordedMetric
+}
+
+type recordedMetric struct {
+	name   string
+	labels map[string]string
+}
+
+func (m *recordingMetrics) IncCounter(name string, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.counters = append(m.counters, recordedMetric{name, labels})
+}
+
+func (m *recordingMetrics) ObserveLatency(name string, d time.Duration, labels map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencies = append(m.latencies, recordedMetric{name, labels})
+}
+
+func TestMetricsCountSuccessAndErrors(t *testing.T) {
+	const playlistID = "37i9dQZF1DXcBWIGoYBM5M"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/search":
+			writeJSON(w, `{"tracks":{"items":[]}}`)
+		case "/me/tracks/contains":
+			writeJSON(w, `[true]`)
+		default:
+			http.Error(w, `{"error":{"status":404,"message":"Not found"}}`, http.StatusNotFound)
+		}
+	})
+	metrics := &recordingMetrics{}
+	c.Metrics = metrics
+
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if _, err := c.TracksAreSaved(context.Background(), []string{"4uLU6hMCjMI75M1A2tKUQC"}); err != nil {
+		t.Fatalf("TracksAreSaved() error = %v", err)
+	}
+	if _, err := c.GetPlaylistTracks(context.Background(), playlistID); err == nil {
+		t.Fatal("GetPlaylistTracks() error = nil, want the 404")
+	}
+
+	want := []map[string]string{
+		{"endpoint": "GET /search", "outcome": "ok"},
+		{"endpoint": "GET /me/tracks/contains", "outcome": "ok"},
+		{"endpoint": "GET /playlists/{id}/tracks", "outcome": "not_found"},
+	}
+	if len(metrics.counters) != len(want) {
+		t.Fatalf("got %d counters, want %d: %v", len(metrics.counters), len(want), metrics.counters)
+	}
+	for i, counter := range metrics.counters {
+		if counter.name != MetricRequests {
+			t.Errorf("counter %d name = %q, want %q", i, counter.name, MetricRequests)
+		}
+		for key, value := range want[i] {
+			if counter.labels[key] != value {
+				t.Errorf("counter %d %s = %q, want %q", i, key, counter.labels[key], value)
+			}
+		}
+	}
+	if len(metrics.latencies) != len(want) {
+		t.Fatalf("got %d latencies, want %d", len(metrics.latencies), len(want))
+	}
+	for i, latency := range metrics.latencies {
+		if latency.name != MetricRequestLatency || latency.labels["endpoint"] != want[i]["endpoint"] {
+			t.Errorf("latency %d = %s %v, want %s for %s", i, latency.name, latency.labels, MetricRequestLatency, want[i]["endpoint"])
+		}
+	}
+}
+
+func TestMetricsCountNetworkErrors(t *testing.T) {
+	srv := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	c.MaxNetworkRetries = 0
+	metrics := &recordingMetrics{}
+	c.Metrics = metrics
+	srv.Close()
+
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err == nil {
+		t.Fatal("SearchTracks() error = nil, want a network error")
+	}
+	if len(metrics.counters) != 1 || metrics.counters[0].labels["outcome"] != "network_error" {
+		t.Errorf("counters = %v, want one network_error", metrics.counters)
+	}
+}
+
+func TestNopMetricsByDefault(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+	if _, ok := c.metrics().(NopMetrics); !ok {
+		t.Errorf("metrics() = %T, want NopMetrics when none is set", c.metrics())
+	}
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+}
+
+func TestEndpointRoute(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/search?q=happy&type=track", "/search"},
+		{"/me/tracks/contains?ids=4uLU6hMCjMI75M1A2tKUQC", "/me/tracks/contains"},
+		{"/me/tracks", "/me/tracks"},
+		{"/me/top/tracks", "/me/top/tracks"},
+		{"/me/player/play", "/me/player/play"},
+		{"/playlists/37i9dQZF1DXcBWIGoYBM5M/tracks", "/playlists/{id}/tracks"},
+		{"/playlists/37i9dQZF1DXcBWIGoYBM5M/images", "/playlists/{id}/images"},
+		{"/playlists/37i9dQZF1DXcBWIGoYBM5M", "/playlists/{id}"},
+		{"/users/smedjan/playlists", "/users/{id}/playlists"},
+		{"/artists/0TnOYISbd1XYRBk9myaseg/top-tracks", "/artists/{id}/top-tracks"},
+		{"/audio-features/4uLU6hMCjMI75M1A2tKUQC", "/audio-features/{id}"},
+		{"/audio-features?ids=a,b", "/audio-features"},
+		{"/recommendations/available-genre-seeds", "/recommendations/available-genre-seeds"},
+	}
+	for _, tt := range tests {
+		if got := endpointRoute(tt.path); got != tt.want {
+			t.Errorf("endpointRoute(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRequestOutcome(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusOK, "ok"},
+		{http.StatusCreated, "ok"},
+		{http.StatusUnauthorized, "unauthorized"},
+		{http.StatusForbidden, "forbidden"},
+		{http.StatusNotFound, "not_found"},
+		{http.StatusTooManyRequests, "rate_limited"},
+		{http.StatusBadGateway, "server_error"},
+		{http.StatusBadRequest, "status_400"},
+	}
+	for _, tt := range tests {
+		if got := requestOutcome(&http.Response{StatusCode: tt.status}, nil); got != tt.want {
+			t.Errorf("requestOutcome(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+	if got := requestOutcome(nil, context.DeadlineExceeded); got != "timeout" {
+		t.Errorf("requestOutcome(deadline exceeded) = %q, want timeout", got)
+	}
+}
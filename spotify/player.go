@@ -0,0 +1,111 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// GetCurrentlyPlaying gets the track the authenticated user is listening to.
+// It returns a nil track and no error when nothing is playing or the user is
+// listening to something other than a track, such as a podcast episode.
+func (c *Client) GetCurrentlyPlaying(ctx context.Context) (*Track, error) {
+	resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/me/player/currently-playing", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get currently playing track: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Spotify answers 204 No Content when nothing is playing
+	if resp.StatusCode == http.StatusNoContent {
+		return nil, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get currently playing", resp)
+	}
+
+	var result struct {
+		CurrentlyPlayingType string `json:"currently_playing_type"`
+		Item                 *Track `json:"item"`
+	}
+	err = decodeJSON(resp, &result)
+	// Spotify sometimes answers 200 without a body when nothing is playing
+	if errors.Is(err, ErrEmptyResponse) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode currently playing response: %w", err)
+	}
+
+	if result.CurrentlyPlayingType != "track" {
+		return nil, nil
+	}
+	return result.Item, nil
+}
+
+// StartPlayback starts playing the tracks with the given URIs on the user's
+// device. An empty deviceID plays on the currently active device. It fails with
+// ErrNoActiveDevice when the user has no device to play on. Playback control
+// requires Spotify Premium.
+func (c *Client) StartPlayback(ctx context.Context, deviceID string, uris []string) error {
+	jsonData, err := json.Marshal(map[string]interface{}{"uris": uris})
+	if err != nil {
+		return fmt.Errorf("failed to marshal playback data: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PUT", c.playerURL("/play", deviceID, nil), jsonData)
+	if err != nil {
+		return fmt.Errorf("failed to start playback: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return checkPlayerResponse("start playback", resp)
+}
+
+// AddToQueue adds the track with the given URI to the end of the user's
+// playback queue. An empty deviceID uses the currently active device. It fails
+// with ErrNoActiveDevice when the user has no device to play on.
+func (c *Client) AddToQueue(ctx context.Context, uri, deviceID string) error {
+	params := url.Values{}
+	params.Set("uri", uri)
+
+	resp, err := c.doRequest(ctx, "POST", c.playerURL("/queue", deviceID, params), nil)
+	if err != nil {
+		return fmt.Errorf("failed to add to queue: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return checkPlayerResponse("add to queue", resp)
+}
+
+// playerURL builds the URL of a player endpoint, targeting deviceID when set
+func (c *Client) playerURL(path, deviceID string, params url.Values) string {
+	if deviceID != "" {
+		if params == nil {
+			params = url.Values{}
+		}
+		params.Set("device_id", deviceID)
+	}
+
+	endpoint := c.apiURL() + "/me/player" + path
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
+	return endpoint
+}
+
+// checkPlayerResponse turns an unsuccessful player response into an error.
+// Spotify answers 404 when there is no active device to control.
+func checkPlayerResponse(operation string, resp *http.Response) error {
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrNoActiveDevice, newAPIError(operation, resp))
+	default:
+		return newAPIError(operation, resp)
+	}
+}
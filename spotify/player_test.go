@@ -0,0 +1,145 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestGetCurrentlyPlaying(t *testing.T) {
+	var path string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		writeJSON(w, `{"currently_playing_type":"track","is_playing":true,"item":{"id":"t1","name":"Now","artists":[{"id":"a1","name":"Someone"}]}}`)
+	})
+
+	track, err := c.GetCurrentlyPlaying(context.Background())
+	if err != nil {
+		t.Fatalf("GetCurrentlyPlaying() error = %v", err)
+	}
+	if path != "/me/player/currently-playing" {
+		t.Errorf("requested %s, want /me/player/currently-playing", path)
+	}
+	if track == nil || track.ID != "t1" || track.Name != "Now" || len(track.Artists) != 1 || track.Artists[0].ID != "a1" {
+		t.Errorf("GetCurrentlyPlaying() = %+v, want track t1 by a1", track)
+	}
+}
+
+func TestGetCurrentlyPlayingNothing(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"no content", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}},
+		{"empty body", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}},
+		{"episode", func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, `{"currently_playing_type":"episode","item":null}`)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newTestClient(t, tt.handler)
+			track, err := c.GetCurrentlyPlaying(context.Background())
+			if track != nil || err != nil {
+				t.Errorf("GetCurrentlyPlaying() = %+v, %v, want nil, nil", track, err)
+			}
+		})
+	}
+}
+
+func TestGetCurrentlyPlayingError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	if _, err := c.GetCurrentlyPlaying(context.Background()); err == nil {
+		t.Error("GetCurrentlyPlaying() error = nil, want an error")
+	}
+}
+
+func TestStartPlayback(t *testing.T) {
+	tests := []struct {
+		deviceID   string
+		wantDevice string
+	}{
+		{"", ""},
+		{"phone", "phone"},
+	}
+
+	for _, tt := range tests {
+		var method, path, device, body string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			method, path, device = r.Method, r.URL.Path, r.URL.Query().Get("device_id")
+			data, _ := io.ReadAll(r.Body)
+			body = string(data)
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		if err := c.StartPlayback(context.Background(), tt.deviceID, []string{"spotify:track:1", "spotify:track:2"}); err != nil {
+			t.Fatalf("StartPlayback(%q) error = %v", tt.deviceID, err)
+		}
+		if method != http.MethodPut || path != "/me/player/play" || device != tt.wantDevice {
+			t.Errorf("StartPlayback(%q) sent %s %s with device_id %q, want PUT /me/player/play with %q", tt.deviceID, method, path, device, tt.wantDevice)
+		}
+		if want := `{"uris":["spotify:track:1","spotify:track:2"]}`; body != want {
+			t.Errorf("StartPlayback(%q) body = %s, want %s", tt.deviceID, body, want)
+		}
+	}
+}
+
+func TestAddToQueue(t *testing.T) {
+	var method, path string
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path, query = r.Method, r.URL.Path, r.URL.Query()
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := c.AddToQueue(context.Background(), "spotify:track:1", "phone"); err != nil {
+		t.Fatalf("AddToQueue() error = %v", err)
+	}
+	if method != http.MethodPost || path != "/me/player/queue" {
+		t.Errorf("AddToQueue() sent %s %s, want POST /me/player/queue", method, path)
+	}
+	if query.Get("uri") != "spotify:track:1" || query.Get("device_id") != "phone" {
+		t.Errorf("AddToQueue() query = %v, want the track URI and device", query)
+	}
+}
+
+func TestPlayerNoActiveDevice(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"error":{"status":404,"message":"Player command failed: No active device found","reason":"NO_ACTIVE_DEVICE"}}`)
+	})
+	c.MaxRetries = 0
+
+	errs := map[string]error{
+		"StartPlayback": c.StartPlayback(context.Background(), "", []string{"spotify:track:1"}),
+		"AddToQueue":    c.AddToQueue(context.Background(), "spotify:track:1", ""),
+	}
+	for name, err := range errs {
+		if !errors.Is(err, ErrNoActiveDevice) || !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s() error = %v, want ErrNoActiveDevice wrapping the 404", name, err)
+		}
+	}
+}
+
+func TestPlayerPremiumRequired(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	err := c.StartPlayback(context.Background(), "", []string{"spotify:track:1"})
+	if !errors.Is(err, ErrForbidden) || errors.Is(err, ErrNoActiveDevice) {
+		t.Errorf("StartPlayback() error = %v, want ErrForbidden only", err)
+	}
+}
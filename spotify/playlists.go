@@ -0,0 +1,195 @@
+package spotify
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// maxPlaylistsPerPage is the most playlists Spotify returns per page
+const maxPlaylistsPerPage = 50
+
+// GetUserPlaylists gets a page of the playlists owned or followed by the authenticated user
+func (c *Client) GetUserPlaylists(ctx context.Context, limit, offset int) ([]Playlist, error) {
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", limit))
+	params.Set("offset", fmt.Sprintf("%d", offset))
+
+	resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/me/playlists?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get playlists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get playlists", resp)
+	}
+
+	var result struct {
+		Items []Playlist `json:"items"`
+	}
+	err = decodeJSON(resp, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode playlists response: %w", err)
+	}
+
+	return result.Items, nil
+}
+
+// FindUserPlaylist looks through the authenticated user's playlists for one owned
+// by ownerID with the given name. It returns nil if there is no such playlist.
+func (c *Client) FindUserPlaylist(ctx context.Context, ownerID, name string) (*Playlist, error) {
+	for offset := 0; ; offset += maxPlaylistsPerPage {
+		playlists, err := c.GetUserPlaylists(ctx, maxPlaylistsPerPage, offset)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range playlists {
+			if playlists[i].Name == name && playlists[i].Owner.ID == ownerID {
+				return &playlists[i], nil
+			}
+		}
+
+		if len(playlists) < maxPlaylistsPerPage {
+			return nil, nil
+		}
+	}
+}
+
+// GetPlaylistTracks gets all tracks of a playlist, following Spotify's pages of
+// 100 tracks. Removed tracks and podcast episodes are left out.
+func (c *Client) GetPlaylistTracks(ctx context.Context, playlistID string) ([]Track, error) {
+	params := url.Values{}
+	params.Set("limit", fmt.Sprintf("%d", maxPlaylistTracksPerRequest))
+	params.Set("additional_types", "track")
+	if market := c.market(); market != "" {
+		params.Set("market", market)
+	}
+
+	var tracks []Track
+	next := fmt.Sprintf("%s/playlists/%s/tracks?%s", c.apiURL(), playlistID, params.Encode())
+	for next != "" {
+		page, nextURL, err := c.playlistTracksPage(ctx, next)
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, page...)
+		next = nextURL
+	}
+	return tracks, nil
+}
+
+// playlistTracksPage gets one page of playlist tracks and the URL of the next page, if any
+func (c *Client) playlistTracksPage(ctx context.Context, pageURL string) ([]Track, string, error) {
+	resp, err := c.doRequest(ctx, "GET", pageURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get playlist tracks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", newAPIError("get playlist tracks", resp)
+	}
+
+	var result struct {
+		Items []struct {
+			Track *struct {
+				Track
+				Type string `json:"type"`
+			} `json:"track"`
+		} `json:"items"`
+		Next string `json:"next"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return nil, "", fmt.Errorf("failed to decode playlist tracks response: %w", err)
+	}
+
+	tracks := make([]Track, 0, len(result.Items))
+	for _, item := range result.Items {
+		if item.Track != nil && item.Track.Type != "episode" {
+			tracks = append(tracks, item.Track.Track)
+		}
+	}
+	return tracks, result.Next, nil
+}
+
+// ReplacePlaylistTracks replaces all tracks of a playlist. Spotify replaces at most
+// 100 tracks per request, so any further tracks are added afterwards.
+func (c *Client) ReplacePlaylistTracks(ctx context.Context, playlistID string, trackURIs []string) error {
+	first := trackURIs
+	if len(first) > maxPlaylistTracksPerRequest {
+		first = first[:maxPlaylistTracksPerRequest]
+	}
+
+	// Replacing with an empty list clears the playlist
+	err := c.playlistTracksRequest(ctx, "PUT", "replace tracks", playlistID, map[string][]string{
+		"uris": append([]string{}, first...),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(trackURIs) > len(first) {
+		return c.AddTracksToPlaylist(ctx, playlistID, trackURIs[len(first):])
+	}
+	return nil
+}
+
+// maxCoverImageSize is the largest base64-encoded cover image Spotify accepts
+const maxCoverImageSize = 256 * 1024
+
+// SetPlaylistCover replaces the cover image of a playlist with a JPEG image.
+// The image may be at most 256 KB once base64 encoded.
+func (c *Client) SetPlaylistCover(ctx context.Context, playlistID string, jpegData []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(jpegData)
+	if len(encoded) > maxCoverImageSize {
+		return fmt.Errorf("cover image is %d bytes encoded, Spotify accepts at most %d", len(encoded), maxCoverImageSize)
+	}
+
+	endpoint := fmt.Sprintf("%s/playlists/%s/images", c.apiURL(), playlistID)
+	resp, err := c.doRequestWithContentType(ctx, "PUT", endpoint, "image/jpeg", []byte(encoded))
+	if err != nil {
+		return fmt.Errorf("failed to set playlist cover: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		return newAPIError("set playlist cover", resp)
+	}
+	return nil
+}
+
+// UpdatePlaylistDetails changes the name and description of a playlist. Empty
+// values leave the current ones unchanged.
+func (c *Client) UpdatePlaylistDetails(ctx context.Context, playlistID, name, description string) error {
+	details := map[string]string{}
+	if name != "" {
+		details["name"] = name
+	}
+	if description != "" {
+		details["description"] = description
+	}
+	if len(details) == 0 {
+		return nil
+	}
+
+	jsonData, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to marshal playlist details: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PUT", fmt.Sprintf("%s/playlists/%s", c.apiURL(), playlistID), jsonData)
+	if err != nil {
+		return fmt.Errorf("failed to update playlist details: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError("update playlist details", resp)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package spotify
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestGetUserPlaylists(t *testing.T) {
+	var limit, offset string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		limit, offset = r.URL.Query().Get("limit"), r.URL.Query().Get("offset")
+		writeJSON(w, `{"items":[{"id":"p1","name":"Mood Analyst: Happy Vibes","owner":{"id":"me"},"tracks":{"total":12}}]}`)
+	})
+
+	playlists, err := c.GetUserPlaylists(context.Background(), 20, 40)
+	if err != nil {
+		t.Fatalf("GetUserPlaylists() error = %v", err)
+	}
+	if limit != "20" || offset != "40" {
+		t.Errorf("limit, offset = %q, %q, want \"20\", \"40\"", limit, offset)
+	}
+	if len(playlists) != 1 || playlists[0].ID != "p1" || playlists[0].Owner.ID != "me" || playlists[0].Tracks.Total != 12 {
+		t.Errorf("GetUserPlaylists() = %+v, want the decoded playlist", playlists)
+	}
+}
+
+func TestFindUserPlaylist(t *testing.T) {
+	// Two pages: a full first page of other playlists, then the match owned by "me"
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		type item struct {
+			ID    string `json:"id"`
+			Name  string `json:"name"`
+			Owner struct {
+				ID string `json:"id"`
+			} `json:"owner"`
+		}
+
+		var items []item
+		if offset == 0 {
+			for i := range maxPlaylistsPerPage {
+				items = append(items, item{ID: fmt.Sprintf("other-%d", i), Name: "Other"})
+			}
+			// Same name but someone else's
+			items[0].Name = "Target"
+			items[0].Owner.ID = "friend"
+		} else {
+			match := item{ID: "target", Name: "Target"}
+			match.Owner.ID = "me"
+			items = append(items, match)
+		}
+
+		body, _ := json.Marshal(map[string][]item{"items": items})
+		writeJSON(w, string(body))
+	})
+
+	playlist, err := c.FindUserPlaylist(context.Background(), "me", "Target")
+	if err != nil {
+		t.Fatalf("FindUserPlaylist() error = %v", err)
+	}
+	if playlist == nil || playlist.ID != "target" {
+		t.Errorf("FindUserPlaylist() = %+v, want the playlist on the second page", playlist)
+	}
+
+	playlist, err = c.FindUserPlaylist(context.Background(), "me", "Missing")
+	if err != nil || playlist != nil {
+		t.Errorf("FindUserPlaylist(\"Missing\") = %+v, %v, want nil, nil", playlist, err)
+	}
+}
+
+func TestGetPlaylistTracksPaginated(t *testing.T) {
+	var requests []string
+	var srvURL string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.URL.RequestURI())
+		if r.URL.Query().Get("offset") == "" {
+			writeJSON(w, fmt.Sprintf(`{"items":[
+				{"track":{"id":"t1","name":"One","type":"track"}},
+				{"track":null},
+				{"track":{"id":"e1","name":"An Episode","type":"episode"}}
+			],"next":"%s/playlists/p1/tracks?offset=100&limit=100"}`, srvURL))
+			return
+		}
+		writeJSON(w, `{"items":[{"track":{"id":"t2","name":"Two","type":"track"}}],"next":null}`)
+	})
+	srvURL = c.APIURL
+
+	tracks, err := c.GetPlaylistTracks(context.Background(), "p1")
+	if err != nil {
+		t.Fatalf("GetPlaylistTracks() error = %v", err)
+	}
+	if got := trackIDs(tracks); !slices.Equal(got, []string{"t1", "t2"}) {
+		t.Errorf("GetPlaylistTracks() = %v, want [t1 t2] without the removed track and episode", got)
+	}
+	if len(requests) != 2 || requests[1] != "/playlists/p1/tracks?offset=100&limit=100" {
+		t.Errorf("requests = %v, want the first page then the next link", requests)
+	}
+}
+
+func TestGetPlaylistTracksPageError(t *testing.T) {
+	var srvURL string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("offset") == "" {
+			writeJSON(w, fmt.Sprintf(`{"items":[{"track":{"id":"t1"}}],"next":"%s/playlists/p1/tracks?offset=100"}`, srvURL))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	})
+	srvURL = c.APIURL
+
+	if tracks, err := c.GetPlaylistTracks(context.Background(), "p1"); !errors.Is(err, ErrNotFound) || tracks != nil {
+		t.Errorf("GetPlaylistTracks() = %v, %v, want nil and ErrNotFound", tracks, err)
+	}
+}
+
+func TestReplacePlaylistTracks(t *testing.T) {
+	var methods []string
+	var sizes []int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			URIs []string `json:"uris"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		if body.URIs == nil {
+			t.Error("request body uris = null, want a list")
+		}
+		methods = append(methods, r.Method)
+		sizes = append(sizes, len(body.URIs))
+		writeJSON(w, `{"snapshot_id":"s"}`)
+	})
+
+	if err := c.ReplacePlaylistTracks(context.Background(), "p1", trackURIs(150)); err != nil {
+		t.Fatalf("ReplacePlaylistTracks() error = %v", err)
+	}
+	if want := []string{"PUT", "POST"}; !slices.Equal(methods, want) {
+		t.Errorf("methods = %v, want %v", methods, want)
+	}
+	if want := []int{100, 50}; !slices.Equal(sizes, want) {
+		t.Errorf("batch sizes = %v, want %v", sizes, want)
+	}
+
+	methods, sizes = nil, nil
+	if err := c.ReplacePlaylistTracks(context.Background(), "p1", nil); err != nil {
+		t.Fatalf("ReplacePlaylistTracks(nil) error = %v", err)
+	}
+	if !slices.Equal(methods, []string{"PUT"}) || !slices.Equal(sizes, []int{0}) {
+		t.Errorf("clearing sent %v with sizes %v, want one empty PUT", methods, sizes)
+	}
+}
+
+func TestSetPlaylistCover(t *testing.T) {
+	jpegData := []byte{0xff, 0xd8, 0xff, 0xe0, 'c', 'o', 'v', 'e', 'r', 0xff, 0xd9}
+
+	var method, path, contentType, auth string
+	var body []byte
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path = r.Method, r.URL.Path
+		contentType, auth = r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	if err := c.SetPlaylistCover(context.Background(), "p1", jpegData); err != nil {
+		t.Fatalf("SetPlaylistCover() error = %v", err)
+	}
+	if method != http.MethodPut || path != "/playlists/p1/images" {
+		t.Errorf("SetPlaylistCover() sent %s %s, want PUT /playlists/p1/images", method, path)
+	}
+	if contentType != "image/jpeg" || auth != "Bearer test-token" {
+		t.Errorf("Content-Type = %q, Authorization = %q, want image/jpeg with the token", contentType, auth)
+	}
+	if want := base64.StdEncoding.EncodeToString(jpegData); string(body) != want {
+		t.Errorf("body = %q, want the base64 encoded image %q", body, want)
+	}
+}
+
+func TestSetPlaylistCoverTooLarge(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	if err := c.SetPlaylistCover(context.Background(), "p1", make([]byte, maxCoverImageSize)); err == nil {
+		t.Error("SetPlaylistCover() error = nil, want an error for an oversized image")
+	}
+}
+
+func TestSetPlaylistCoverRejected(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	err := c.SetPlaylistCover(context.Background(), "p1", []byte{0xff, 0xd8})
+	if !errors.Is(err, ErrForbidden) {
+		t.Errorf("SetPlaylistCover() error = %v, want ErrForbidden", err)
+	}
+}
+
+func TestUpdatePlaylistDetails(t *testing.T) {
+	tests := []struct {
+		name, description string
+		want              map[string]string
+	}{
+		{"Mood Analyst: Sad Vibes", "Songs for a sad mood", map[string]string{"name": "Mood Analyst: Sad Vibes", "description": "Songs for a sad mood"}},
+		{"", "Refreshed on Jan 2, 2026.", map[string]string{"description": "Refreshed on Jan 2, 2026."}},
+	}
+
+	for _, tt := range tests {
+		var method, path string
+		var body map[string]string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			method, path = r.Method, r.URL.Path
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Errorf("decoding request body: %v", err)
+			}
+		})
+
+		if err := c.UpdatePlaylistDetails(context.Background(), "p1", tt.name, tt.description); err != nil {
+			t.Fatalf("UpdatePlaylistDetails(%q, %q) error = %v", tt.name, tt.description, err)
+		}
+		if method != http.MethodPut || path != "/playlists/p1" {
+			t.Errorf("UpdatePlaylistDetails() sent %s %s, want PUT /playlists/p1", method, path)
+		}
+		if !maps.Equal(body, tt.want) {
+			t.Errorf("UpdatePlaylistDetails(%q, %q) body = %v, want %v", tt.name, tt.description, body, tt.want)
+		}
+	}
+}
+
+func TestUpdatePlaylistDetailsNothingToChange(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	if err := c.UpdatePlaylistDetails(context.Background(), "p1", "", ""); err != nil {
+		t.Errorf("UpdatePlaylistDetails() error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,201 @@
+package spotify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// doRequest sends an authenticated request to the Spotify API. A non-nil body is
+// sent as JSON. Rate-limited (429) requests are retried up to MaxRetries times,
+// waiting for the Retry-After duration Spotify asks for unless it is longer than
+// MaxRetryDelay. When Spotify rejects the
+// access token (401) the client re-authenticates and retries once, and transient
+// network errors are retried up to MaxNetworkRetries times. Every attempt
+// first waits for the client's RateLimiter, if it has one, and is reported to
+// the client's Metrics.
+// The caller is responsible for checking the status and closing the response body.
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	return c.doRequestWithContentType(ctx, method, endpoint, "application/json", body)
+}
+
+// decodeJSON decodes the JSON body of a response into v. A 204 No Content or
+// empty body gives ErrEmptyResponse rather than an unhelpful EOF error.
+func decodeJSON(resp *http.Response, v interface{}) error {
+	if resp.StatusCode == http.StatusNoContent {
+		return ErrEmptyResponse
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		if errors.Is(err, io.EOF) {
+			return ErrEmptyResponse
+		}
+		return err
+	}
+	return nil
+}
+
+// doRequestWithContentType is doRequest for a body of the given content type
+func (c *Client) doRequestWithContentType(ctx context.Context, method, endpoint, contentType string, body []byte) (*http.Response, error) {
+	reauthenticated := false
+	networkAttempt := 0
+	for attempt := 0; ; {
+		token, err := c.token(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var reader io.Reader
+		if body != nil {
+			reader = bytes.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+		if err != nil {
+			return nil, err
+		}
+
+		req.Header.Add("Authorization", "Bearer "+token)
+		if body != nil {
+			req.Header.Add("Content-Type", contentType)
+		}
+
+		if c.RateLimiter != nil {
+			if err := c.RateLimiter.Wait(ctx); err != nil {
+				return nil, fmt.Errorf("failed waiting for rate limiter: %w", err)
+			}
+		}
+
+		start := time.Now()
+		resp, err := c.send(req)
+		c.observeRequest(method, endpoint, time.Since(start), resp, err)
+		if err != nil {
+			if networkAttempt >= c.MaxNetworkRetries || !transientError(ctx, method, err) {
+				return nil, err
+			}
+
+			delay := c.RetryBackoff << networkAttempt
+			networkAttempt++
+			c.logger().Warn("Request to Spotify failed, retrying", "error", err, "delay", delay, "attempt", networkAttempt, "max_retries", c.MaxNetworkRetries)
+			if err := sleep(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		// A token can go stale before its expiry, e.g. when it was revoked
+		if resp.StatusCode == http.StatusUnauthorized && !reauthenticated {
+			resp.Body.Close()
+			reauthenticated = true
+
+			c.logger().Info("Spotify rejected the access token, re-authenticating")
+			if err := c.reauthenticate(ctx, token); err != nil {
+				return nil, fmt.Errorf("failed to re-authenticate: %w", err)
+			}
+			continue
+		}
+
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= c.MaxRetries {
+			return resp, nil
+		}
+
+		delay := c.retryDelay(resp, attempt)
+		if c.MaxRetryDelay > 0 && delay > c.MaxRetryDelay {
+			c.logger().Warn("Rate limited by Spotify for longer than the maximum retry delay, giving up",
+				"delay", delay, "max_retry_delay", c.MaxRetryDelay)
+			return resp, nil
+		}
+		resp.Body.Close()
+		attempt++
+
+		c.logger().Warn("Rate limited by Spotify, retrying", "delay", delay, "attempt", attempt, "max_retries", c.MaxRetries)
+		if err := sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send sends a request with the client's Timeout applied. The timeout keeps
+// running until the response body is closed so slow bodies are cut off too.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if c.Timeout <= 0 {
+		return c.httpClient().Do(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), c.Timeout)
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request's timeout when its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// transientError reports whether a request that failed with err may succeed
+// when sent again. Connections that couldn't be made are always worth
+// retrying; ones that broke off are only retried for idempotent methods, as
+// the server may already have acted on the request. Nothing is retried once
+// the caller's context is done, and the client's own Timeout isn't retried.
+func transientError(ctx context.Context, method string, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	if method == http.MethodPost {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// retryDelay returns how long to wait before retrying a rate-limited request,
+// preferring the Retry-After header over exponential backoff. The backoff is
+// capped at MaxRetryDelay; Retry-After isn't, so doRequest can give up on it.
+func (c *Client) retryDelay(resp *http.Response, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	backoff := c.RetryBackoff << attempt
+	if c.MaxRetryDelay > 0 && backoff > c.MaxRetryDelay {
+		return c.MaxRetryDelay
+	}
+	return backoff
+}
+
+// sleep waits for the given duration or until the context is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,462 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestRetriesRateLimitedRequest(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1","name":"Song"}]}}`)
+	})
+
+	tracks, err := c.SearchTracks(context.Background(), "happy", 5)
+	if err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if len(tracks) != 1 || tracks[0].ID != "t1" {
+		t.Errorf("SearchTracks() = %+v, want the track from the retried request", tracks)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("server got %d requests, want 2", got)
+	}
+}
+
+func TestRateLimitRetriesExhausted(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	c.MaxRetries = 2
+
+	_, err := c.SearchTracks(context.Background(), "happy", 5)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("SearchTracks() error = %v, want ErrRateLimited", err)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("server got %d requests, want 3", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	c := &Client{RetryBackoff: 100 * time.Millisecond}
+
+	tests := []struct {
+		retryAfter string
+		attempt    int
+		want       time.Duration
+	}{
+		{"2", 0, 2 * time.Second},
+		{"0", 3, 0},
+		{"", 0, 100 * time.Millisecond},
+		{"", 2, 400 * time.Millisecond},
+		{"soon", 1, 200 * time.Millisecond},
+		{"-1", 0, 100 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		if got := c.retryDelay(resp, tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(Retry-After %q, attempt %d) = %v, want %v", tt.retryAfter, tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelayCapsBackoff(t *testing.T) {
+	c := &Client{RetryBackoff: 100 * time.Millisecond, MaxRetryDelay: 300 * time.Millisecond}
+
+	resp := &http.Response{Header: http.Header{}}
+	if got := c.retryDelay(resp, 1); got != 200*time.Millisecond {
+		t.Errorf("retryDelay(attempt 1) = %v, want %v", got, 200*time.Millisecond)
+	}
+	if got := c.retryDelay(resp, 4); got != c.MaxRetryDelay {
+		t.Errorf("retryDelay(attempt 4) = %v, want it capped at %v", got, c.MaxRetryDelay)
+	}
+}
+
+func TestRateLimitRetryAfterTooLong(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	c.MaxRetryDelay = time.Second
+
+	start := time.Now()
+	_, err := c.SearchTracks(context.Background(), "happy", 5)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("SearchTracks() error = %v, want ErrRateLimited", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("server got %d requests, want 1 without waiting out the Retry-After", got)
+	}
+	if elapsed := time.Since(start); elapsed > c.MaxRetryDelay {
+		t.Errorf("SearchTracks() took %v, want it to give up without waiting", elapsed)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	var first time.Time
+	var waited time.Duration
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			first = time.Now()
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		waited = time.Since(first)
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if waited < time.Second {
+		t.Errorf("retry sent after %v, want at least the 1s Retry-After", waited)
+	}
+}
+
+func TestRequestTimeout(t *testing.T) {
+	var attempts atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	c.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	_, err := c.SearchTracks(context.Background(), "happy", 5)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SearchTracks() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed < c.Timeout || elapsed > time.Second {
+		t.Errorf("SearchTracks() returned after %v, want around the %v timeout", elapsed, c.Timeout)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("server got %d requests, want the timed out request not retried", got)
+	}
+}
+
+func TestRequestTimeoutCoversBody(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"tracks":{"items":[`))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	c.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err == nil {
+		t.Fatal("SearchTracks() error = nil, want the slow body cut off")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SearchTracks() returned after %v, want around the %v timeout", elapsed, c.Timeout)
+	}
+}
+
+func TestRequestTimeoutWithCallerDeadline(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	})
+	c.Timeout = 5 * time.Second
+
+	// The caller's shorter deadline still applies
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := c.SearchTracks(ctx, "happy", 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("SearchTracks() error = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SearchTracks() returned after %v, want around the caller's deadline", elapsed)
+	}
+}
+
+func TestRetriesOnceAfterUnauthorized(t *testing.T) {
+	issuer := &tokenIssuer{}
+	var auths []string
+	srv := newIssuingTestServer(t, issuer, func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+		if len(auths) == 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		writeJSON(w, `{"id":"me"}`)
+	})
+
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	user, err := c.GetCurrentUser(context.Background())
+	if err != nil {
+		t.Fatalf("GetCurrentUser() error = %v", err)
+	}
+	if user.ID != "me" {
+		t.Errorf("GetCurrentUser() = %+v, want the retried response", user)
+	}
+	if want := []string{"Bearer token-1", "Bearer token-2"}; !slices.Equal(auths, want) {
+		t.Errorf("requests sent with %q, want %q", auths, want)
+	}
+}
+
+func TestUnauthorizedRetriedOnlyOnce(t *testing.T) {
+	issuer := &tokenIssuer{}
+	var attempts atomic.Int32
+	srv := newIssuingTestServer(t, issuer, func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.Authenticate(context.Background()); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+
+	if _, err := c.GetCurrentUser(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("GetCurrentUser() error = %v, want ErrUnauthorized", err)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("server got %d requests, want 2", got)
+	}
+	if got := issuer.issued.Load(); got != 2 {
+		t.Errorf("token endpoint called %d times, want one re-authentication", got)
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+		wantID  string
+	}{
+		{"no content", http.StatusNoContent, "", ErrEmptyResponse, ""},
+		{"empty body", http.StatusOK, "", ErrEmptyResponse, ""},
+		{"body", http.StatusOK, `{"id":"u1"}`, nil, "u1"},
+	}
+
+	for _, tt := range tests {
+		resp := &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))}
+		var user User
+		err := decodeJSON(resp, &user)
+		if !errors.Is(err, tt.wantErr) || user.ID != tt.wantID {
+			t.Errorf("%s: decodeJSON() = %q, %v, want %q, %v", tt.name, user.ID, err, tt.wantID, tt.wantErr)
+		}
+	}
+
+	resp := &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("{not json"))}
+	if err := decodeJSON(resp, &User{}); err == nil || errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("decodeJSON(invalid) error = %v, want a decode error", err)
+	}
+}
+
+func TestEmptyResponseIsNotADecodeError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	_, err := c.GetCurrentUser(context.Background())
+	if !errors.Is(err, ErrEmptyResponse) {
+		t.Errorf("GetCurrentUser() error = %v, want ErrEmptyResponse", err)
+	}
+	if strings.Contains(fmt.Sprint(err), "EOF") {
+		t.Errorf("GetCurrentUser() error = %v, want no EOF", err)
+	}
+}
+
+func TestRateLimiterSpacesRequests(t *testing.T) {
+	var sent []time.Time
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, time.Now())
+		writeJSON(w, `{"id":"me"}`)
+	})
+	c.RateLimiter = rate.NewLimiter(rate.Every(50*time.Millisecond), 1)
+
+	for range 4 {
+		if _, err := c.GetCurrentUser(context.Background()); err != nil {
+			t.Fatalf("GetCurrentUser() error = %v", err)
+		}
+	}
+
+	for i := 1; i < len(sent); i++ {
+		// Allow for timer slack, but not for requests sent back to back
+		if gap := sent[i].Sub(sent[i-1]); gap < 40*time.Millisecond {
+			t.Errorf("request %d was sent %v after the one before, want about 50ms", i+1, gap)
+		}
+	}
+}
+
+func TestRateLimiterHonorsContext(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		writeJSON(w, `{"id":"me"}`)
+	})
+	c.RateLimiter = rate.NewLimiter(rate.Every(time.Hour), 1)
+
+	if _, err := c.GetCurrentUser(context.Background()); err != nil {
+		t.Fatalf("GetCurrentUser() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.GetCurrentUser(ctx); err == nil {
+		t.Error("GetCurrentUser() error = nil, want the wait for the limiter to fail")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("sent %d requests, want only the first", got)
+	}
+}
+
+// flakyTransport fails the first failures requests with err and sends the rest
+type flakyTransport struct {
+	failures int32
+	err      error
+	requests atomic.Int32
+}
+
+func (ft *flakyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if ft.requests.Add(1) <= ft.failures {
+		return nil, ft.err
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// connectionReset is the error of a connection the server dropped
+var connectionReset = &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}
+
+func TestRetriesTransientNetworkError(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1"}]}}`)
+	})
+	transport := &flakyTransport{failures: 1, err: connectionReset}
+	c.HTTPClient = &http.Client{Transport: transport}
+
+	tracks, err := c.SearchTracks(context.Background(), "happy", 5)
+	if err != nil {
+		t.Fatalf("SearchTracks() error = %v, want the retry to succeed", err)
+	}
+	if len(tracks) != 1 {
+		t.Errorf("SearchTracks() = %d tracks, want 1", len(tracks))
+	}
+	if got := transport.requests.Load(); got != 2 {
+		t.Errorf("sent %d requests, want 2", got)
+	}
+}
+
+func TestNetworkRetriesExhausted(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+	transport := &flakyTransport{failures: 100, err: connectionReset}
+	c.HTTPClient = &http.Client{Transport: transport}
+
+	_, err := c.SearchTracks(context.Background(), "happy", 5)
+	if !errors.Is(err, syscall.ECONNRESET) {
+		t.Fatalf("SearchTracks() error = %v, want the connection reset", err)
+	}
+	if got, want := transport.requests.Load(), int32(c.MaxNetworkRetries+1); got != want {
+		t.Errorf("sent %d requests, want %d", got, want)
+	}
+}
+
+func TestNetworkRetriesDisabled(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[]}}`)
+	})
+	transport := &flakyTransport{failures: 1, err: connectionReset}
+	c.HTTPClient = &http.Client{Transport: transport}
+	c.MaxNetworkRetries = 0
+
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err == nil {
+		t.Fatal("SearchTracks() error = nil, want the failure without retries")
+	}
+	if got := transport.requests.Load(); got != 1 {
+		t.Errorf("sent %d requests, want 1", got)
+	}
+}
+
+func TestHTTPErrorsAreNotRetriedAsNetworkErrors(t *testing.T) {
+	var requests atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Error(w, `{"error":{"status":404,"message":"Not found"}}`, http.StatusNotFound)
+	})
+
+	if _, err := c.SearchTracks(context.Background(), "happy", 5); err == nil {
+		t.Fatal("SearchTracks() error = nil, want the 404")
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("server got %d requests, want 1", got)
+	}
+}
+
+func TestTransientError(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		method string
+		err    error
+		want   bool
+	}{
+		{"connection reset", context.Background(), http.MethodGet, connectionReset, true},
+		{"unexpected EOF", context.Background(), http.MethodGet, io.ErrUnexpectedEOF, true},
+		{"DNS failure", context.Background(), http.MethodGet, &net.DNSError{Err: "no such host", Name: "api.spotify.com"}, true},
+		{"connection refused", context.Background(), http.MethodPost, &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}, true},
+		// The server may have acted on a POST that was cut off, so it's not sent twice
+		{"POST reset", context.Background(), http.MethodPost, connectionReset, false},
+		{"deadline", context.Background(), http.MethodGet, context.DeadlineExceeded, false},
+		{"canceled", canceled, http.MethodGet, connectionReset, false},
+		{"other", context.Background(), http.MethodGet, errors.New("malformed response"), false},
+	}
+	for _, tt := range tests {
+		if got := transientError(tt.ctx, tt.method, tt.err); got != tt.want {
+			t.Errorf("%s: transientError() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
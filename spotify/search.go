@@ -0,0 +1,59 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// SearchFilters narrow a track search with Spotify's field filters. Empty
+// fields are left out.
+type SearchFilters struct {
+	Artist string
+	Year   string // a year such as "1995" or a range such as "1990-1999"
+	Genre  string
+
+	// AnyMarket searches tracks from every market rather than only those
+	// playable in the client's market
+	AnyMarket bool
+}
+
+// searchSyntaxReplacer blanks out the characters Spotify reads as search syntax
+var searchSyntaxReplacer = strings.NewReplacer(":", " ", `"`, " ")
+
+// EscapeSearchText makes free text safe to put in a search query, so e.g.
+// "re:union" is searched as words rather than read as a field filter
+func EscapeSearchText(text string) string {
+	return strings.Join(strings.Fields(searchSyntaxReplacer.Replace(text)), " ")
+}
+
+// BuildSearchQuery assembles a search query from escaped free text followed by
+// the filters, e.g. `sad songs artist:"Adele" year:2010-2019`
+func BuildSearchQuery(text string, filters SearchFilters) string {
+	parts := []string{EscapeSearchText(text)}
+	if filters.Artist != "" {
+		parts = append(parts, fmt.Sprintf("artist:%q", EscapeSearchText(filters.Artist)))
+	}
+	if filters.Year != "" {
+		parts = append(parts, "year:"+strings.Join(strings.Fields(filters.Year), ""))
+	}
+	if filters.Genre != "" {
+		parts = append(parts, fmt.Sprintf("genre:%q", EscapeSearchText(filters.Genre)))
+	}
+	return strings.TrimSpace(strings.Join(parts, " "))
+}
+
+// SearchTracksFiltered searches for tracks matching free text and filters,
+// skipping the first offset results. The text is escaped, so unlike
+// SearchTracks it can't contain field filters of its own.
+func (c *Client) SearchTracksFiltered(ctx context.Context, text string, filters SearchFilters, limit, offset int) ([]Track, error) {
+	query := BuildSearchQuery(text, filters)
+	if query == "" {
+		return nil, fmt.Errorf("empty search query")
+	}
+	market := c.market()
+	if filters.AnyMarket {
+		market = ""
+	}
+	return c.searchTracks(ctx, query, limit, offset, market)
+}
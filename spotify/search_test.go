@@ -0,0 +1,85 @@
+package spotify
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestEscapeSearchText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"re:union", "re union"},
+		{`"quoted" words`, "quoted words"},
+		{"  extra   spaces ", "extra spaces"},
+		{"artist:Adele", "artist Adele"},
+		{"plain", "plain"},
+	}
+
+	for _, tt := range tests {
+		if got := EscapeSearchText(tt.text); got != tt.want {
+			t.Errorf("EscapeSearchText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestBuildSearchQuery(t *testing.T) {
+	tests := []struct {
+		text    string
+		filters SearchFilters
+		want    string
+	}{
+		{"sad songs", SearchFilters{Artist: "Adele", Year: "2010-2019"}, `sad songs artist:"Adele" year:2010-2019`},
+		{"happy", SearchFilters{Genre: "hip-hop"}, `happy genre:"hip-hop"`},
+		{"re:union", SearchFilters{Artist: `The "Band"`}, `re union artist:"The Band"`},
+		{"", SearchFilters{Year: "1990 - 1999"}, "year:1990-1999"},
+		{"chill", SearchFilters{}, "chill"},
+		{"", SearchFilters{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := BuildSearchQuery(tt.text, tt.filters); got != tt.want {
+			t.Errorf("BuildSearchQuery(%q, %+v) = %q, want %q", tt.text, tt.filters, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTracksFiltered(t *testing.T) {
+	var q, market, offset string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		q, market, offset = r.URL.Query().Get("q"), r.URL.Query().Get("market"), r.URL.Query().Get("offset")
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1"}]}}`)
+	})
+	c.Market = "US"
+
+	filters := SearchFilters{Artist: "Adele", Year: "2015"}
+	if _, err := c.SearchTracksFiltered(context.Background(), "sad: songs", filters, 5, 10); err != nil {
+		t.Fatalf("SearchTracksFiltered() error = %v", err)
+	}
+	if want := `sad songs artist:"Adele" year:2015`; q != want {
+		t.Errorf("q = %q, want %q", q, want)
+	}
+	if market != "US" || offset != "10" {
+		t.Errorf("market = %q, offset = %q, want US and 10", market, offset)
+	}
+
+	filters.AnyMarket = true
+	if _, err := c.SearchTracksFiltered(context.Background(), "sad", filters, 5, 0); err != nil {
+		t.Fatalf("SearchTracksFiltered() error = %v", err)
+	}
+	if market != "" {
+		t.Errorf("market = %q with AnyMarket, want none", market)
+	}
+}
+
+func TestSearchTracksFilteredEmpty(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	if _, err := c.SearchTracksFiltered(context.Background(), " : ", SearchFilters{}, 5, 0); err == nil {
+		t.Error("SearchTracksFiltered() error = nil, want an error for an empty query")
+	}
+}
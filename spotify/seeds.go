@@ -0,0 +1,55 @@
+package spotify
+
+// Seeds are the tracks, artists and genres a recommendations request is based on
+type Seeds struct {
+	Tracks  []string
+	Artists []string
+	Genres  []string
+}
+
+// Len returns the total number of seeds
+func (s Seeds) Len() int {
+	return len(s.Tracks) + len(s.Artists) + len(s.Genres)
+}
+
+// SelectSeeds picks up to MaxSeeds seeds from the candidates, taking one track,
+// one artist and one genre in turn so each kind is represented when it can be.
+// Candidates are taken in the order given, so the most relevant should come
+// first; duplicates and empty values are skipped. When one kind runs out the
+// others fill the remaining slots.
+func SelectSeeds(tracks, artists, genres []string) Seeds {
+	candidates := [][]string{tracks, artists, genres}
+	picked := make([][]string, len(candidates))
+	next := make([]int, len(candidates))
+	seen := make([]map[string]bool, len(candidates))
+	for i := range seen {
+		seen[i] = make(map[string]bool)
+	}
+
+	total := 0
+	for total < MaxSeeds {
+		progressed := false
+		for i, list := range candidates {
+			if total == MaxSeeds {
+				break
+			}
+			for next[i] < len(list) {
+				value := list[next[i]]
+				next[i]++
+				if value == "" || seen[i][value] {
+					continue
+				}
+				seen[i][value] = true
+				picked[i] = append(picked[i], value)
+				total++
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			break
+		}
+	}
+
+	return Seeds{Tracks: picked[0], Artists: picked[1], Genres: picked[2]}
+}
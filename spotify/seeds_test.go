@@ -0,0 +1,157 @@
+package spotify
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSelectSeeds(t *testing.T) {
+	tests := []struct {
+		name                   string
+		tracks, artists, genre []string
+		want                   Seeds
+	}{
+		{
+			name:   "interleaved",
+			tracks: []string{"t1", "t2", "t3"}, artists: []string{"a1", "a2"}, genre: []string{"pop", "rock"},
+			want: Seeds{Tracks: []string{"t1", "t2"}, Artists: []string{"a1", "a2"}, Genres: []string{"pop"}},
+		},
+		{
+			name:   "tracks fill the rest",
+			tracks: []string{"t1", "t2", "t3", "t4", "t5", "t6"}, artists: []string{"a1"},
+			want: Seeds{Tracks: []string{"t1", "t2", "t3", "t4"}, Artists: []string{"a1"}},
+		},
+		{
+			name:   "duplicates and empties skipped",
+			tracks: []string{"t1", "", "t1", "t2"}, genre: []string{"pop", "pop"},
+			want: Seeds{Tracks: []string{"t1", "t2"}, Genres: []string{"pop"}},
+		},
+		{
+			name: "none",
+			want: Seeds{},
+		},
+	}
+
+	for _, tt := range tests {
+		got := SelectSeeds(tt.tracks, tt.artists, tt.genre)
+		if !slices.Equal(got.Tracks, tt.want.Tracks) || !slices.Equal(got.Artists, tt.want.Artists) || !slices.Equal(got.Genres, tt.want.Genres) {
+			t.Errorf("%s: SelectSeeds() = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got.Len() > MaxSeeds {
+			t.Errorf("%s: SelectSeeds() picked %d seeds, want at most %d", tt.name, got.Len(), MaxSeeds)
+		}
+	}
+}
+
+func TestGetRecommendationsSeedArtists(t *testing.T) {
+	var query map[string][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(w, `{"tracks":[]}`)
+	})
+
+	tracks := []string{"t1", "t2", "t3", "t4"}
+	artists := []string{"a1", "a2", "a3", "a4"}
+	if _, err := c.GetRecommendations(context.Background(), tracks, artists, nil, nil, 10); err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+
+	gotTracks := strings.Split(query["seed_tracks"][0], ",")
+	gotArtists := strings.Split(query["seed_artists"][0], ",")
+	if !slices.Equal(gotTracks, []string{"t1", "t2", "t3"}) || !slices.Equal(gotArtists, []string{"a1", "a2"}) {
+		t.Errorf("seed_tracks = %v, seed_artists = %v, want [t1 t2 t3] and [a1 a2]", gotTracks, gotArtists)
+	}
+	if total := len(gotTracks) + len(gotArtists); total != MaxSeeds {
+		t.Errorf("sent %d seeds, want %d", total, MaxSeeds)
+	}
+}
+
+func TestGetRecommendationsArtistOnly(t *testing.T) {
+	var query map[string][]string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(w, `{"tracks":[{"id":"r1"}]}`)
+	})
+
+	if _, err := c.GetRecommendations(context.Background(), nil, []string{"a1"}, nil, nil, 10); err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+	if got := query["seed_artists"]; !slices.Equal(got, []string{"a1"}) {
+		t.Errorf("seed_artists = %v, want [a1]", got)
+	}
+	if _, ok := query["seed_tracks"]; ok {
+		t.Errorf("seed_tracks = %v, want it left out", query["seed_tracks"])
+	}
+}
+
+func TestSelectSeedsInputSizes(t *testing.T) {
+	genres := []string{"pop", "rock", "indie", "jazz", "soul", "funk"}
+
+	tests := []struct {
+		tracks, artists   int
+		wantTracks        int
+		wantArtists       int
+		wantGenres        int
+		wantLeadingGenres []string
+	}{
+		{tracks: 0, artists: 0, wantGenres: 5, wantLeadingGenres: []string{"pop", "rock", "indie", "jazz", "soul"}},
+		{tracks: 0, artists: 1, wantArtists: 1, wantGenres: 4, wantLeadingGenres: []string{"pop", "rock", "indie", "jazz"}},
+		{tracks: 1, artists: 0, wantTracks: 1, wantGenres: 4, wantLeadingGenres: []string{"pop", "rock", "indie", "jazz"}},
+		{tracks: 2, artists: 2, wantTracks: 2, wantArtists: 2, wantGenres: 1, wantLeadingGenres: []string{"pop"}},
+		{tracks: 10, artists: 10, wantTracks: 2, wantArtists: 2, wantGenres: 1, wantLeadingGenres: []string{"pop"}},
+	}
+
+	for _, tt := range tests {
+		seeds := SelectSeeds(trackURIs(tt.tracks), trackURIs(tt.artists), genres)
+		if len(seeds.Tracks) != tt.wantTracks || len(seeds.Artists) != tt.wantArtists || len(seeds.Genres) != tt.wantGenres {
+			t.Errorf("SelectSeeds(%d tracks, %d artists, %d genres) = %d, %d, %d, want %d, %d, %d",
+				tt.tracks, tt.artists, len(genres), len(seeds.Tracks), len(seeds.Artists), len(seeds.Genres),
+				tt.wantTracks, tt.wantArtists, tt.wantGenres)
+		}
+		if !slices.Equal(seeds.Genres, tt.wantLeadingGenres) {
+			t.Errorf("SelectSeeds(%d tracks, %d artists).Genres = %v, want %v", tt.tracks, tt.artists, seeds.Genres, tt.wantLeadingGenres)
+		}
+	}
+}
+
+func TestGetRecommendationsNoSeeds(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	_, err := c.GetRecommendations(context.Background(), nil, []string{""}, nil, nil, 10)
+	if !errors.Is(err, ErrNoSeeds) {
+		t.Errorf("GetRecommendations() error = %v, want ErrNoSeeds", err)
+	}
+}
+
+func TestGetRecommendationsFeatureBounds(t *testing.T) {
+	var query url.Values
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		writeJSON(w, `{"tracks":[]}`)
+	})
+
+	params := map[string]interface{}{
+		"target_valence": float32(0.2),
+		"max_valence":    float32(0.45),
+		"min_energy":     float32(0.6),
+	}
+	if _, err := c.GetRecommendations(context.Background(), []string{"t1"}, nil, nil, params, 10); err != nil {
+		t.Fatalf("GetRecommendations() error = %v", err)
+	}
+
+	for key, want := range map[string]string{"target_valence": "0.2", "max_valence": "0.45", "min_energy": "0.6"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if query.Has("min_valence") || query.Has("max_energy") {
+		t.Errorf("query = %v, want only the bounds given", query)
+	}
+}
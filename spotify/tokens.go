@@ -0,0 +1,72 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// savedTokens is the on-disk format of the client's tokens
+type savedTokens struct {
+	AccessToken  string    `json:"access_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry,omitempty"`
+}
+
+// SaveTokens writes the client's access token, refresh token and expiry to a
+// JSON file readable only by the current user
+func (c *Client) SaveTokens(path string) error {
+	c.mu.RLock()
+	tokens := savedTokens{
+		AccessToken:  c.accessToken,
+		RefreshToken: c.refreshToken,
+		Expiry:       c.tokenExpiry,
+	}
+	c.mu.RUnlock()
+
+	if tokens.AccessToken == "" && tokens.RefreshToken == "" {
+		return ErrNotAuthenticated
+	}
+
+	data, err := json.MarshalIndent(tokens, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tokens: %w", err)
+	}
+
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write tokens file: %w", err)
+	}
+	return nil
+}
+
+// LoadTokens restores tokens written by SaveTokens. If the access token has
+// expired it is refreshed straight away.
+func (c *Client) LoadTokens(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tokens file: %w", err)
+	}
+
+	var tokens savedTokens
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return fmt.Errorf("failed to decode tokens file: %w", err)
+	}
+
+	c.mu.Lock()
+	c.accessToken = tokens.AccessToken
+	c.tokenExpiry = tokens.Expiry
+	if tokens.RefreshToken != "" {
+		c.refreshToken = tokens.RefreshToken
+	}
+	c.userToken = c.refreshToken != ""
+	c.mu.Unlock()
+
+	if _, valid := c.currentToken(); !valid {
+		if err := c.Authenticate(ctx); err != nil {
+			return fmt.Errorf("failed to refresh loaded tokens: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package spotify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTokensFile writes tokens to a file in a temporary directory and returns its path
+func writeTokensFile(t *testing.T, tokens savedTokens) string {
+	t.Helper()
+
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		t.Fatalf("marshaling tokens: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatalf("writing tokens file: %v", err)
+	}
+	return path
+}
+
+func TestSaveAndLoadTokens(t *testing.T) {
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	saved := NewClient("id", "secret")
+	saved.accessToken = "access-1"
+	saved.refreshToken = "refresh-1"
+	saved.tokenExpiry = expiry
+
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	if err := saved.SaveTokens(path); err != nil {
+		t.Fatalf("SaveTokens() error = %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("stat tokens file: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("tokens file permissions = %v, want 0600", perm)
+	}
+
+	loaded := NewClient("id", "secret")
+	if err := loaded.LoadTokens(context.Background(), path); err != nil {
+		t.Fatalf("LoadTokens() error = %v", err)
+	}
+
+	if loaded.accessToken != "access-1" || loaded.refreshToken != "refresh-1" || !loaded.tokenExpiry.Equal(expiry) {
+		t.Errorf("loaded tokens = %q, %q, %v, want the saved ones", loaded.accessToken, loaded.refreshToken, loaded.tokenExpiry)
+	}
+	if !loaded.CanActAsUser() {
+		t.Error("CanActAsUser() = false after loading a refresh token, want true")
+	}
+}
+
+func TestLoadTokensRefreshesExpired(t *testing.T) {
+	issuer := &tokenIssuer{}
+	var grantType, refreshToken string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		grantType, refreshToken = r.PostForm.Get("grant_type"), r.PostForm.Get("refresh_token")
+		issuer.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	path := writeTokensFile(t, savedTokens{
+		AccessToken:  "stale",
+		RefreshToken: "refresh-1",
+		Expiry:       time.Now().Add(-time.Hour),
+	})
+
+	c := newUnauthenticatedClient(t, srv)
+	if err := c.LoadTokens(context.Background(), path); err != nil {
+		t.Fatalf("LoadTokens() error = %v", err)
+	}
+
+	if grantType != "refresh_token" || refreshToken != "refresh-1" {
+		t.Errorf("refresh request grant_type = %q, refresh_token = %q, want a refresh with the saved token", grantType, refreshToken)
+	}
+	if token, valid := c.currentToken(); token != "token-1" || !valid {
+		t.Errorf("currentToken() = %q, %v, want the refreshed token", token, valid)
+	}
+	if got := c.RefreshToken(); got != "refresh-1" {
+		t.Errorf("RefreshToken() = %q, want the saved one kept", got)
+	}
+}
+
+func TestSaveTokensNotAuthenticated(t *testing.T) {
+	c := NewClient("id", "secret")
+	err := c.SaveTokens(filepath.Join(t.TempDir(), "tokens.json"))
+	if !errors.Is(err, ErrNotAuthenticated) {
+		t.Errorf("SaveTokens() error = %v, want ErrNotAuthenticated", err)
+	}
+}
+
+func TestLoadTokensErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := os.WriteFile(invalid, []byte("{not json"), 0600); err != nil {
+		t.Fatalf("writing tokens file: %v", err)
+	}
+
+	for _, path := range []string{filepath.Join(dir, "missing.json"), invalid} {
+		c := NewClient("id", "secret")
+		if err := c.LoadTokens(context.Background(), path); err == nil {
+			t.Errorf("LoadTokens(%s) error = nil, want an error", filepath.Base(path))
+		}
+	}
+}
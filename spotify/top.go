@@ -0,0 +1,51 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Time ranges for a user's top items
+const (
+	TimeRangeShort  = "short_term"  // roughly the last 4 weeks
+	TimeRangeMedium = "medium_term" // roughly the last 6 months
+	TimeRangeLong   = "long_term"   // roughly the last year
+)
+
+// GetTopTracks gets the authenticated user's most listened tracks over a time
+// range. An empty time range uses Spotify's default of medium_term.
+func (c *Client) GetTopTracks(ctx context.Context, timeRange string, limit int) ([]Track, error) {
+	switch timeRange {
+	case "":
+		timeRange = TimeRangeMedium
+	case TimeRangeShort, TimeRangeMedium, TimeRangeLong:
+	default:
+		return nil, fmt.Errorf("invalid time range %q, expected %s, %s or %s", timeRange, TimeRangeShort, TimeRangeMedium, TimeRangeLong)
+	}
+
+	params := url.Values{}
+	params.Set("time_range", timeRange)
+	params.Set("limit", fmt.Sprintf("%d", limit))
+
+	resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/me/top/tracks?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top tracks: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get top tracks", resp)
+	}
+
+	var result struct {
+		Items []Track `json:"items"`
+	}
+	err = decodeJSON(resp, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode top tracks response: %w", err)
+	}
+
+	return result.Items, nil
+}
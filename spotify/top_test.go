@@ -0,0 +1,48 @@
+package spotify
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestGetTopTracks(t *testing.T) {
+	tests := []struct {
+		timeRange string
+		want      string
+	}{
+		{TimeRangeShort, "short_term"},
+		{TimeRangeLong, "long_term"},
+		{"", "medium_term"},
+	}
+
+	for _, tt := range tests {
+		var path, timeRange, limit string
+		c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			path, timeRange, limit = r.URL.Path, r.URL.Query().Get("time_range"), r.URL.Query().Get("limit")
+			writeJSON(w, `{"items":[{"id":"t1","name":"One"},{"id":"t2","name":"Two"}],"total":2}`)
+		})
+
+		tracks, err := c.GetTopTracks(context.Background(), tt.timeRange, 2)
+		if err != nil {
+			t.Fatalf("GetTopTracks(%q) error = %v", tt.timeRange, err)
+		}
+		if path != "/me/top/tracks" || timeRange != tt.want || limit != "2" {
+			t.Errorf("GetTopTracks(%q) requested %s with time_range %q, limit %q, want /me/top/tracks with %q, \"2\"", tt.timeRange, path, timeRange, limit, tt.want)
+		}
+		if got := trackIDs(tracks); !slices.Equal(got, []string{"t1", "t2"}) {
+			t.Errorf("GetTopTracks(%q) = %v, want [t1 t2]", tt.timeRange, got)
+		}
+	}
+}
+
+func TestGetTopTracksInvalidTimeRange(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+
+	if _, err := c.GetTopTracks(context.Background(), "forever", 5); err == nil {
+		t.Error("GetTopTracks(\"forever\") error = nil, want an error")
+	}
+}
@@ -0,0 +1,91 @@
+package spotify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxTrackIDs is the most track IDs Spotify accepts in one tracks request
+const maxTrackIDs = 50
+
+// GetTracks gets the full details of tracks, such as their album and duration.
+// Tracks Spotify doesn't know are left out of the result.
+func (c *Client) GetTracks(ctx context.Context, trackIDs []string) ([]Track, error) {
+	var tracks []Track
+	for _, batch := range chunk(trackIDs, maxTrackIDs) {
+		params := url.Values{}
+		params.Set("ids", strings.Join(batch, ","))
+		if market := c.market(); market != "" {
+			params.Set("market", market)
+		}
+
+		resp, err := c.doRequest(ctx, "GET", c.apiURL()+"/tracks?"+params.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tracks: %w", err)
+		}
+
+		batchTracks, err := decodeTracks(resp)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		tracks = append(tracks, batchTracks...)
+	}
+
+	return tracks, nil
+}
+
+// decodeTracks decodes a several-tracks response, skipping null entries
+func decodeTracks(resp *http.Response) ([]Track, error) {
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("get tracks", resp)
+	}
+
+	var result struct {
+		Tracks []*Track `json:"tracks"`
+	}
+	if err := decodeJSON(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode tracks response: %w", err)
+	}
+
+	tracks := make([]Track, 0, len(result.Tracks))
+	for _, t := range result.Tracks {
+		if t != nil {
+			tracks = append(tracks, *t)
+		}
+	}
+	return tracks, nil
+}
+
+// ParseTrackID extracts the track ID from a Spotify track link such as
+// "https://open.spotify.com/track/<id>?si=..." (with or without the scheme, and
+// with an optional locale such as "/intl-de") or a "spotify:track:<id>" URI.
+// It reports false for anything else, including links to albums or playlists.
+func ParseTrackID(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	if id, ok := strings.CutPrefix(s, "spotify:track:"); ok {
+		if !spotifyIDPattern.MatchString(id) {
+			return "", false
+		}
+		return id, true
+	}
+
+	if !strings.Contains(s, "://") {
+		s = "https://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Host != "open.spotify.com" {
+		return "", false
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(segments) > 0 && strings.HasPrefix(segments[0], "intl-") {
+		segments = segments[1:]
+	}
+	if len(segments) != 2 || segments[0] != "track" || !spotifyIDPattern.MatchString(segments[1]) {
+		return "", false
+	}
+	return segments[1], true
+}
@@ -0,0 +1,216 @@
+package spotify
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestGetTracks(t *testing.T) {
+	var ids string
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		ids = r.URL.Query().Get("ids")
+		writeJSON(w, `{"tracks":[
+			{
+				"id": "t1",
+				"name": "Everlong",
+				"duration_ms": 250546,
+				"popularity": 79,
+				"album": {
+					"name": "The Colour and the Shape",
+					"images": [
+						{"url": "https://i.scdn.co/image/large", "height": 640, "width": 640},
+						{"url": "https://i.scdn.co/image/small", "height": 64, "width": 64}
+					]
+				}
+			},
+			null
+		]}`)
+	})
+
+	tracks, err := c.GetTracks(context.Background(), []string{"t1", "gone"})
+	if err != nil {
+		t.Fatalf("GetTracks() error = %v", err)
+	}
+	if ids != "t1,gone" {
+		t.Errorf("ids = %q, want %q", ids, "t1,gone")
+	}
+	if len(tracks) != 1 {
+		t.Fatalf("GetTracks() returned %d tracks, want 1 with the null entry skipped", len(tracks))
+	}
+
+	track := tracks[0]
+	if track.DurationMs != 250546 || track.Popularity != 79 || track.Album.Name != "The Colour and the Shape" {
+		t.Errorf("GetTracks()[0] = %+v, want the decoded duration, popularity and album", track)
+	}
+	if len(track.Album.Images) != 2 || track.Album.Images[1].URL != "https://i.scdn.co/image/small" {
+		t.Errorf("album images = %+v, want both decoded", track.Album.Images)
+	}
+}
+
+func TestGetTracksBatches(t *testing.T) {
+	var batches int
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		batches++
+		writeJSON(w, `{"tracks":[]}`)
+	})
+
+	if _, err := c.GetTracks(context.Background(), trackURIs(120)); err != nil {
+		t.Fatalf("GetTracks() error = %v", err)
+	}
+	if batches != 3 {
+		t.Errorf("server got %d requests, want 3 batches of at most %d", batches, maxTrackIDs)
+	}
+}
+
+func TestBestImage(t *testing.T) {
+	album := Album{Images: []Image{
+		{URL: "large", Width: 640},
+		{URL: "medium", Width: 300},
+		{URL: "small", Width: 64},
+	}}
+
+	tests := []struct {
+		minWidth int
+		want     string
+	}{
+		{0, "small"},
+		{100, "medium"},
+		{300, "medium"},
+		{301, "large"},
+		{1000, "large"},
+	}
+	for _, tt := range tests {
+		image, ok := album.BestImage(tt.minWidth)
+		if !ok || image.URL != tt.want {
+			t.Errorf("BestImage(%d) = %q, %v, want %q", tt.minWidth, image.URL, ok, tt.want)
+		}
+	}
+
+	if _, ok := (Album{}).BestImage(100); ok {
+		t.Error("BestImage() of an album without images reported true")
+	}
+}
+
+func TestArtistNames(t *testing.T) {
+	track := newTrack("t1", "Under Pressure", "Queen", "David Bowie")
+	if got := track.ArtistNames(); !slices.Equal(got, []string{"Queen", "David Bowie"}) {
+		t.Errorf("ArtistNames() = %v, want [Queen David Bowie]", got)
+	}
+}
+
+func TestSearchDecodesAlbumImages(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1","album":{"name":"25","images":[
+			{"url":"https://i.scdn.co/image/640","height":640,"width":640},
+			{"url":"https://i.scdn.co/image/300","height":300,"width":300},
+			{"url":"https://i.scdn.co/image/64","height":64,"width":64}
+		]}}]}}`)
+	})
+
+	tracks, err := c.SearchTracks(context.Background(), "hello", 1)
+	if err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if len(tracks) != 1 || len(tracks[0].Album.Images) != 3 {
+		t.Fatalf("SearchTracks() = %+v, want one track with three album images", tracks)
+	}
+	want := Image{URL: "https://i.scdn.co/image/640", Height: 640, Width: 640}
+	if largest, ok := tracks[0].Album.BestImage(1 << 20); !ok || largest != want {
+		t.Errorf("largest image = %+v, want %+v", largest, want)
+	}
+}
+
+func TestParseTrackID(t *testing.T) {
+	const id = "4uLU6hMCjMI75M1A2tKUQC"
+	tests := []struct {
+		input  string
+		wantID string
+		wantOK bool
+	}{
+		{"https://open.spotify.com/track/" + id, id, true},
+		{"https://open.spotify.com/track/" + id + "?si=abc123", id, true},
+		{"open.spotify.com/track/" + id, id, true},
+		{"https://open.spotify.com/intl-de/track/" + id, id, true},
+		{"  spotify:track:" + id + "  ", id, true},
+		{"spotify:track:tooShort", "", false},
+		{"spotify:album:" + id, "", false},
+		{"https://open.spotify.com/album/" + id, "", false},
+		{"https://open.spotify.com/playlist/" + id, "", false},
+		{"https://open.spotify.com/track/" + id + "/extra", "", false},
+		{"https://open.spotify.com/track/not-an-id!!!!!!!!!!!!!!!", "", false},
+		{"https://example.com/track/" + id, "", false},
+		{"http://[::1", "", false},
+		{"happy", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		id, ok := ParseTrackID(tt.input)
+		if id != tt.wantID || ok != tt.wantOK {
+			t.Errorf("ParseTrackID(%q) = %q, %v, want %q, %v", tt.input, id, ok, tt.wantID, tt.wantOK)
+		}
+	}
+}
+
+func TestFilterByMarket(t *testing.T) {
+	everywhere := newTrack("1", "Everywhere")
+	usOnly := newTrack("2", "US only")
+	usOnly.AvailableMarkets = []string{"US"}
+	europe := newTrack("3", "Europe")
+	europe.AvailableMarkets = []string{"DE", "FR", "GB"}
+	tracks := []Track{everywhere, usOnly, europe}
+
+	tests := []struct {
+		market string
+		want   []string
+	}{
+		{"US", []string{"1", "2"}},
+		{"DE", []string{"1", "3"}},
+		{"JP", []string{"1"}},
+	}
+	for _, tt := range tests {
+		if got := trackIDs(FilterByMarket(tracks, tt.market)); !slices.Equal(got, tt.want) {
+			t.Errorf("FilterByMarket(%q) = %v, want %v", tt.market, got, tt.want)
+		}
+	}
+}
+
+func TestPlayableTracks(t *testing.T) {
+	usOnly := newTrack("1", "US only")
+	usOnly.AvailableMarkets = []string{"US"}
+	europe := newTrack("2", "Europe")
+	europe.AvailableMarkets = []string{"DE"}
+	tracks := []Track{usOnly, europe}
+
+	tests := []struct {
+		market string
+		want   []string
+	}{
+		{"", []string{"1", "2"}},
+		{"from_token", []string{"1", "2"}},
+		{"US", []string{"1"}},
+		{"de", []string{"2"}},
+	}
+	for _, tt := range tests {
+		c := NewClient("client-id", "client-secret")
+		c.Market = tt.market
+		if got := trackIDs(c.PlayableTracks(tracks)); !slices.Equal(got, tt.want) {
+			t.Errorf("PlayableTracks() with market %q = %v, want %v", tt.market, got, tt.want)
+		}
+	}
+}
+
+func TestSearchDecodesAvailableMarkets(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, `{"tracks":{"items":[{"id":"t1","available_markets":["US","CA"]},{"id":"t2"}]}}`)
+	})
+
+	tracks, err := c.SearchTracks(context.Background(), "hello", 2)
+	if err != nil {
+		t.Fatalf("SearchTracks() error = %v", err)
+	}
+	if len(tracks) != 2 || !slices.Equal(tracks[0].AvailableMarkets, []string{"US", "CA"}) || tracks[1].AvailableMarkets != nil {
+		t.Errorf("SearchTracks() = %+v, want the first track's markets and none for the second", tracks)
+	}
+}